@@ -5,13 +5,17 @@ import (
 	"net/http"
 	"net/url"
 	"strings"
+	"time"
 
 	"kasir-api-go/docs"
 	"kasir-api-go/internal/config"
 	"kasir-api-go/internal/database"
 	"kasir-api-go/internal/handler"
+	"kasir-api-go/internal/middleware"
+	"kasir-api-go/internal/models"
 	"kasir-api-go/internal/repository"
 	"kasir-api-go/internal/service"
+	"kasir-api-go/internal/utils"
 
 	httpSwagger "github.com/swaggo/http-swagger"
 )
@@ -31,9 +35,18 @@ import (
 // @host localhost:8080
 // @BasePath /
 // @schemes http https
+
+// @securityDefinitions.apikey BearerAuth
+// @in header
+// @name Authorization
+// @description Type "Bearer" followed by a space and the access token.
 func main() {
 	// Load configuration
 	cfg := config.GetConfig()
+	if cfg.Auth.JWTSecret == "" {
+		fmt.Println("Invalid auth configuration:", utils.ErrEmptyJWTSecret)
+		return
+	}
 
 	// Database initialization
 	db, closeDB, err := database.NewPostgres(&cfg.Database)
@@ -52,6 +65,22 @@ func main() {
 	productRepo := repository.NewPostgresProductRepository(db)
 	transactionRepo := repository.NewPostgresTransactionRepository(db)
 	reportRepo := repository.NewPostgresReportRepository(db)
+	promotionRepo := repository.NewPostgresPromotionRepository(db)
+	taxRateRepo := repository.NewPostgresTaxRateRepository(db)
+	cartRepo := repository.NewPostgresCartRepository(db)
+	shiftRepo := repository.NewPostgresShiftRepository(db)
+	closingRepo := repository.NewPostgresClosingRepository(db)
+	userRepo := repository.NewPostgresUserRepository(db)
+	approvalRepo := repository.NewPostgresApprovalRepository(db)
+	stockMovementRepo := repository.NewPostgresStockMovementRepository(db)
+	roleRepo := repository.NewPostgresRoleRepository(db)
+	stockTakeRepo := repository.NewPostgresStockTakeRepository(db)
+	supplierRepo := repository.NewPostgresSupplierRepository(db)
+	purchaseOrderRepo := repository.NewPostgresPurchaseOrderRepository(db)
+	lowStockRepo := repository.NewPostgresLowStockRepository(db)
+	stockBatchRepo := repository.NewPostgresStockBatchRepository(db)
+	outletRepo := repository.NewPostgresOutletRepository(db)
+	stockTransferRepo := repository.NewPostgresStockTransferRepository(db)
 
 	// Update swagger info host and schemes dynamically
 	if cfg.App.URL != "" {
@@ -68,56 +97,344 @@ func main() {
 	}
 
 	// Services
-	categoryService := service.NewCategoryService(categoryRepo)
-	productService := service.NewProductService(productRepo, categoryRepo)
-	transactionService := service.NewTransactionService(transactionRepo, productRepo)
-	reportService := service.NewReportService(reportRepo)
+	authService := service.NewAuthService(userRepo, cfg.Auth.JWTSecret, time.Duration(cfg.Auth.AccessTokenTTLMinutes)*time.Minute,
+		time.Duration(cfg.Auth.RefreshTokenTTLHours)*time.Hour, time.Duration(cfg.Approval.TokenTTLSeconds)*time.Second)
+	categoryService := service.NewCategoryService(categoryRepo, taxRateRepo)
+	productService := service.NewProductService(productRepo, categoryRepo, taxRateRepo, stockMovementRepo, stockBatchRepo, authService)
+	transactionService := service.NewTransactionService(transactionRepo, productRepo, authService, cfg.Tax.ServiceChargeRate,
+		models.InvoiceFormat{Pattern: cfg.Invoice.Format, Digits: cfg.Invoice.SequenceDigits},
+		cfg.Approval.DiscountThresholdPercent, models.CostingMethod(cfg.Inventory.CostingMethod))
+	reportService := service.NewReportService(reportRepo, models.CostingMethod(cfg.Inventory.CostingMethod))
+	promotionService := service.NewPromotionService(promotionRepo, productRepo, categoryRepo)
+	taxRateService := service.NewTaxRateService(taxRateRepo)
+	cartService := service.NewCartService(cartRepo, productRepo, transactionService, time.Duration(cfg.Cart.TTLMinutes)*time.Minute)
+	receiptService := service.NewReceiptService(transactionRepo, outletRepo, cfg.Receipt)
+	shiftService := service.NewShiftService(shiftRepo, reportRepo)
+	closingService := service.NewClosingService(closingRepo)
+	userService := service.NewUserService(userRepo, roleRepo, outletRepo)
+	roleService := service.NewRoleService(roleRepo)
+	approvalService := service.NewApprovalService(approvalRepo)
+	stockTakeService := service.NewStockTakeService(stockTakeRepo)
+	supplierService := service.NewSupplierService(supplierRepo)
+	purchaseOrderService := service.NewPurchaseOrderService(purchaseOrderRepo, supplierRepo, outletRepo)
+	lowStockService := service.NewLowStockService(lowStockRepo, cfg.Inventory.ReorderWindowDays)
+	stockBatchService := service.NewStockBatchService(stockBatchRepo, cfg.Inventory.ExpiryWarningDays)
+	outletService := service.NewOutletService(outletRepo, cfg.Invoice.OutletCode)
+	stockTransferService := service.NewStockTransferService(stockTransferRepo, outletRepo, models.CostingMethod(cfg.Inventory.CostingMethod))
+
+	// Create the first owner account on an empty users table
+	if err := authService.EnsureAdmin(cfg.Auth.AdminUsername, cfg.Auth.AdminPassword); err != nil {
+		fmt.Println("failed to create owner user:", err)
+		return
+	}
+
+	// Terminals without an outlet of their own sell from the default outlet
+	if err := outletService.EnsureDefault(); err != nil {
+		fmt.Println("failed to create default outlet:", err)
+		return
+	}
 
 	// Handlers
 	categoryHandler := handler.NewCategoryHandler(categoryService)
 	productHandler := handler.NewProductHandler(productService)
 	transactionHandler := handler.NewTransactionHandler(transactionService)
 	reportHandler := handler.NewReportHandler(reportService)
+	promotionHandler := handler.NewPromotionHandler(promotionService)
+	taxRateHandler := handler.NewTaxRateHandler(taxRateService)
+	cartHandler := handler.NewCartHandler(cartService)
+	receiptHandler := handler.NewReceiptHandler(receiptService)
+	shiftHandler := handler.NewShiftHandler(shiftService)
+	closingHandler := handler.NewClosingHandler(closingService)
+	authHandler := handler.NewAuthHandler(authService)
+	userHandler := handler.NewUserHandler(userService)
+	roleHandler := handler.NewRoleHandler(roleService)
+	approvalHandler := handler.NewApprovalHandler(approvalService)
+	stockTakeHandler := handler.NewStockTakeHandler(stockTakeService)
+	supplierHandler := handler.NewSupplierHandler(supplierService)
+	purchaseOrderHandler := handler.NewPurchaseOrderHandler(purchaseOrderService)
+	lowStockHandler := handler.NewLowStockHandler(lowStockService)
+	stockBatchHandler := handler.NewStockBatchHandler(stockBatchService)
+	outletHandler := handler.NewOutletHandler(outletService)
+	stockTransferHandler := handler.NewStockTransferHandler(stockTransferService)
+
+	// Expire abandoned carts in the background
+	go func() {
+		ticker := time.NewTicker(time.Minute)
+		defer ticker.Stop()
+		for range ticker.C {
+			if _, err := cartService.ExpireStale(); err != nil {
+				fmt.Println("failed to expire carts:", err)
+			}
+		}
+	}()
 
 	// Get localhost:8080/health
 
 	// Get localhost:8080/health
 	http.HandleFunc("/health", handler.HealthHandler)
 
+	// Handle /api/auth/login, /pin-login, /refresh and /approval-token (POST) and /me (GET)
+	http.HandleFunc("/api/auth/login", authHandler.Login)
+	http.HandleFunc("/api/auth/pin-login", authHandler.PINLogin)
+	http.HandleFunc("/api/auth/refresh", authHandler.Refresh)
+	http.HandleFunc("/api/auth/approval-token", authHandler.IssueApprovalToken)
+	http.HandleFunc("/api/auth/me", authHandler.Me)
+
+	// Access rules: every other /api route is guarded by the permission it
+	// needs, so the rules live here rather than in the handlers.
+	guard := middleware.RequirePermission
+
+	// Handle /api/users (GET and POST)
+	http.HandleFunc("/api/users", guard(models.PermissionUsersManage, func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodPost {
+			userHandler.CreateUser(w, r)
+			return
+		}
+		userHandler.GetUsers(w, r)
+	}))
+
+	// Handle /api/users/{id} (GET, UPDATE AND DELETE)
+	http.HandleFunc("/api/users/", guard(models.PermissionUsersManage, func(w http.ResponseWriter, r *http.Request) {
+		idStr := strings.TrimPrefix(r.URL.Path, "/api/users/")
+		if idStr == "" {
+			return
+		}
+		switch r.Method {
+		case http.MethodGet:
+			userHandler.GetUserDetail(w, r)
+		case http.MethodPut:
+			userHandler.UpdateUser(w, r)
+		case http.MethodDelete:
+			userHandler.DeleteUser(w, r)
+		default:
+			w.WriteHeader(http.StatusMethodNotAllowed)
+		}
+	}))
+
+	// Handle /api/roles (GET and POST)
+	http.HandleFunc("/api/roles", guard(models.PermissionRolesManage, func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodPost {
+			roleHandler.CreateRole(w, r)
+			return
+		}
+		roleHandler.GetRoles(w, r)
+	}))
+
+	// Handle /api/roles/{name} (GET, UPDATE AND DELETE)
+	http.HandleFunc("/api/roles/", guard(models.PermissionRolesManage, func(w http.ResponseWriter, r *http.Request) {
+		name := strings.TrimPrefix(r.URL.Path, "/api/roles/")
+		if name == "" {
+			return
+		}
+		switch r.Method {
+		case http.MethodGet:
+			roleHandler.GetRoleDetail(w, r)
+		case http.MethodPut:
+			roleHandler.UpdateRole(w, r)
+		case http.MethodDelete:
+			roleHandler.DeleteRole(w, r)
+		default:
+			w.WriteHeader(http.StatusMethodNotAllowed)
+		}
+	}))
+
+	// Handle /api/permissions (GET)
+	http.HandleFunc("/api/permissions", guard(models.PermissionRolesManage, roleHandler.GetPermissions))
+
+	// Handle /api/outlets (GET and POST)
+	http.HandleFunc("/api/outlets", func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodPost {
+			guard(models.PermissionOutletsManage, outletHandler.CreateOutlet)(w, r)
+			return
+		}
+		guard(models.PermissionCatalogView, outletHandler.GetOutlets)(w, r)
+	})
+
+	// Handle /api/outlets/{id} (GET and UPDATE) and /stock (GET)
+	http.HandleFunc("/api/outlets/", func(w http.ResponseWriter, r *http.Request) {
+		idStr, action, _ := strings.Cut(strings.TrimPrefix(r.URL.Path, "/api/outlets/"), "/")
+		if idStr == "" {
+			return
+		}
+		switch {
+		case action == "" && r.Method == http.MethodGet:
+			guard(models.PermissionCatalogView, outletHandler.GetOutletDetail)(w, r)
+		case action == "" && r.Method == http.MethodPut:
+			guard(models.PermissionOutletsManage, outletHandler.UpdateOutlet)(w, r)
+		case action == "stock" && r.Method == http.MethodGet:
+			guard(models.PermissionCatalogView, outletHandler.GetOutletStock)(w, r)
+		default:
+			w.WriteHeader(http.StatusMethodNotAllowed)
+		}
+	})
+
 	// Handle /api/products (GET and POST)
 	http.HandleFunc("/api/products", func(w http.ResponseWriter, r *http.Request) {
 		if r.Method == http.MethodPost {
-			productHandler.CreateProduct(w, r)
+			guard(models.PermissionCatalogManage, productHandler.CreateProduct)(w, r)
 			return
 		}
-		productHandler.GetProducts(w, r)
+		guard(models.PermissionCatalogView, productHandler.GetProducts)(w, r)
 	})
 
-	// Handle /api/products/{id} (GET, UPDATE AND DELETE)
+	// Handle /api/products/{id} (GET, UPDATE AND DELETE), /variants (GET), /stock-history (GET) and /batches (GET)
 	http.HandleFunc("/api/products/", func(w http.ResponseWriter, r *http.Request) {
-		idStr := strings.TrimPrefix(r.URL.Path, "/api/products/")
+		idStr, action, _ := strings.Cut(strings.TrimPrefix(r.URL.Path, "/api/products/"), "/")
+		if idStr == "" {
+			return
+		}
+		switch {
+		case action == "" && r.Method == http.MethodGet:
+			guard(models.PermissionCatalogView, productHandler.GetProductDetail)(w, r)
+		case action == "" && r.Method == http.MethodPut:
+			guard(models.PermissionCatalogManage, productHandler.UpdateProduct)(w, r)
+		case action == "" && r.Method == http.MethodDelete:
+			guard(models.PermissionCatalogManage, productHandler.DeleteProduct)(w, r)
+		case action == "variants" && r.Method == http.MethodGet:
+			guard(models.PermissionCatalogView, productHandler.GetVariants)(w, r)
+		case action == "stock-history" && r.Method == http.MethodGet:
+			guard(models.PermissionCatalogView, productHandler.GetStockHistory)(w, r)
+		case action == "batches" && r.Method == http.MethodGet:
+			guard(models.PermissionCatalogView, productHandler.GetBatches)(w, r)
+		default:
+			w.WriteHeader(http.StatusMethodNotAllowed)
+		}
+	})
+
+	// Handle /api/inventory/low-stock (GET) and /events (GET)
+	http.HandleFunc("/api/inventory/low-stock", guard(models.PermissionInventoryManage, lowStockHandler.GetLowStock))
+	http.HandleFunc("/api/inventory/low-stock/events", guard(models.PermissionInventoryManage, lowStockHandler.GetLowStockEvents))
+
+	// Handle /api/inventory/expiring (GET)
+	http.HandleFunc("/api/inventory/expiring", guard(models.PermissionInventoryManage, stockBatchHandler.GetExpiringBatches))
+
+	// Handle /api/stock-takes (GET and POST)
+	http.HandleFunc("/api/stock-takes", func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodPost {
+			guard(models.PermissionInventoryManage, stockTakeHandler.OpenStockTake)(w, r)
+			return
+		}
+		guard(models.PermissionInventoryCount, stockTakeHandler.GetStockTakes)(w, r)
+	})
+
+	// Handle /api/stock-takes/{id} (GET), /counts, /approve and /cancel (POST)
+	http.HandleFunc("/api/stock-takes/", func(w http.ResponseWriter, r *http.Request) {
+		idStr, action, _ := strings.Cut(strings.TrimPrefix(r.URL.Path, "/api/stock-takes/"), "/")
+		if idStr == "" {
+			return
+		}
+		switch {
+		case action == "" && r.Method == http.MethodGet:
+			guard(models.PermissionInventoryCount, stockTakeHandler.GetStockTakeDetail)(w, r)
+		case action == "counts" && r.Method == http.MethodPost:
+			guard(models.PermissionInventoryCount, stockTakeHandler.SubmitCounts)(w, r)
+		case action == "approve" && r.Method == http.MethodPost:
+			guard(models.PermissionInventoryManage, stockTakeHandler.ApproveStockTake)(w, r)
+		case action == "cancel" && r.Method == http.MethodPost:
+			guard(models.PermissionInventoryManage, stockTakeHandler.CancelStockTake)(w, r)
+		default:
+			w.WriteHeader(http.StatusMethodNotAllowed)
+		}
+	})
+
+	// Handle /api/stock-transfers (GET and POST)
+	http.HandleFunc("/api/stock-transfers", guard(models.PermissionInventoryManage, func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodPost {
+			stockTransferHandler.CreateStockTransfer(w, r)
+			return
+		}
+		stockTransferHandler.GetStockTransfers(w, r)
+	}))
+
+	// Handle /api/stock-transfers/discrepancies and /api/stock-transfers/{id} (GET and UPDATE), /send, /receive and /cancel (POST)
+	http.HandleFunc("/api/stock-transfers/", guard(models.PermissionInventoryManage, func(w http.ResponseWriter, r *http.Request) {
+		idStr, action, _ := strings.Cut(strings.TrimPrefix(r.URL.Path, "/api/stock-transfers/"), "/")
+		if idStr == "" {
+			return
+		}
+		switch {
+		case idStr == "discrepancies" && action == "" && r.Method == http.MethodGet:
+			stockTransferHandler.GetDiscrepancyReport(w, r)
+		case action == "" && r.Method == http.MethodGet:
+			stockTransferHandler.GetStockTransferDetail(w, r)
+		case action == "" && r.Method == http.MethodPut:
+			stockTransferHandler.UpdateStockTransfer(w, r)
+		case action == "send" && r.Method == http.MethodPost:
+			stockTransferHandler.SendStockTransfer(w, r)
+		case action == "receive" && r.Method == http.MethodPost:
+			stockTransferHandler.ReceiveStockTransfer(w, r)
+		case action == "cancel" && r.Method == http.MethodPost:
+			stockTransferHandler.CancelStockTransfer(w, r)
+		default:
+			w.WriteHeader(http.StatusMethodNotAllowed)
+		}
+	}))
+
+	// Handle /api/suppliers (GET and POST)
+	http.HandleFunc("/api/suppliers", guard(models.PermissionPurchasingManage, func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodPost {
+			supplierHandler.CreateSupplier(w, r)
+			return
+		}
+		supplierHandler.GetSuppliers(w, r)
+	}))
+
+	// Handle /api/suppliers/{id} (GET, UPDATE AND DELETE)
+	http.HandleFunc("/api/suppliers/", guard(models.PermissionPurchasingManage, func(w http.ResponseWriter, r *http.Request) {
+		idStr := strings.TrimPrefix(r.URL.Path, "/api/suppliers/")
 		if idStr == "" {
 			return
 		}
 		switch r.Method {
 		case http.MethodGet:
-			productHandler.GetProductDetail(w, r)
+			supplierHandler.GetSupplierDetail(w, r)
 		case http.MethodPut:
-			productHandler.UpdateProduct(w, r)
+			supplierHandler.UpdateSupplier(w, r)
 		case http.MethodDelete:
-			productHandler.DeleteProduct(w, r)
+			supplierHandler.DeleteSupplier(w, r)
 		default:
 			w.WriteHeader(http.StatusMethodNotAllowed)
 		}
-	})
+	}))
+
+	// Handle /api/purchase-orders (GET and POST)
+	http.HandleFunc("/api/purchase-orders", guard(models.PermissionPurchasingManage, func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodPost {
+			purchaseOrderHandler.CreatePurchaseOrder(w, r)
+			return
+		}
+		purchaseOrderHandler.GetPurchaseOrders(w, r)
+	}))
+
+	// Handle /api/purchase-orders/outstanding and /api/purchase-orders/{id} (GET), /receipts, /returns and /cancel (POST)
+	http.HandleFunc("/api/purchase-orders/", guard(models.PermissionPurchasingManage, func(w http.ResponseWriter, r *http.Request) {
+		idStr, action, _ := strings.Cut(strings.TrimPrefix(r.URL.Path, "/api/purchase-orders/"), "/")
+		if idStr == "" {
+			return
+		}
+		switch {
+		case idStr == "outstanding" && action == "" && r.Method == http.MethodGet:
+			purchaseOrderHandler.GetOutstandingPurchaseOrders(w, r)
+		case action == "" && r.Method == http.MethodGet:
+			purchaseOrderHandler.GetPurchaseOrderDetail(w, r)
+		case action == "receipts" && r.Method == http.MethodPost:
+			purchaseOrderHandler.ReceiveGoods(w, r)
+		case action == "returns" && r.Method == http.MethodPost:
+			purchaseOrderHandler.ReturnGoods(w, r)
+		case action == "cancel" && r.Method == http.MethodPost:
+			purchaseOrderHandler.CancelPurchaseOrder(w, r)
+		default:
+			w.WriteHeader(http.StatusMethodNotAllowed)
+		}
+	}))
 
 	// Handle /api/categories (GET and POST)
 	http.HandleFunc("/api/categories", func(w http.ResponseWriter, r *http.Request) {
 		if r.Method == http.MethodPost {
-			categoryHandler.CreateCategory(w, r)
+			guard(models.PermissionCatalogManage, categoryHandler.CreateCategory)(w, r)
 			return
 		}
-		categoryHandler.GetCategories(w, r)
+		guard(models.PermissionCatalogView, categoryHandler.GetCategories)(w, r)
 	})
 
 	// Handle /api/categories/{id} (GET, UPDATE AND DELETE)
@@ -128,41 +445,194 @@ func main() {
 		}
 		switch r.Method {
 		case http.MethodGet:
-			categoryHandler.GetCategoryDetail(w, r)
+			guard(models.PermissionCatalogView, categoryHandler.GetCategoryDetail)(w, r)
+		case http.MethodPut:
+			guard(models.PermissionCatalogManage, categoryHandler.UpdateCategory)(w, r)
+		case http.MethodDelete:
+			guard(models.PermissionCatalogManage, categoryHandler.DeleteCategory)(w, r)
+		default:
+			w.WriteHeader(http.StatusMethodNotAllowed)
+		}
+	})
+
+	// Handle /api/promotions (GET and POST)
+	http.HandleFunc("/api/promotions", func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodPost {
+			guard(models.PermissionCatalogManage, promotionHandler.CreatePromotion)(w, r)
+			return
+		}
+		guard(models.PermissionCatalogView, promotionHandler.GetPromotions)(w, r)
+	})
+
+	// Handle /api/promotions/{id} (GET, UPDATE AND DELETE)
+	http.HandleFunc("/api/promotions/", func(w http.ResponseWriter, r *http.Request) {
+		idStr := strings.TrimPrefix(r.URL.Path, "/api/promotions/")
+		if idStr == "" {
+			return
+		}
+		switch r.Method {
+		case http.MethodGet:
+			guard(models.PermissionCatalogView, promotionHandler.GetPromotionDetail)(w, r)
 		case http.MethodPut:
-			categoryHandler.UpdateCategory(w, r)
+			guard(models.PermissionCatalogManage, promotionHandler.UpdatePromotion)(w, r)
 		case http.MethodDelete:
-			categoryHandler.DeleteCategory(w, r)
+			guard(models.PermissionCatalogManage, promotionHandler.DeletePromotion)(w, r)
+		default:
+			w.WriteHeader(http.StatusMethodNotAllowed)
+		}
+	})
+
+	// Handle /api/tax-rates (GET and POST)
+	http.HandleFunc("/api/tax-rates", func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodPost {
+			guard(models.PermissionCatalogManage, taxRateHandler.CreateTaxRate)(w, r)
+			return
+		}
+		guard(models.PermissionCatalogView, taxRateHandler.GetTaxRates)(w, r)
+	})
+
+	// Handle /api/tax-rates/{id} (GET, UPDATE AND DELETE)
+	http.HandleFunc("/api/tax-rates/", func(w http.ResponseWriter, r *http.Request) {
+		idStr := strings.TrimPrefix(r.URL.Path, "/api/tax-rates/")
+		if idStr == "" {
+			return
+		}
+		switch r.Method {
+		case http.MethodGet:
+			guard(models.PermissionCatalogView, taxRateHandler.GetTaxRateDetail)(w, r)
+		case http.MethodPut:
+			guard(models.PermissionCatalogManage, taxRateHandler.UpdateTaxRate)(w, r)
+		case http.MethodDelete:
+			guard(models.PermissionCatalogManage, taxRateHandler.DeleteTaxRate)(w, r)
 		default:
 			w.WriteHeader(http.StatusMethodNotAllowed)
 		}
 	})
 
 	// Handle /api/transactions (GET)
-	http.HandleFunc("/api/transactions", transactionHandler.GetTransactions)
+	http.HandleFunc("/api/transactions", guard(models.PermissionSalesCheckout, transactionHandler.GetTransactions))
 
 	// Handle /api/checkout (POST)
-	http.HandleFunc("/api/checkout", transactionHandler.HandleCheckout)
+	http.HandleFunc("/api/checkout", guard(models.PermissionSalesCheckout, transactionHandler.HandleCheckout))
 
-	// Handle /api/transactions/{id} (GET)
+	// Handle /api/transactions/{id} and /receipt (GET), /void and /refund (POST)
 	http.HandleFunc("/api/transactions/", func(w http.ResponseWriter, r *http.Request) {
-		idStr := strings.TrimPrefix(r.URL.Path, "/api/transactions/")
+		idStr, action, _ := strings.Cut(strings.TrimPrefix(r.URL.Path, "/api/transactions/"), "/")
 		if idStr == "" {
 			return
 		}
-		switch r.Method {
-		case http.MethodGet:
-			transactionHandler.GetTransactionDetail(w, r)
+		switch {
+		case action == "" && r.Method == http.MethodGet:
+			guard(models.PermissionSalesCheckout, transactionHandler.GetTransactionDetail)(w, r)
+		case action == "receipt" && r.Method == http.MethodGet:
+			guard(models.PermissionSalesCheckout, receiptHandler.GetReceipt)(w, r)
+		case action == "void" && r.Method == http.MethodPost:
+			// Cashiers may void and refund with a supervisor's approval
+			guard(models.PermissionSalesCheckout, transactionHandler.VoidTransaction)(w, r)
+		case action == "refund" && r.Method == http.MethodPost:
+			guard(models.PermissionSalesCheckout, transactionHandler.RefundTransaction)(w, r)
 		default:
 			w.WriteHeader(http.StatusMethodNotAllowed)
 		}
 	})
 
+	// Handle /api/carts (GET and POST)
+	http.HandleFunc("/api/carts", guard(models.PermissionSalesCheckout, func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodPost {
+			cartHandler.CreateCart(w, r)
+			return
+		}
+		cartHandler.GetCarts(w, r)
+	}))
+
+	// Handle /api/carts/{id} (GET), /items, /hold, /resume and /checkout
+	http.HandleFunc("/api/carts/", guard(models.PermissionSalesCheckout, func(w http.ResponseWriter, r *http.Request) {
+		parts := strings.Split(strings.TrimPrefix(r.URL.Path, "/api/carts/"), "/")
+		if parts[0] == "" {
+			return
+		}
+		action := ""
+		if len(parts) > 1 {
+			action = parts[1]
+		}
+		hasProduct := len(parts) > 2 && parts[2] != ""
+
+		switch {
+		case action == "" && r.Method == http.MethodGet:
+			cartHandler.GetCartDetail(w, r)
+		case action == "items" && !hasProduct && r.Method == http.MethodPost:
+			cartHandler.AddItem(w, r)
+		case action == "items" && hasProduct && r.Method == http.MethodPut:
+			cartHandler.UpdateItem(w, r)
+		case action == "items" && hasProduct && r.Method == http.MethodDelete:
+			cartHandler.RemoveItem(w, r)
+		case action == "hold" && r.Method == http.MethodPost:
+			cartHandler.HoldCart(w, r)
+		case action == "resume" && r.Method == http.MethodPost:
+			cartHandler.ResumeCart(w, r)
+		case action == "checkout" && r.Method == http.MethodPost:
+			cartHandler.CheckoutCart(w, r)
+		default:
+			w.WriteHeader(http.StatusMethodNotAllowed)
+		}
+	}))
+
+	// Handle /api/shifts (GET and POST)
+	http.HandleFunc("/api/shifts", guard(models.PermissionShiftsOperate, func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodPost {
+			shiftHandler.OpenShift(w, r)
+			return
+		}
+		shiftHandler.GetShifts(w, r)
+	}))
+
+	// Handle /api/shifts/current and /api/shifts/{id} (GET), /cash-movements and /close (POST)
+	http.HandleFunc("/api/shifts/", guard(models.PermissionShiftsOperate, func(w http.ResponseWriter, r *http.Request) {
+		idStr, action, _ := strings.Cut(strings.TrimPrefix(r.URL.Path, "/api/shifts/"), "/")
+		if idStr == "" {
+			return
+		}
+		switch {
+		case idStr == "current" && action == "" && r.Method == http.MethodGet:
+			shiftHandler.GetCurrentShift(w, r)
+		case action == "" && r.Method == http.MethodGet:
+			shiftHandler.GetShiftDetail(w, r)
+		case action == "cash-movements" && r.Method == http.MethodPost:
+			shiftHandler.AddCashMovement(w, r)
+		case action == "close" && r.Method == http.MethodPost:
+			shiftHandler.CloseShift(w, r)
+		default:
+			w.WriteHeader(http.StatusMethodNotAllowed)
+		}
+	}))
+
+	// Handle /api/closings (GET and POST)
+	http.HandleFunc("/api/closings", guard(models.PermissionClosingsManage, func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodPost {
+			closingHandler.CloseDay(w, r)
+			return
+		}
+		closingHandler.GetClosings(w, r)
+	}))
+
+	// Handle /api/closings/{date} (GET)
+	http.HandleFunc("/api/closings/", guard(models.PermissionClosingsManage, closingHandler.GetClosingDetail))
+
+	// Handle /api/approvals (GET)
+	http.HandleFunc("/api/approvals", guard(models.PermissionReportsView, approvalHandler.GetApprovals))
+
 	// Handle /api/report/today (GET)
-	http.HandleFunc("/api/report/today", reportHandler.GetTodayReport)
+	http.HandleFunc("/api/report/today", guard(models.PermissionReportsView, reportHandler.GetTodayReport))
+
+	// Handle /api/report/inventory-valuation (GET), which shows costs
+	http.HandleFunc("/api/report/inventory-valuation", guard(models.PermissionReportsView,
+		middleware.RequireCostView(reportHandler.GetInventoryValuation)))
+
+	// Handle /api/report/profit (GET), which shows costs
+	http.HandleFunc("/api/report/profit", guard(models.PermissionReportsView, middleware.RequireCostView(reportHandler.GetProfitReport)))
 
 	// Handle /api/report (GET)
-	http.HandleFunc("/api/report", reportHandler.GetReportByRange)
+	http.HandleFunc("/api/report", guard(models.PermissionReportsView, reportHandler.GetReportByRange))
 
 	// Serve static files from the "public" directory
 	// Assuming the app is run from the project root
@@ -175,7 +645,12 @@ func main() {
 	fmt.Printf("Starting server on http://localhost:%s\n", port)
 	fmt.Printf("Swagger documentation at http://localhost:%s/swagger/index.html\n", port)
 
-	err = http.ListenAndServe(":"+port, nil)
+	// Every /api route except signing in needs a bearer token, and works at
+	// the outlet of the terminal or the user
+	authMiddleware := middleware.Auth(authService, "/api/auth/login", "/api/auth/pin-login", "/api/auth/refresh")
+	outletMiddleware := middleware.Outlet(outletService)
+
+	err = http.ListenAndServe(":"+port, authMiddleware(outletMiddleware(http.DefaultServeMux)))
 
 	if err != nil {
 		fmt.Println("error starting server:", err)
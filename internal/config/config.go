@@ -9,8 +9,15 @@ import (
 )
 
 type Config struct {
-	App      AppConfig      `mapstructure:"app"`
-	Database DatabaseConfig `mapstructure:"database"`
+	App       AppConfig       `mapstructure:"app"`
+	Database  DatabaseConfig  `mapstructure:"database"`
+	Tax       TaxConfig       `mapstructure:"tax"`
+	Cart      CartConfig      `mapstructure:"cart"`
+	Receipt   ReceiptConfig   `mapstructure:"receipt"`
+	Invoice   InvoiceConfig   `mapstructure:"invoice"`
+	Auth      AuthConfig      `mapstructure:"auth"`
+	Approval  ApprovalConfig  `mapstructure:"approval"`
+	Inventory InventoryConfig `mapstructure:"inventory"`
 }
 
 type AppConfig struct {
@@ -25,6 +32,47 @@ type DatabaseConfig struct {
 	MaxIdleConns int    `mapstructure:"max_idle_conns"`
 }
 
+type TaxConfig struct {
+	ServiceChargeRate float64 `mapstructure:"service_charge_rate"`
+}
+
+type CartConfig struct {
+	TTLMinutes int `mapstructure:"ttl_minutes"`
+}
+
+type ReceiptConfig struct {
+	StoreName    string `mapstructure:"store_name"`
+	StoreAddress string `mapstructure:"store_address"`
+	StorePhone   string `mapstructure:"store_phone"`
+	Footer       string `mapstructure:"footer"`
+	PaperWidth   int    `mapstructure:"paper_width"`
+}
+
+type InvoiceConfig struct {
+	Format         string `mapstructure:"format"`
+	SequenceDigits int    `mapstructure:"sequence_digits"`
+	OutletCode     string `mapstructure:"outlet_code"`
+}
+
+type AuthConfig struct {
+	JWTSecret             string `mapstructure:"jwt_secret"`
+	AccessTokenTTLMinutes int    `mapstructure:"access_token_ttl_minutes"`
+	RefreshTokenTTLHours  int    `mapstructure:"refresh_token_ttl_hours"`
+	AdminUsername         string `mapstructure:"admin_username"`
+	AdminPassword         string `mapstructure:"admin_password"`
+}
+
+type ApprovalConfig struct {
+	DiscountThresholdPercent float64 `mapstructure:"discount_threshold_percent"`
+	TokenTTLSeconds          int     `mapstructure:"token_ttl_seconds"`
+}
+
+type InventoryConfig struct {
+	CostingMethod     string `mapstructure:"costing_method"`
+	ReorderWindowDays int    `mapstructure:"reorder_window_days"`
+	ExpiryWarningDays int    `mapstructure:"expiry_warning_days"`
+}
+
 var (
 	cfg  *Config
 	once sync.Once
@@ -46,6 +94,26 @@ func LoadConfig() *Config {
 	v.SetDefault("database.url", v.GetString("DATABASE_URL"))
 	v.SetDefault("database.max_open_conns", v.GetInt("DATABASE_MAX_OPEN_CONNS"))
 	v.SetDefault("database.max_idle_conns", v.GetInt("DATABASE_MAX_IDLE_CONNS"))
+	v.SetDefault("tax.service_charge_rate", v.GetFloat64("TAX_SERVICE_CHARGE_RATE"))
+	v.SetDefault("cart.ttl_minutes", v.GetInt("CART_TTL_MINUTES"))
+	v.SetDefault("receipt.store_name", v.GetString("RECEIPT_STORE_NAME"))
+	v.SetDefault("receipt.store_address", v.GetString("RECEIPT_STORE_ADDRESS"))
+	v.SetDefault("receipt.store_phone", v.GetString("RECEIPT_STORE_PHONE"))
+	v.SetDefault("receipt.footer", v.GetString("RECEIPT_FOOTER"))
+	v.SetDefault("receipt.paper_width", v.GetInt("RECEIPT_PAPER_WIDTH"))
+	v.SetDefault("invoice.format", v.GetString("INVOICE_FORMAT"))
+	v.SetDefault("invoice.sequence_digits", v.GetInt("INVOICE_SEQUENCE_DIGITS"))
+	v.SetDefault("invoice.outlet_code", v.GetString("INVOICE_OUTLET_CODE"))
+	v.SetDefault("auth.jwt_secret", v.GetString("AUTH_JWT_SECRET"))
+	v.SetDefault("auth.access_token_ttl_minutes", v.GetInt("AUTH_ACCESS_TOKEN_TTL_MINUTES"))
+	v.SetDefault("auth.refresh_token_ttl_hours", v.GetInt("AUTH_REFRESH_TOKEN_TTL_HOURS"))
+	v.SetDefault("auth.admin_username", v.GetString("AUTH_ADMIN_USERNAME"))
+	v.SetDefault("auth.admin_password", v.GetString("AUTH_ADMIN_PASSWORD"))
+	v.SetDefault("approval.discount_threshold_percent", v.GetFloat64("APPROVAL_DISCOUNT_THRESHOLD_PERCENT"))
+	v.SetDefault("approval.token_ttl_seconds", v.GetInt("APPROVAL_TOKEN_TTL_SECONDS"))
+	v.SetDefault("inventory.costing_method", v.GetString("INVENTORY_COSTING_METHOD"))
+	v.SetDefault("inventory.reorder_window_days", v.GetInt("INVENTORY_REORDER_WINDOW_DAYS"))
+	v.SetDefault("inventory.expiry_warning_days", v.GetInt("INVENTORY_EXPIRY_WARNING_DAYS"))
 
 	var config Config
 	if err := v.Unmarshal(&config); err != nil {
@@ -65,6 +133,54 @@ func LoadConfig() *Config {
 	if config.Database.MaxIdleConns == 0 {
 		config.Database.MaxIdleConns = 25
 	}
+	if config.Cart.TTLMinutes == 0 {
+		config.Cart.TTLMinutes = 240
+	}
+	if config.Receipt.StoreName == "" {
+		config.Receipt.StoreName = config.App.Name
+	}
+	if config.Receipt.PaperWidth == 0 {
+		config.Receipt.PaperWidth = 80
+	}
+	// The sequence starts over every day, so only the date tells the
+	// numbers of two days apart
+	if !strings.Contains(config.Invoice.Format, "{seq}") || !strings.Contains(config.Invoice.Format, "{date}") {
+		if config.Invoice.Format != "" {
+			log.Println("Invoice format must contain {date} and {seq}, using the default format")
+		}
+		config.Invoice.Format = "INV/{date}/{seq}"
+	}
+	if config.Invoice.SequenceDigits == 0 {
+		config.Invoice.SequenceDigits = 4
+	}
+	if config.Invoice.OutletCode == "" {
+		config.Invoice.OutletCode = "MAIN"
+	}
+	if config.Auth.AccessTokenTTLMinutes == 0 {
+		config.Auth.AccessTokenTTLMinutes = 15
+	}
+	if config.Auth.RefreshTokenTTLHours == 0 {
+		config.Auth.RefreshTokenTTLHours = 168
+	}
+	if config.Approval.DiscountThresholdPercent == 0 {
+		config.Approval.DiscountThresholdPercent = 10
+	}
+	if config.Approval.TokenTTLSeconds == 0 {
+		config.Approval.TokenTTLSeconds = 120
+	}
+	if config.Inventory.CostingMethod == "" {
+		config.Inventory.CostingMethod = "average"
+	}
+	if config.Inventory.CostingMethod != "average" && config.Inventory.CostingMethod != "fifo" {
+		log.Println("Inventory costing method must be average or fifo, using average")
+		config.Inventory.CostingMethod = "average"
+	}
+	if config.Inventory.ReorderWindowDays <= 0 {
+		config.Inventory.ReorderWindowDays = 30
+	}
+	if config.Inventory.ExpiryWarningDays <= 0 {
+		config.Inventory.ExpiryWarningDays = 30
+	}
 
 	return &config
 }
@@ -0,0 +1,37 @@
+package handler
+
+import (
+	"kasir-api-go/internal/models"
+	"kasir-api-go/internal/service"
+	"kasir-api-go/internal/utils"
+	"net/http"
+)
+
+type ApprovalHandler struct {
+	service service.ApprovalService
+}
+
+func NewApprovalHandler(service service.ApprovalService) *ApprovalHandler {
+	return &ApprovalHandler{
+		service: service,
+	}
+}
+
+// @Summary List approvals
+// @Description Get the audit trail of voids, refunds, price overrides, large discounts and price changes, newest first
+// @Tags approvals
+// @Security BearerAuth
+// @Produce json
+// @Param action query string false "Only approvals of this action (void, refund, price_override, discount or price_change)"
+// @Success 200 {object} utils.JSONResponse{data=[]models.Approval}
+// @Router /api/approvals [get]
+func (h *ApprovalHandler) GetApprovals(w http.ResponseWriter, r *http.Request) {
+	action := models.ApprovalAction(r.URL.Query().Get("action"))
+
+	approvals, err := h.service.GetAll(action)
+	if err != nil {
+		utils.ErrorResponse(w, http.StatusInternalServerError, "Failed to fetch approvals", err.Error())
+		return
+	}
+	utils.SuccessResponse(w, http.StatusOK, "Success", approvals)
+}
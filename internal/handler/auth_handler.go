@@ -0,0 +1,146 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"kasir-api-go/internal/middleware"
+	"kasir-api-go/internal/models"
+	"kasir-api-go/internal/service"
+	"kasir-api-go/internal/utils"
+	"net/http"
+)
+
+type AuthHandler struct {
+	service service.AuthService
+}
+
+func NewAuthHandler(service service.AuthService) *AuthHandler {
+	return &AuthHandler{
+		service: service,
+	}
+}
+
+// @Summary Log in
+// @Description Sign in with username and password and get an access and a refresh token
+// @Tags auth
+// @Accept json
+// @Produce json
+// @Param request body models.LoginRequest true "Login Request object"
+// @Success 200 {object} utils.JSONResponse{data=models.AuthTokens}
+// @Failure 401 {object} utils.JSONResponse
+// @Failure 429 {object} utils.JSONResponse
+// @Router /api/auth/login [post]
+func (h *AuthHandler) Login(w http.ResponseWriter, r *http.Request) {
+	var req models.LoginRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		utils.ErrorResponse(w, http.StatusBadRequest, "Invalid request body", err.Error())
+		return
+	}
+
+	tokens, err := h.service.Login(req)
+	if err != nil {
+		writeAuthError(w, err)
+		return
+	}
+	utils.SuccessResponse(w, http.StatusOK, "Logged in successfully", tokens)
+}
+
+// @Summary Log in with a PIN
+// @Description Quick sign-in at the till with username and a 4-6 digit PIN
+// @Tags auth
+// @Accept json
+// @Produce json
+// @Param request body models.PINLoginRequest true "PIN Login Request object"
+// @Success 200 {object} utils.JSONResponse{data=models.AuthTokens}
+// @Failure 401 {object} utils.JSONResponse
+// @Failure 429 {object} utils.JSONResponse
+// @Router /api/auth/pin-login [post]
+func (h *AuthHandler) PINLogin(w http.ResponseWriter, r *http.Request) {
+	var req models.PINLoginRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		utils.ErrorResponse(w, http.StatusBadRequest, "Invalid request body", err.Error())
+		return
+	}
+
+	tokens, err := h.service.PINLogin(req)
+	if err != nil {
+		writeAuthError(w, err)
+		return
+	}
+	utils.SuccessResponse(w, http.StatusOK, "Logged in successfully", tokens)
+}
+
+// @Summary Refresh tokens
+// @Description Exchange a refresh token for a new access and refresh token
+// @Tags auth
+// @Accept json
+// @Produce json
+// @Param request body models.RefreshTokenRequest true "Refresh Token Request object"
+// @Success 200 {object} utils.JSONResponse{data=models.AuthTokens}
+// @Failure 401 {object} utils.JSONResponse
+// @Router /api/auth/refresh [post]
+func (h *AuthHandler) Refresh(w http.ResponseWriter, r *http.Request) {
+	var req models.RefreshTokenRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		utils.ErrorResponse(w, http.StatusBadRequest, "Invalid request body", err.Error())
+		return
+	}
+
+	tokens, err := h.service.Refresh(req)
+	if err != nil {
+		writeAuthError(w, err)
+		return
+	}
+	utils.SuccessResponse(w, http.StatusOK, "Token refreshed successfully", tokens)
+}
+
+// @Summary Get the current user
+// @Description Get the user the access token belongs to
+// @Tags auth
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {object} utils.JSONResponse{data=models.User}
+// @Failure 401 {object} utils.JSONResponse
+// @Router /api/auth/me [get]
+func (h *AuthHandler) Me(w http.ResponseWriter, r *http.Request) {
+	user, ok := middleware.UserFromContext(r.Context())
+	if !ok {
+		utils.ErrorResponse(w, http.StatusUnauthorized, "Unauthorized", utils.ErrUnauthorized.Error())
+		return
+	}
+	utils.SuccessResponse(w, http.StatusOK, "Success", user)
+}
+
+// @Summary Issue an approval token
+// @Description Get a short-lived token with which the signed-in supervisor approves one of a cashier's voids, price overrides or large discounts; the token can be used once
+// @Tags auth
+// @Security BearerAuth
+// @Produce json
+// @Success 200 {object} utils.JSONResponse{data=models.ApprovalToken}
+// @Failure 401 {object} utils.JSONResponse
+// @Router /api/auth/approval-token [post]
+func (h *AuthHandler) IssueApprovalToken(w http.ResponseWriter, r *http.Request) {
+	user, ok := middleware.UserFromContext(r.Context())
+	if !ok {
+		utils.ErrorResponse(w, http.StatusUnauthorized, "Unauthorized", utils.ErrUnauthorized.Error())
+		return
+	}
+
+	token, err := h.service.IssueApprovalToken(user)
+	if err != nil {
+		utils.ErrorResponse(w, http.StatusInternalServerError, "Failed to issue approval token", err.Error())
+		return
+	}
+	utils.SuccessResponse(w, http.StatusOK, "Approval token issued successfully", token)
+}
+
+func writeAuthError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, utils.ErrInvalidCredentials), errors.Is(err, utils.ErrUnauthorized):
+		utils.ErrorResponse(w, http.StatusUnauthorized, err.Error(), "Unauthorized")
+	case errors.Is(err, utils.ErrAccountLocked):
+		utils.ErrorResponse(w, http.StatusTooManyRequests, err.Error(), "Too Many Requests")
+	default:
+		utils.ErrorResponse(w, http.StatusInternalServerError, "Failed to sign in", err.Error())
+	}
+}
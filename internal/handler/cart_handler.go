@@ -0,0 +1,295 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"kasir-api-go/internal/middleware"
+	"kasir-api-go/internal/models"
+	"kasir-api-go/internal/service"
+	"kasir-api-go/internal/utils"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+type CartHandler struct {
+	service service.CartService
+}
+
+func NewCartHandler(service service.CartService) *CartHandler {
+	return &CartHandler{
+		service: service,
+	}
+}
+
+// @Summary List carts
+// @Description Get open and held carts, or the carts in the given status
+// @Tags carts
+// @Security BearerAuth
+// @Produce json
+// @Param status query string false "Cart status (open, held, checked_out, expired)"
+// @Success 200 {object} utils.JSONResponse{data=[]models.Cart}
+// @Router /api/carts [get]
+func (h *CartHandler) GetCarts(w http.ResponseWriter, r *http.Request) {
+	status := models.CartStatus(r.URL.Query().Get("status"))
+	carts, err := h.service.GetAll(status)
+	if err != nil {
+		utils.ErrorResponse(w, http.StatusInternalServerError, "Failed to fetch carts", err.Error())
+		return
+	}
+	utils.SuccessResponse(w, http.StatusOK, "Success", carts)
+}
+
+// @Summary Create a cart
+// @Description Open a new cart at the caller's outlet, optionally labelled with a customer or table
+// @Tags carts
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param X-Outlet-Code header string false "Outlet code (defaults to the user's outlet, then the default outlet)"
+// @Param request body models.CreateCartRequest true "Create Cart Request object"
+// @Success 201 {object} utils.JSONResponse{data=models.Cart}
+// @Failure 400 {object} utils.JSONResponse
+// @Router /api/carts [post]
+func (h *CartHandler) CreateCart(w http.ResponseWriter, r *http.Request) {
+	var req models.CreateCartRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		utils.ErrorResponse(w, http.StatusBadRequest, "Invalid request body", err.Error())
+		return
+	}
+
+	outlet, _ := middleware.OutletFromContext(r.Context())
+	cart, err := h.service.Create(req, outlet)
+	if err != nil {
+		utils.ErrorResponse(w, http.StatusInternalServerError, "Failed to create cart", err.Error())
+		return
+	}
+	utils.SuccessResponse(w, http.StatusCreated, "Cart created successfully", cart)
+}
+
+// @Summary Get a cart detail
+// @Description Get a cart and its items by ID
+// @Tags carts
+// @Security BearerAuth
+// @Produce json
+// @Param id path int true "Cart ID"
+// @Success 200 {object} utils.JSONResponse{data=models.Cart}
+// @Failure 404 {object} utils.JSONResponse
+// @Router /api/carts/{id} [get]
+func (h *CartHandler) GetCartDetail(w http.ResponseWriter, r *http.Request) {
+	id, _, _ := cartPath(r.URL.Path)
+
+	cart, err := h.service.GetByID(id)
+	if err != nil {
+		writeCartError(w, err)
+		return
+	}
+	utils.SuccessResponse(w, http.StatusOK, "Success", cart)
+}
+
+// @Summary Add an item to a cart
+// @Description Add a product to an open cart, adding to the quantity if it is already there
+// @Tags carts
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param id path int true "Cart ID"
+// @Param request body models.CartItemRequest true "Cart Item Request object"
+// @Success 200 {object} utils.JSONResponse{data=models.Cart}
+// @Failure 400 {object} utils.JSONResponse
+// @Failure 404 {object} utils.JSONResponse
+// @Failure 409 {object} utils.JSONResponse
+// @Router /api/carts/{id}/items [post]
+func (h *CartHandler) AddItem(w http.ResponseWriter, r *http.Request) {
+	id, _, _ := cartPath(r.URL.Path)
+
+	var req models.CartItemRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		utils.ErrorResponse(w, http.StatusBadRequest, "Invalid request body", err.Error())
+		return
+	}
+
+	cart, err := h.service.AddItem(id, req)
+	if err != nil {
+		writeCartError(w, err)
+		return
+	}
+	utils.SuccessResponse(w, http.StatusOK, "Item added successfully", cart)
+}
+
+// @Summary Update a cart item
+// @Description Set the quantity of a product in an open cart; zero removes it
+// @Tags carts
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param id path int true "Cart ID"
+// @Param product_id path int true "Product ID"
+// @Param request body models.CartItemRequest true "Cart Item Request object"
+// @Success 200 {object} utils.JSONResponse{data=models.Cart}
+// @Failure 400 {object} utils.JSONResponse
+// @Failure 404 {object} utils.JSONResponse
+// @Failure 409 {object} utils.JSONResponse
+// @Router /api/carts/{id}/items/{product_id} [put]
+func (h *CartHandler) UpdateItem(w http.ResponseWriter, r *http.Request) {
+	id, _, productID := cartPath(r.URL.Path)
+
+	var req models.CartItemRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		utils.ErrorResponse(w, http.StatusBadRequest, "Invalid request body", err.Error())
+		return
+	}
+
+	cart, err := h.service.UpdateItem(id, productID, req.Quantity)
+	if err != nil {
+		writeCartError(w, err)
+		return
+	}
+	utils.SuccessResponse(w, http.StatusOK, "Item updated successfully", cart)
+}
+
+// @Summary Remove a cart item
+// @Description Remove a product from an open cart
+// @Tags carts
+// @Security BearerAuth
+// @Produce json
+// @Param id path int true "Cart ID"
+// @Param product_id path int true "Product ID"
+// @Success 200 {object} utils.JSONResponse{data=models.Cart}
+// @Failure 400 {object} utils.JSONResponse
+// @Failure 404 {object} utils.JSONResponse
+// @Failure 409 {object} utils.JSONResponse
+// @Router /api/carts/{id}/items/{product_id} [delete]
+func (h *CartHandler) RemoveItem(w http.ResponseWriter, r *http.Request) {
+	id, _, productID := cartPath(r.URL.Path)
+
+	cart, err := h.service.RemoveItem(id, productID)
+	if err != nil {
+		writeCartError(w, err)
+		return
+	}
+	utils.SuccessResponse(w, http.StatusOK, "Item removed successfully", cart)
+}
+
+// @Summary Hold a cart
+// @Description Park an open cart so the next customer can be served
+// @Tags carts
+// @Security BearerAuth
+// @Produce json
+// @Param id path int true "Cart ID"
+// @Success 200 {object} utils.JSONResponse{data=models.Cart}
+// @Failure 404 {object} utils.JSONResponse
+// @Failure 409 {object} utils.JSONResponse
+// @Router /api/carts/{id}/hold [post]
+func (h *CartHandler) HoldCart(w http.ResponseWriter, r *http.Request) {
+	id, _, _ := cartPath(r.URL.Path)
+
+	cart, err := h.service.Hold(id)
+	if err != nil {
+		writeCartError(w, err)
+		return
+	}
+	utils.SuccessResponse(w, http.StatusOK, "Cart held successfully", cart)
+}
+
+// @Summary Resume a cart
+// @Description Reopen a held cart for editing or checkout
+// @Tags carts
+// @Security BearerAuth
+// @Produce json
+// @Param id path int true "Cart ID"
+// @Success 200 {object} utils.JSONResponse{data=models.Cart}
+// @Failure 404 {object} utils.JSONResponse
+// @Failure 409 {object} utils.JSONResponse
+// @Router /api/carts/{id}/resume [post]
+func (h *CartHandler) ResumeCart(w http.ResponseWriter, r *http.Request) {
+	id, _, _ := cartPath(r.URL.Path)
+
+	cart, err := h.service.Resume(id)
+	if err != nil {
+		writeCartError(w, err)
+		return
+	}
+	utils.SuccessResponse(w, http.StatusOK, "Cart resumed successfully", cart)
+}
+
+// @Summary Checkout a cart
+// @Description Convert an open or held cart into a transaction and update stock, at the outlet the cart was opened at
+// @Tags carts
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param id path int true "Cart ID"
+// @Param Idempotency-Key header string false "Key that makes retries of the same checkout replay the original response"
+// @Param X-Outlet-Code header string false "Outlet selling (defaults to the user's outlet, then the default outlet)"
+// @Param request body models.CartCheckoutRequest true "Cart Checkout Request object"
+// @Success 201 {object} utils.JSONResponse{data=models.Transaction}
+// @Failure 400 {object} utils.JSONResponse
+// @Failure 403 {object} utils.JSONResponse
+// @Failure 404 {object} utils.JSONResponse
+// @Failure 409 {object} utils.JSONResponse
+// @Router /api/carts/{id}/checkout [post]
+func (h *CartHandler) CheckoutCart(w http.ResponseWriter, r *http.Request) {
+	id, _, _ := cartPath(r.URL.Path)
+
+	var req models.CartCheckoutRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		utils.ErrorResponse(w, http.StatusBadRequest, "Invalid request body", err.Error())
+		return
+	}
+	// Retries carrying the same key replay the original transaction
+	req.IdempotencyKey = strings.TrimSpace(r.Header.Get("Idempotency-Key"))
+	req.Outlet, _ = middleware.OutletFromContext(r.Context())
+	req.Actor, _ = middleware.UserFromContext(r.Context())
+
+	transaction, err := h.service.Checkout(id, req)
+	if err != nil {
+		writeCartCheckoutError(w, err)
+		return
+	}
+	utils.SuccessResponse(w, http.StatusCreated, "Cart checked out successfully", transactionFor(r, transaction))
+}
+
+func writeCartError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, utils.ErrCartNotFound):
+		utils.ErrorResponse(w, http.StatusNotFound, "Cart not found", err.Error())
+	case errors.Is(err, utils.ErrCartNotOpen), errors.Is(err, utils.ErrCartChanged), errors.Is(err, utils.ErrDayClosed):
+		utils.ErrorResponse(w, http.StatusConflict, err.Error(), "Conflict")
+	case errors.Is(err, utils.ErrInvalidCartItem), errors.Is(err, utils.ErrCartEmpty), errors.Is(err, utils.ErrInvalidVariant):
+		utils.ErrorResponse(w, http.StatusBadRequest, err.Error(), "Bad Request")
+	default:
+		utils.ErrorResponse(w, http.StatusInternalServerError, "Failed to process cart", err.Error())
+	}
+}
+
+// writeCartCheckoutError maps the errors of the checkout a cart goes through
+// the same way /api/checkout does.
+func writeCartCheckoutError(w http.ResponseWriter, err error) {
+	switch {
+	case isPaymentError(err), errors.Is(err, utils.ErrInvalidIdempotencyKey), errors.Is(err, utils.ErrInvalidOverride),
+		errors.Is(err, utils.ErrInvalidItemQuantity):
+		utils.ErrorResponse(w, http.StatusBadRequest, err.Error(), "Bad Request")
+	case errors.Is(err, utils.ErrCartOtherOutlet), errors.Is(err, utils.ErrApprovalRequired), errors.Is(err, utils.ErrApprovalDenied):
+		utils.ErrorResponse(w, http.StatusForbidden, err.Error(), "Forbidden")
+	case errors.Is(err, utils.ErrIdempotencyKeyConflict):
+		utils.ErrorResponse(w, http.StatusConflict, err.Error(), "Conflict")
+	case errors.Is(err, utils.ErrAccountLocked):
+		utils.ErrorResponse(w, http.StatusTooManyRequests, err.Error(), "Too Many Requests")
+	default:
+		writeCartError(w, err)
+	}
+}
+
+// cartPath reads /api/carts/{id}[/{action}[/{product_id}]].
+func cartPath(path string) (id int, action string, productID int) {
+	parts := strings.Split(strings.TrimPrefix(path, "/api/carts/"), "/")
+	id, _ = strconv.Atoi(parts[0])
+	if len(parts) > 1 {
+		action = parts[1]
+	}
+	if len(parts) > 2 {
+		productID, _ = strconv.Atoi(parts[2])
+	}
+	return id, action, productID
+}
@@ -23,6 +23,7 @@ func NewCategoryHandler(service service.CategoryService) *CategoryHandler {
 // @Summary List all categories
 // @Description Get a list of all categories
 // @Tags categories
+// @Security BearerAuth
 // @Produce json
 // @Success 200 {object} utils.JSONResponse{data=[]models.Category}
 // @Router /api/categories [get]
@@ -34,6 +35,7 @@ func (h *CategoryHandler) GetCategories(w http.ResponseWriter, r *http.Request)
 // @Summary Create a new category
 // @Description Add a new category to the catalog
 // @Tags categories
+// @Security BearerAuth
 // @Accept json
 // @Produce json
 // @Param category body models.Category true "Category object"
@@ -54,6 +56,11 @@ func (h *CategoryHandler) CreateCategory(w http.ResponseWriter, r *http.Request)
 		return
 	}
 
+	if err != nil && err.Error() == "tax rate not found" {
+		utils.ErrorResponse(w, http.StatusBadRequest, err.Error(), "Invalid Tax Rate ID")
+		return
+	}
+
 	if err != nil {
 		utils.ErrorResponse(w, http.StatusBadRequest, err.Error(), "Bad Request")
 		return
@@ -65,6 +72,7 @@ func (h *CategoryHandler) CreateCategory(w http.ResponseWriter, r *http.Request)
 // @Summary Get a category detail
 // @Description Get details of a category by ID
 // @Tags categories
+// @Security BearerAuth
 // @Produce json
 // @Param id path int true "Category ID"
 // @Success 200 {object} utils.JSONResponse{data=models.Category}
@@ -85,6 +93,7 @@ func (h *CategoryHandler) GetCategoryDetail(w http.ResponseWriter, r *http.Reque
 // @Summary Update a category
 // @Description Update an existing category's details
 // @Tags categories
+// @Security BearerAuth
 // @Accept json
 // @Produce json
 // @Param id path int true "Category ID"
@@ -105,6 +114,11 @@ func (h *CategoryHandler) UpdateCategory(w http.ResponseWriter, r *http.Request)
 
 	category.ID = id
 	updatedCategory, err := h.service.Update(id, category)
+	if err != nil && err.Error() == "tax rate not found" {
+		utils.ErrorResponse(w, http.StatusBadRequest, err.Error(), "Invalid Tax Rate ID")
+		return
+	}
+
 	if err != nil {
 		utils.ErrorResponse(w, http.StatusNotFound, "Category not found", "Category not found")
 		return
@@ -115,6 +129,7 @@ func (h *CategoryHandler) UpdateCategory(w http.ResponseWriter, r *http.Request)
 // @Summary Delete a category
 // @Description Remove a category from the catalog
 // @Tags categories
+// @Security BearerAuth
 // @Produce json
 // @Param id path int true "Category ID"
 // @Success 200 {object} utils.JSONResponse
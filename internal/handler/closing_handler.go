@@ -0,0 +1,103 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"kasir-api-go/internal/middleware"
+	"kasir-api-go/internal/models"
+	"kasir-api-go/internal/service"
+	"kasir-api-go/internal/utils"
+	"net/http"
+	"strings"
+)
+
+type ClosingHandler struct {
+	service service.ClosingService
+}
+
+func NewClosingHandler(service service.ClosingService) *ClosingHandler {
+	return &ClosingHandler{
+		service: service,
+	}
+}
+
+// @Summary List daily closings
+// @Description Get the stored Z-reports of the closed days of the outlet, most recent first
+// @Tags closings
+// @Security BearerAuth
+// @Produce json
+// @Param X-Outlet-Code header string false "Outlet code (defaults to the user's outlet, then the default outlet)"
+// @Success 200 {object} utils.JSONResponse{data=[]models.DailyClosing}
+// @Router /api/closings [get]
+func (h *ClosingHandler) GetClosings(w http.ResponseWriter, r *http.Request) {
+	outlet, _ := middleware.OutletFromContext(r.Context())
+	closings, err := h.service.GetAll(outlet.Code)
+	if err != nil {
+		utils.ErrorResponse(w, http.StatusInternalServerError, "Failed to fetch closings", err.Error())
+		return
+	}
+	utils.SuccessResponse(w, http.StatusOK, "Success", closings)
+}
+
+// @Summary Close a business day
+// @Description Freeze the day's figures into a Z-report, recorded as closed by the signed-in user, and lock the day against new sales, voids and refunds
+// @Tags closings
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param X-Outlet-Code header string false "Outlet code (defaults to the user's outlet, then the default outlet)"
+// @Param request body models.CloseDayRequest true "Close Day Request object"
+// @Success 201 {object} utils.JSONResponse{data=models.DailyClosing}
+// @Failure 400 {object} utils.JSONResponse
+// @Failure 409 {object} utils.JSONResponse
+// @Router /api/closings [post]
+func (h *ClosingHandler) CloseDay(w http.ResponseWriter, r *http.Request) {
+	var req models.CloseDayRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		utils.ErrorResponse(w, http.StatusBadRequest, "Invalid request body", err.Error())
+		return
+	}
+
+	actor, _ := middleware.UserFromContext(r.Context())
+	outlet, _ := middleware.OutletFromContext(r.Context())
+	closing, err := h.service.CloseDay(req, outlet.Code, actor)
+	if err != nil {
+		writeClosingError(w, err)
+		return
+	}
+	utils.SuccessResponse(w, http.StatusCreated, "Day closed successfully", closing)
+}
+
+// @Summary Get a daily closing
+// @Description Get the stored Z-report of a closed day
+// @Tags closings
+// @Security BearerAuth
+// @Produce json
+// @Param date path string true "Business date (YYYY-MM-DD)"
+// @Param X-Outlet-Code header string false "Outlet code (defaults to the user's outlet, then the default outlet)"
+// @Success 200 {object} utils.JSONResponse{data=models.DailyClosing}
+// @Failure 400 {object} utils.JSONResponse
+// @Failure 404 {object} utils.JSONResponse
+// @Router /api/closings/{date} [get]
+func (h *ClosingHandler) GetClosingDetail(w http.ResponseWriter, r *http.Request) {
+	outlet, _ := middleware.OutletFromContext(r.Context())
+	closing, err := h.service.GetByDate(strings.TrimPrefix(r.URL.Path, "/api/closings/"), outlet.Code)
+	if err != nil {
+		writeClosingError(w, err)
+		return
+	}
+	utils.SuccessResponse(w, http.StatusOK, "Success", closing)
+}
+
+func writeClosingError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, utils.ErrClosingNotFound):
+		utils.ErrorResponse(w, http.StatusNotFound, err.Error(), "Not Found")
+	case errors.Is(err, utils.ErrDayClosed):
+		utils.ErrorResponse(w, http.StatusConflict, err.Error(), "Conflict")
+	case errors.Is(err, utils.ErrInvalidClosing):
+		utils.ErrorResponse(w, http.StatusBadRequest, err.Error(), "Bad Request")
+	default:
+		utils.ErrorResponse(w, http.StatusInternalServerError, "Failed to process closing", err.Error())
+	}
+}
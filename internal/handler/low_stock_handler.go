@@ -0,0 +1,58 @@
+package handler
+
+import (
+	"kasir-api-go/internal/service"
+	"kasir-api-go/internal/utils"
+	"net/http"
+	"strconv"
+)
+
+type LowStockHandler struct {
+	service service.LowStockService
+}
+
+func NewLowStockHandler(service service.LowStockService) *LowStockHandler {
+	return &LowStockHandler{
+		service: service,
+	}
+}
+
+// @Summary List products below their minimum stock
+// @Description Get the products below their minimum stock with a suggested order quantity, based on the average daily sales over the window
+// @Tags inventory
+// @Security BearerAuth
+// @Produce json
+// @Param window_days query int false "Days of sales to average (defaults to INVENTORY_REORDER_WINDOW_DAYS)"
+// @Param outlet query string false "Outlet code (defaults to all outlets)"
+// @Success 200 {object} utils.JSONResponse{data=models.LowStockReport}
+// @Router /api/inventory/low-stock [get]
+func (h *LowStockHandler) GetLowStock(w http.ResponseWriter, r *http.Request) {
+	windowDays, _ := strconv.Atoi(r.URL.Query().Get("window_days"))
+
+	report, err := h.service.GetLowStock(windowDays, r.URL.Query().Get("outlet"))
+	if err != nil {
+		utils.ErrorResponse(w, http.StatusInternalServerError, "Failed to fetch low stock products", err.Error())
+		return
+	}
+	utils.SuccessResponse(w, http.StatusOK, "Success", report)
+}
+
+// @Summary List low-stock events
+// @Description Get the latest low-stock events raised by checkout, newest first
+// @Tags inventory
+// @Security BearerAuth
+// @Produce json
+// @Param limit query int false "Number of events (default 100, at most 500)"
+// @Param outlet query string false "Outlet code (defaults to all outlets)"
+// @Success 200 {object} utils.JSONResponse{data=[]models.LowStockEvent}
+// @Router /api/inventory/low-stock/events [get]
+func (h *LowStockHandler) GetLowStockEvents(w http.ResponseWriter, r *http.Request) {
+	limit, _ := strconv.Atoi(r.URL.Query().Get("limit"))
+
+	events, err := h.service.GetEvents(limit, r.URL.Query().Get("outlet"))
+	if err != nil {
+		utils.ErrorResponse(w, http.StatusInternalServerError, "Failed to fetch low stock events", err.Error())
+		return
+	}
+	utils.SuccessResponse(w, http.StatusOK, "Success", events)
+}
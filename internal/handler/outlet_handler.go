@@ -0,0 +1,149 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"kasir-api-go/internal/models"
+	"kasir-api-go/internal/service"
+	"kasir-api-go/internal/utils"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+type OutletHandler struct {
+	service service.OutletService
+}
+
+func NewOutletHandler(service service.OutletService) *OutletHandler {
+	return &OutletHandler{
+		service: service,
+	}
+}
+
+// @Summary List all outlets
+// @Description Get a list of all outlets
+// @Tags outlets
+// @Security BearerAuth
+// @Produce json
+// @Success 200 {object} utils.JSONResponse{data=[]models.Outlet}
+// @Router /api/outlets [get]
+func (h *OutletHandler) GetOutlets(w http.ResponseWriter, r *http.Request) {
+	outlets, err := h.service.GetAll()
+	if err != nil {
+		utils.ErrorResponse(w, http.StatusInternalServerError, "Failed to fetch outlets", err.Error())
+		return
+	}
+	utils.SuccessResponse(w, http.StatusOK, "Success", outlets)
+}
+
+// @Summary Create a new outlet
+// @Description Add a store or warehouse with its own stock. The code cannot be changed afterwards.
+// @Tags outlets
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param outlet body models.Outlet true "Outlet object"
+// @Success 201 {object} utils.JSONResponse{data=models.Outlet}
+// @Failure 400 {object} utils.JSONResponse
+// @Failure 409 {object} utils.JSONResponse
+// @Router /api/outlets [post]
+func (h *OutletHandler) CreateOutlet(w http.ResponseWriter, r *http.Request) {
+	var outlet models.Outlet
+	if err := json.NewDecoder(r.Body).Decode(&outlet); err != nil {
+		utils.ErrorResponse(w, http.StatusBadRequest, "Invalid request body", err.Error())
+		return
+	}
+
+	createdOutlet, err := h.service.Create(outlet)
+	if err != nil {
+		writeOutletError(w, err)
+		return
+	}
+	utils.SuccessResponse(w, http.StatusCreated, "Outlet created successfully", createdOutlet)
+}
+
+// @Summary Get an outlet detail
+// @Description Get details of an outlet by ID
+// @Tags outlets
+// @Security BearerAuth
+// @Produce json
+// @Param id path int true "Outlet ID"
+// @Success 200 {object} utils.JSONResponse{data=models.Outlet}
+// @Failure 404 {object} utils.JSONResponse
+// @Router /api/outlets/{id} [get]
+func (h *OutletHandler) GetOutletDetail(w http.ResponseWriter, r *http.Request) {
+	idStr := strings.TrimPrefix(r.URL.Path, "/api/outlets/")
+	id, _ := strconv.Atoi(idStr)
+
+	outlet, err := h.service.GetByID(id)
+	if err != nil {
+		writeOutletError(w, err)
+		return
+	}
+	utils.SuccessResponse(w, http.StatusOK, "Success", outlet)
+}
+
+// @Summary Update an outlet
+// @Description Update the name and address of an outlet; the code stays as it is
+// @Tags outlets
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param id path int true "Outlet ID"
+// @Param outlet body models.Outlet true "Outlet object"
+// @Success 200 {object} utils.JSONResponse{data=models.Outlet}
+// @Failure 400 {object} utils.JSONResponse
+// @Failure 404 {object} utils.JSONResponse
+// @Router /api/outlets/{id} [put]
+func (h *OutletHandler) UpdateOutlet(w http.ResponseWriter, r *http.Request) {
+	idStr := strings.TrimPrefix(r.URL.Path, "/api/outlets/")
+	id, _ := strconv.Atoi(idStr)
+
+	var outlet models.Outlet
+	if err := json.NewDecoder(r.Body).Decode(&outlet); err != nil {
+		utils.ErrorResponse(w, http.StatusBadRequest, "Invalid request body", err.Error())
+		return
+	}
+
+	updatedOutlet, err := h.service.Update(id, outlet)
+	if err != nil {
+		writeOutletError(w, err)
+		return
+	}
+	utils.SuccessResponse(w, http.StatusOK, "Outlet updated successfully", updatedOutlet)
+}
+
+// @Summary Get the stock of an outlet
+// @Description Get the stock of every product at an outlet
+// @Tags outlets
+// @Security BearerAuth
+// @Produce json
+// @Param id path int true "Outlet ID"
+// @Success 200 {object} utils.JSONResponse{data=[]models.OutletStock}
+// @Failure 404 {object} utils.JSONResponse
+// @Router /api/outlets/{id}/stock [get]
+func (h *OutletHandler) GetOutletStock(w http.ResponseWriter, r *http.Request) {
+	idStr := strings.TrimSuffix(strings.TrimPrefix(r.URL.Path, "/api/outlets/"), "/stock")
+	id, _ := strconv.Atoi(idStr)
+
+	stock, err := h.service.GetStock(id)
+	if err != nil {
+		writeOutletError(w, err)
+		return
+	}
+	utils.SuccessResponse(w, http.StatusOK, "Success", stock)
+}
+
+func writeOutletError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, utils.ErrOutletNotFound):
+		utils.ErrorResponse(w, http.StatusNotFound, err.Error(), "Not Found")
+	case errors.Is(err, utils.ErrOutletExists):
+		utils.ErrorResponse(w, http.StatusConflict, err.Error(), "Conflict")
+	case errors.Is(err, utils.ErrInvalidOutlet):
+		utils.ErrorResponse(w, http.StatusBadRequest, err.Error(), "Bad Request")
+	default:
+		utils.ErrorResponse(w, http.StatusInternalServerError, "Failed to process outlet", err.Error())
+	}
+}
@@ -2,6 +2,8 @@ package handler
 
 import (
 	"encoding/json"
+	"errors"
+	"kasir-api-go/internal/middleware"
 	"kasir-api-go/internal/models"
 	"kasir-api-go/internal/service"
 	"kasir-api-go/internal/utils"
@@ -21,27 +23,31 @@ func NewProductHandler(service service.ProductService) *ProductHandler {
 }
 
 // @Summary List all products
-// @Description Get a list of all products, optionally filtered by name
+// @Description Get a list of all products and variants, optionally filtered by name, or by an exact SKU or barcode
 // @Tags products
+// @Security BearerAuth
 // @Produce json
-// @Param search query string false "Search products by name"
+// @Param search query string false "Search products by name, SKU or barcode"
 // @Success 200 {object} utils.JSONResponse{data=[]models.Product}
 // @Router /api/products [get]
 func (h *ProductHandler) GetProducts(w http.ResponseWriter, r *http.Request) {
 	search := r.URL.Query().Get("search")
 	products := h.service.GetAll(search)
-	utils.SuccessResponse(w, http.StatusOK, "Success", products)
+	utils.SuccessResponse(w, http.StatusOK, "Success", productsFor(r, products))
 }
 
 // @Summary Create a new product
-// @Description Add a new product to the catalog
+// @Description Add a new product to the catalog. The opening stock goes in at the caller's outlet. With a parent_id and variant_name the product is a variant of that product, named after it and sharing its category and tax rate; its price defaults to the parent's.
 // @Tags products
+// @Security BearerAuth
 // @Accept json
 // @Produce json
+// @Param X-Outlet-Code header string false "Outlet code (defaults to the user's outlet, then the default outlet)"
 // @Param product body models.Product true "Product object"
 // @Success 201 {object} utils.JSONResponse{data=models.Product}
 // @Failure 400 {object} utils.JSONResponse
 // @Failure 409 {object} utils.JSONResponse
+// @Failure 500 {object} utils.JSONResponse
 // @Router /api/products [post]
 func (h *ProductHandler) CreateProduct(w http.ResponseWriter, r *http.Request) {
 	var product models.Product
@@ -50,28 +56,46 @@ func (h *ProductHandler) CreateProduct(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	createdProduct, err := h.service.Create(product)
+	actor, _ := middleware.UserFromContext(r.Context())
+	outlet, _ := middleware.OutletFromContext(r.Context())
+	createdProduct, err := h.service.Create(product, actor, outlet.ID)
 	if err != nil && err.Error() == "product ID already exists" {
 		utils.ErrorResponse(w, http.StatusConflict, err.Error(), "Duplicate ID")
 		return
 	}
 
+	if err != nil && isDuplicateProductCode(err) {
+		utils.ErrorResponse(w, http.StatusConflict, err.Error(), "Conflict")
+		return
+	}
+
 	if err != nil && err.Error() == "category not found" {
 		utils.ErrorResponse(w, http.StatusBadRequest, err.Error(), "Invalid Category ID")
 		return
 	}
 
+	if err != nil && err.Error() == "tax rate not found" {
+		utils.ErrorResponse(w, http.StatusBadRequest, err.Error(), "Invalid Tax Rate ID")
+		return
+	}
+
+	if errors.Is(err, utils.ErrProductNotSaved) {
+		utils.ErrorResponse(w, http.StatusInternalServerError, "Failed to create product", err.Error())
+		return
+	}
+
 	if err != nil {
 		utils.ErrorResponse(w, http.StatusBadRequest, err.Error(), "Bad Request")
 		return
 	}
 
-	utils.SuccessResponse(w, http.StatusCreated, "Product created successfully", createdProduct)
+	utils.SuccessResponse(w, http.StatusCreated, "Product created successfully", productFor(r, createdProduct))
 }
 
 // @Summary Get a product detail
-// @Description Get details of a product by ID
+// @Description Get details of a product by ID with its stock at each outlet and its variants
 // @Tags products
+// @Security BearerAuth
 // @Produce json
 // @Param id path int true "Product ID"
 // @Success 200 {object} utils.JSONResponse{data=models.Product}
@@ -86,47 +110,159 @@ func (h *ProductHandler) GetProductDetail(w http.ResponseWriter, r *http.Request
 		utils.ErrorResponse(w, http.StatusNotFound, "Product not found", "Product not found")
 		return
 	}
-	utils.SuccessResponse(w, http.StatusOK, "Success", product)
+	utils.SuccessResponse(w, http.StatusOK, "Success", productFor(r, product))
+}
+
+// @Summary Get the variants of a product
+// @Description Get the variants of a product, like its sizes and colours, each with its own SKU, barcode, price and stock
+// @Tags products
+// @Security BearerAuth
+// @Produce json
+// @Param id path int true "Product ID"
+// @Success 200 {object} utils.JSONResponse{data=[]models.Product}
+// @Failure 404 {object} utils.JSONResponse
+// @Router /api/products/{id}/variants [get]
+func (h *ProductHandler) GetVariants(w http.ResponseWriter, r *http.Request) {
+	idStr, _, _ := strings.Cut(strings.TrimPrefix(r.URL.Path, "/api/products/"), "/")
+	id, _ := strconv.Atoi(idStr)
+
+	variants, err := h.service.GetVariants(id)
+	if err != nil {
+		utils.ErrorResponse(w, http.StatusNotFound, "Product not found", "Product not found")
+		return
+	}
+	utils.SuccessResponse(w, http.StatusOK, "Success", productsFor(r, variants))
+}
+
+// @Summary Get the stock history of a product
+// @Description Get the stock ledger of a product, newest first: every sale, refund, adjustment, receipt and transfer with the stock after it
+// @Tags products
+// @Security BearerAuth
+// @Produce json
+// @Param id path int true "Product ID"
+// @Success 200 {object} utils.JSONResponse{data=[]models.StockMovement}
+// @Failure 404 {object} utils.JSONResponse
+// @Router /api/products/{id}/stock-history [get]
+func (h *ProductHandler) GetStockHistory(w http.ResponseWriter, r *http.Request) {
+	idStr, _, _ := strings.Cut(strings.TrimPrefix(r.URL.Path, "/api/products/"), "/")
+	id, _ := strconv.Atoi(idStr)
+
+	movements, err := h.service.GetStockHistory(id)
+	if err != nil && err.Error() == "product not found" {
+		utils.ErrorResponse(w, http.StatusNotFound, "Product not found", "Product not found")
+		return
+	}
+
+	if err != nil {
+		utils.ErrorResponse(w, http.StatusInternalServerError, "Failed to fetch stock history", err.Error())
+		return
+	}
+	utils.SuccessResponse(w, http.StatusOK, "Success", movements)
+}
+
+// @Summary Get the stock batches of a product
+// @Description Get the batches of a product still in stock at each outlet with their expiry dates, in the order they are sold
+// @Tags products
+// @Security BearerAuth
+// @Produce json
+// @Param id path int true "Product ID"
+// @Success 200 {object} utils.JSONResponse{data=[]models.StockBatch}
+// @Failure 404 {object} utils.JSONResponse
+// @Router /api/products/{id}/batches [get]
+func (h *ProductHandler) GetBatches(w http.ResponseWriter, r *http.Request) {
+	idStr, _, _ := strings.Cut(strings.TrimPrefix(r.URL.Path, "/api/products/"), "/")
+	id, _ := strconv.Atoi(idStr)
+
+	batches, err := h.service.GetBatches(id)
+	if err != nil && err.Error() == "product not found" {
+		utils.ErrorResponse(w, http.StatusNotFound, "Product not found", "Product not found")
+		return
+	}
+
+	if err != nil {
+		utils.ErrorResponse(w, http.StatusInternalServerError, "Failed to fetch batches", err.Error())
+		return
+	}
+	utils.SuccessResponse(w, http.StatusOK, "Success", batches)
 }
 
 // @Summary Update a product
-// @Description Update an existing product's details
+// @Description Update an existing product's details. A change of the total stock is made at the caller's outlet. Changing the price needs the prices.override permission or a supervisor approval. A variant stays under its parent; changing a parent renames its variants and gives them its category and tax rate.
 // @Tags products
+// @Security BearerAuth
 // @Accept json
 // @Produce json
 // @Param id path int true "Product ID"
-// @Param product body models.Product true "Product object"
+// @Param X-Outlet-Code header string false "Outlet code (defaults to the user's outlet, then the default outlet)"
+// @Param product body models.UpdateProductRequest true "Product object"
 // @Success 200 {object} utils.JSONResponse{data=models.Product}
 // @Failure 400 {object} utils.JSONResponse
+// @Failure 403 {object} utils.JSONResponse
 // @Failure 404 {object} utils.JSONResponse
+// @Failure 409 {object} utils.JSONResponse
+// @Failure 500 {object} utils.JSONResponse
 // @Router /api/products/{id} [put]
 func (h *ProductHandler) UpdateProduct(w http.ResponseWriter, r *http.Request) {
 	idStr := strings.TrimPrefix(r.URL.Path, "/api/products/")
 	id, _ := strconv.Atoi(idStr)
 
-	var product models.Product
-	if err := json.NewDecoder(r.Body).Decode(&product); err != nil {
+	var req models.UpdateProductRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
 		utils.ErrorResponse(w, http.StatusBadRequest, "Invalid request body", err.Error())
 		return
 	}
 
-	product.ID = id
-	updatedProduct, err := h.service.Update(id, product)
+	req.ID = id
+	outlet, _ := middleware.OutletFromContext(r.Context())
+	req.OutletID = outlet.ID
+	req.Actor, _ = middleware.UserFromContext(r.Context())
+	updatedProduct, err := h.service.Update(id, req)
+	if err != nil && err.Error() == "min_stock and reorder_quantity cannot be negative" {
+		utils.ErrorResponse(w, http.StatusBadRequest, err.Error(), "Bad Request")
+		return
+	}
+
 	if err != nil && err.Error() == "category not found" {
 		utils.ErrorResponse(w, http.StatusBadRequest, err.Error(), "Invalid Category ID")
 		return
 	}
 
+	if err != nil && err.Error() == "tax rate not found" {
+		utils.ErrorResponse(w, http.StatusBadRequest, err.Error(), "Invalid Tax Rate ID")
+		return
+	}
+
+	if err != nil && isDuplicateProductCode(err) {
+		utils.ErrorResponse(w, http.StatusConflict, err.Error(), "Conflict")
+		return
+	}
+
+	if errors.Is(err, utils.ErrApprovalRequired) || errors.Is(err, utils.ErrApprovalDenied) {
+		utils.ErrorResponse(w, http.StatusForbidden, err.Error(), "Forbidden")
+		return
+	}
+
+	if errors.Is(err, utils.ErrAccountLocked) {
+		utils.ErrorResponse(w, http.StatusTooManyRequests, err.Error(), "Too Many Requests")
+		return
+	}
+
+	if errors.Is(err, utils.ErrProductNotSaved) {
+		utils.ErrorResponse(w, http.StatusInternalServerError, "Failed to update product", err.Error())
+		return
+	}
+
 	if err != nil {
 		utils.ErrorResponse(w, http.StatusNotFound, "Product not found", "Product not found")
 		return
 	}
-	utils.SuccessResponse(w, http.StatusOK, "Product updated successfully", updatedProduct)
+	utils.SuccessResponse(w, http.StatusOK, "Product updated successfully", productFor(r, updatedProduct))
 }
 
 // @Summary Delete a product
-// @Description Remove a product from the catalog
+// @Description Remove a product and its variants from the catalog. Their past sales and stock ledger are kept.
 // @Tags products
+// @Security BearerAuth
 // @Produce json
 // @Param id path int true "Product ID"
 // @Success 200 {object} utils.JSONResponse
@@ -136,9 +272,43 @@ func (h *ProductHandler) DeleteProduct(w http.ResponseWriter, r *http.Request) {
 	idStr := strings.TrimPrefix(r.URL.Path, "/api/products/")
 	id, _ := strconv.Atoi(idStr)
 
-	if err := h.service.Delete(id); err != nil {
+	err := h.service.Delete(id)
+	if errors.Is(err, utils.ErrProductNotFound) {
 		utils.ErrorResponse(w, http.StatusNotFound, "Product not found", "Product not found")
 		return
 	}
+	if err != nil {
+		utils.ErrorResponse(w, http.StatusInternalServerError, "Failed to delete product", err.Error())
+		return
+	}
 	utils.SuccessResponse(w, http.StatusOK, "Product deleted successfully", nil)
 }
+
+// isDuplicateProductCode reports whether err is about a SKU, barcode or
+// variant name another product already uses.
+func isDuplicateProductCode(err error) bool {
+	switch err.Error() {
+	case "SKU already exists", "barcode already exists", "variant name already exists":
+		return true
+	}
+	return false
+}
+
+// productFor leaves the cost out of product unless the caller may see it.
+func productFor(r *http.Request, product models.Product) models.Product {
+	if actor, _ := middleware.UserFromContext(r.Context()); actor.CanViewCost() {
+		return product
+	}
+	return product.WithoutCost()
+}
+
+func productsFor(r *http.Request, products []models.Product) []models.Product {
+	if actor, _ := middleware.UserFromContext(r.Context()); actor.CanViewCost() {
+		return products
+	}
+	hidden := make([]models.Product, len(products))
+	for i, p := range products {
+		hidden[i] = p.WithoutCost()
+	}
+	return hidden
+}
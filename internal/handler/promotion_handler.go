@@ -0,0 +1,147 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"kasir-api-go/internal/models"
+	"kasir-api-go/internal/service"
+	"kasir-api-go/internal/utils"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+type PromotionHandler struct {
+	service service.PromotionService
+}
+
+func NewPromotionHandler(service service.PromotionService) *PromotionHandler {
+	return &PromotionHandler{
+		service: service,
+	}
+}
+
+// @Summary List all promotions
+// @Description Get a list of all promotions
+// @Tags promotions
+// @Security BearerAuth
+// @Produce json
+// @Success 200 {object} utils.JSONResponse{data=[]models.Promotion}
+// @Router /api/promotions [get]
+func (h *PromotionHandler) GetPromotions(w http.ResponseWriter, r *http.Request) {
+	promotions, err := h.service.GetAll()
+	if err != nil {
+		utils.ErrorResponse(w, http.StatusInternalServerError, "Failed to fetch promotions", err.Error())
+		return
+	}
+	utils.SuccessResponse(w, http.StatusOK, "Success", promotions)
+}
+
+// @Summary Create a new promotion
+// @Description Add a new discount rule applied at checkout
+// @Tags promotions
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param promotion body models.Promotion true "Promotion object"
+// @Success 201 {object} utils.JSONResponse{data=models.Promotion}
+// @Failure 400 {object} utils.JSONResponse
+// @Router /api/promotions [post]
+func (h *PromotionHandler) CreatePromotion(w http.ResponseWriter, r *http.Request) {
+	var promotion models.Promotion
+	if err := json.NewDecoder(r.Body).Decode(&promotion); err != nil {
+		utils.ErrorResponse(w, http.StatusBadRequest, "Invalid request body", err.Error())
+		return
+	}
+
+	createdPromotion, err := h.service.Create(promotion)
+	if errors.Is(err, utils.ErrInvalidPromotion) {
+		utils.ErrorResponse(w, http.StatusBadRequest, err.Error(), "Bad Request")
+		return
+	}
+	if err != nil {
+		utils.ErrorResponse(w, http.StatusInternalServerError, "Failed to create promotion", err.Error())
+		return
+	}
+
+	utils.SuccessResponse(w, http.StatusCreated, "Promotion created successfully", createdPromotion)
+}
+
+// @Summary Get a promotion detail
+// @Description Get details of a promotion by ID
+// @Tags promotions
+// @Security BearerAuth
+// @Produce json
+// @Param id path int true "Promotion ID"
+// @Success 200 {object} utils.JSONResponse{data=models.Promotion}
+// @Failure 404 {object} utils.JSONResponse
+// @Router /api/promotions/{id} [get]
+func (h *PromotionHandler) GetPromotionDetail(w http.ResponseWriter, r *http.Request) {
+	idStr := strings.TrimPrefix(r.URL.Path, "/api/promotions/")
+	id, _ := strconv.Atoi(idStr)
+
+	promotion, err := h.service.GetByID(id)
+	if err != nil {
+		utils.ErrorResponse(w, http.StatusNotFound, "Promotion not found", "Promotion not found")
+		return
+	}
+	utils.SuccessResponse(w, http.StatusOK, "Success", promotion)
+}
+
+// @Summary Update a promotion
+// @Description Update an existing promotion's rule
+// @Tags promotions
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param id path int true "Promotion ID"
+// @Param promotion body models.Promotion true "Promotion object"
+// @Success 200 {object} utils.JSONResponse{data=models.Promotion}
+// @Failure 400 {object} utils.JSONResponse
+// @Failure 404 {object} utils.JSONResponse
+// @Router /api/promotions/{id} [put]
+func (h *PromotionHandler) UpdatePromotion(w http.ResponseWriter, r *http.Request) {
+	idStr := strings.TrimPrefix(r.URL.Path, "/api/promotions/")
+	id, _ := strconv.Atoi(idStr)
+
+	var promotion models.Promotion
+	if err := json.NewDecoder(r.Body).Decode(&promotion); err != nil {
+		utils.ErrorResponse(w, http.StatusBadRequest, "Invalid request body", err.Error())
+		return
+	}
+
+	updatedPromotion, err := h.service.Update(id, promotion)
+	if errors.Is(err, utils.ErrInvalidPromotion) {
+		utils.ErrorResponse(w, http.StatusBadRequest, err.Error(), "Bad Request")
+		return
+	}
+	if errors.Is(err, utils.ErrPromotionNotFound) {
+		utils.ErrorResponse(w, http.StatusNotFound, "Promotion not found", "Promotion not found")
+		return
+	}
+	if err != nil {
+		utils.ErrorResponse(w, http.StatusInternalServerError, "Failed to update promotion", err.Error())
+		return
+	}
+	utils.SuccessResponse(w, http.StatusOK, "Promotion updated successfully", updatedPromotion)
+}
+
+// @Summary Delete a promotion
+// @Description Remove a promotion
+// @Tags promotions
+// @Security BearerAuth
+// @Produce json
+// @Param id path int true "Promotion ID"
+// @Success 200 {object} utils.JSONResponse
+// @Failure 404 {object} utils.JSONResponse
+// @Router /api/promotions/{id} [delete]
+func (h *PromotionHandler) DeletePromotion(w http.ResponseWriter, r *http.Request) {
+	idStr := strings.TrimPrefix(r.URL.Path, "/api/promotions/")
+	id, _ := strconv.Atoi(idStr)
+
+	if err := h.service.Delete(id); err != nil {
+		utils.ErrorResponse(w, http.StatusNotFound, "Promotion not found", "Promotion not found")
+		return
+	}
+	utils.SuccessResponse(w, http.StatusOK, "Promotion deleted successfully", nil)
+}
@@ -0,0 +1,200 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"kasir-api-go/internal/middleware"
+	"kasir-api-go/internal/models"
+	"kasir-api-go/internal/service"
+	"kasir-api-go/internal/utils"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+type PurchaseOrderHandler struct {
+	service service.PurchaseOrderService
+}
+
+func NewPurchaseOrderHandler(service service.PurchaseOrderService) *PurchaseOrderHandler {
+	return &PurchaseOrderHandler{
+		service: service,
+	}
+}
+
+// @Summary List purchase orders
+// @Description Get all purchase orders, most recent first
+// @Tags purchase-orders
+// @Security BearerAuth
+// @Produce json
+// @Param status query string false "Only orders with this status (open, partially_received, received or cancelled)"
+// @Success 200 {object} utils.JSONResponse{data=[]models.PurchaseOrder}
+// @Router /api/purchase-orders [get]
+func (h *PurchaseOrderHandler) GetPurchaseOrders(w http.ResponseWriter, r *http.Request) {
+	status := models.PurchaseOrderStatus(r.URL.Query().Get("status"))
+
+	orders, err := h.service.GetAll(status)
+	if err != nil {
+		utils.ErrorResponse(w, http.StatusInternalServerError, "Failed to fetch purchase orders", err.Error())
+		return
+	}
+	utils.SuccessResponse(w, http.StatusOK, "Success", orders)
+}
+
+// @Summary List outstanding purchase orders
+// @Description Get the open and partially received purchase orders with the quantities still to arrive
+// @Tags purchase-orders
+// @Security BearerAuth
+// @Produce json
+// @Success 200 {object} utils.JSONResponse{data=[]models.PurchaseOrder}
+// @Router /api/purchase-orders/outstanding [get]
+func (h *PurchaseOrderHandler) GetOutstandingPurchaseOrders(w http.ResponseWriter, r *http.Request) {
+	orders, err := h.service.GetOutstanding()
+	if err != nil {
+		utils.ErrorResponse(w, http.StatusInternalServerError, "Failed to fetch purchase orders", err.Error())
+		return
+	}
+	utils.SuccessResponse(w, http.StatusOK, "Success", orders)
+}
+
+// @Summary Create a purchase order
+// @Description Place an order with a supplier for products at an expected unit cost, delivered to the given outlet or the caller's
+// @Tags purchase-orders
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param X-Outlet-Code header string false "Outlet code (defaults to the user's outlet, then the default outlet)"
+// @Param request body models.CreatePurchaseOrderRequest true "Create Purchase Order Request object"
+// @Success 201 {object} utils.JSONResponse{data=models.PurchaseOrder}
+// @Failure 400 {object} utils.JSONResponse
+// @Router /api/purchase-orders [post]
+func (h *PurchaseOrderHandler) CreatePurchaseOrder(w http.ResponseWriter, r *http.Request) {
+	var req models.CreatePurchaseOrderRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		utils.ErrorResponse(w, http.StatusBadRequest, "Invalid request body", err.Error())
+		return
+	}
+
+	actor, _ := middleware.UserFromContext(r.Context())
+	outlet, _ := middleware.OutletFromContext(r.Context())
+	order, err := h.service.Create(req, actor, outlet.ID)
+	if err != nil {
+		writePurchaseOrderError(w, err)
+		return
+	}
+	utils.SuccessResponse(w, http.StatusCreated, "Purchase order created successfully", order)
+}
+
+// @Summary Get a purchase order detail
+// @Description Get a purchase order with its lines, goods receipts and returns
+// @Tags purchase-orders
+// @Security BearerAuth
+// @Produce json
+// @Param id path int true "Purchase Order ID"
+// @Success 200 {object} utils.JSONResponse{data=models.PurchaseOrder}
+// @Failure 404 {object} utils.JSONResponse
+// @Router /api/purchase-orders/{id} [get]
+func (h *PurchaseOrderHandler) GetPurchaseOrderDetail(w http.ResponseWriter, r *http.Request) {
+	order, err := h.service.GetByID(purchaseOrderIDFromPath(r.URL.Path))
+	if err != nil {
+		writePurchaseOrderError(w, err)
+		return
+	}
+	utils.SuccessResponse(w, http.StatusOK, "Success", order)
+}
+
+// @Summary Receive goods
+// @Description Receive a delivery against a purchase order and add it to stock. Without items, everything still outstanding is received.
+// @Tags purchase-orders
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param id path int true "Purchase Order ID"
+// @Param request body models.ReceiveGoodsRequest true "Receive Goods Request object"
+// @Success 201 {object} utils.JSONResponse{data=models.PurchaseOrder}
+// @Failure 400 {object} utils.JSONResponse
+// @Failure 404 {object} utils.JSONResponse
+// @Failure 409 {object} utils.JSONResponse
+// @Router /api/purchase-orders/{id}/receipts [post]
+func (h *PurchaseOrderHandler) ReceiveGoods(w http.ResponseWriter, r *http.Request) {
+	var req models.ReceiveGoodsRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		utils.ErrorResponse(w, http.StatusBadRequest, "Invalid request body", err.Error())
+		return
+	}
+
+	actor, _ := middleware.UserFromContext(r.Context())
+	order, err := h.service.Receive(purchaseOrderIDFromPath(r.URL.Path), req, actor)
+	if err != nil {
+		writePurchaseOrderError(w, err)
+		return
+	}
+	utils.SuccessResponse(w, http.StatusCreated, "Goods received successfully", order)
+}
+
+// @Summary Return goods to the supplier
+// @Description Send received goods of a purchase order back to the supplier and take them out of stock
+// @Tags purchase-orders
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param id path int true "Purchase Order ID"
+// @Param request body models.PurchaseReturnRequest true "Purchase Return Request object"
+// @Success 201 {object} utils.JSONResponse{data=models.PurchaseOrder}
+// @Failure 400 {object} utils.JSONResponse
+// @Failure 404 {object} utils.JSONResponse
+// @Router /api/purchase-orders/{id}/returns [post]
+func (h *PurchaseOrderHandler) ReturnGoods(w http.ResponseWriter, r *http.Request) {
+	var req models.PurchaseReturnRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		utils.ErrorResponse(w, http.StatusBadRequest, "Invalid request body", err.Error())
+		return
+	}
+
+	actor, _ := middleware.UserFromContext(r.Context())
+	order, err := h.service.Return(purchaseOrderIDFromPath(r.URL.Path), req, actor)
+	if err != nil {
+		writePurchaseOrderError(w, err)
+		return
+	}
+	utils.SuccessResponse(w, http.StatusCreated, "Goods returned successfully", order)
+}
+
+// @Summary Cancel a purchase order
+// @Description Stop waiting for the rest of a purchase order. Goods already received stay in stock.
+// @Tags purchase-orders
+// @Security BearerAuth
+// @Produce json
+// @Param id path int true "Purchase Order ID"
+// @Success 200 {object} utils.JSONResponse{data=models.PurchaseOrder}
+// @Failure 404 {object} utils.JSONResponse
+// @Failure 409 {object} utils.JSONResponse
+// @Router /api/purchase-orders/{id}/cancel [post]
+func (h *PurchaseOrderHandler) CancelPurchaseOrder(w http.ResponseWriter, r *http.Request) {
+	order, err := h.service.Cancel(purchaseOrderIDFromPath(r.URL.Path))
+	if err != nil {
+		writePurchaseOrderError(w, err)
+		return
+	}
+	utils.SuccessResponse(w, http.StatusOK, "Purchase order cancelled successfully", order)
+}
+
+func writePurchaseOrderError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, utils.ErrPurchaseOrderNotFound):
+		utils.ErrorResponse(w, http.StatusNotFound, err.Error(), "Not Found")
+	case errors.Is(err, utils.ErrPurchaseOrderClosed):
+		utils.ErrorResponse(w, http.StatusConflict, err.Error(), "Conflict")
+	case errors.Is(err, utils.ErrInvalidPurchaseOrder), errors.Is(err, utils.ErrInvalidGoodsReceipt),
+		errors.Is(err, utils.ErrInvalidPurchaseReturn):
+		utils.ErrorResponse(w, http.StatusBadRequest, err.Error(), "Bad Request")
+	default:
+		utils.ErrorResponse(w, http.StatusInternalServerError, "Failed to process purchase order", err.Error())
+	}
+}
+
+func purchaseOrderIDFromPath(path string) int {
+	idStr, _, _ := strings.Cut(strings.TrimPrefix(path, "/api/purchase-orders/"), "/")
+	id, _ := strconv.Atoi(idStr)
+	return id
+}
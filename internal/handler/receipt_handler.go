@@ -0,0 +1,64 @@
+package handler
+
+import (
+	"errors"
+	"kasir-api-go/internal/service"
+	"kasir-api-go/internal/utils"
+	"net/http"
+	"strconv"
+)
+
+type ReceiptHandler struct {
+	service service.ReceiptService
+}
+
+func NewReceiptHandler(service service.ReceiptService) *ReceiptHandler {
+	return &ReceiptHandler{
+		service: service,
+	}
+}
+
+// @Summary Print a transaction receipt
+// @Description Render the receipt of a transaction as plain text, raw ESC/POS bytes for thermal printers, or HTML
+// @Tags transactions
+// @Security BearerAuth
+// @Produce plain
+// @Produce octet-stream
+// @Produce html
+// @Param id path int true "Transaction ID"
+// @Param format query string false "Receipt format: text (default), escpos or html"
+// @Param width query int false "Paper width in mm: 58 or 80 (defaults to RECEIPT_PAPER_WIDTH)"
+// @Success 200 {string} string "Receipt"
+// @Failure 400 {object} utils.JSONResponse
+// @Failure 404 {object} utils.JSONResponse
+// @Router /api/transactions/{id}/receipt [get]
+func (h *ReceiptHandler) GetReceipt(w http.ResponseWriter, r *http.Request) {
+	id := transactionIDFromPath(r.URL.Path)
+
+	width := 0
+	if widthStr := r.URL.Query().Get("width"); widthStr != "" {
+		var err error
+		width, err = strconv.Atoi(widthStr)
+		if err != nil {
+			utils.ErrorResponse(w, http.StatusBadRequest, "Invalid paper width", utils.ErrInvalidPaperWidth.Error())
+			return
+		}
+	}
+
+	body, contentType, err := h.service.Render(id, r.URL.Query().Get("format"), width)
+	if err != nil {
+		switch {
+		case errors.Is(err, utils.ErrTransactionNotFound):
+			utils.ErrorResponse(w, http.StatusNotFound, "Transaction not found", err.Error())
+		case errors.Is(err, utils.ErrInvalidReceiptFormat), errors.Is(err, utils.ErrInvalidPaperWidth):
+			utils.ErrorResponse(w, http.StatusBadRequest, "Invalid receipt options", err.Error())
+		default:
+			utils.ErrorResponse(w, http.StatusInternalServerError, "Failed to render receipt", err.Error())
+		}
+		return
+	}
+
+	w.Header().Set("Content-Type", contentType)
+	w.WriteHeader(http.StatusOK)
+	w.Write(body)
+}
@@ -1,6 +1,8 @@
 package handler
 
 import (
+	"errors"
+	"kasir-api-go/internal/models"
 	"kasir-api-go/internal/service"
 	"kasir-api-go/internal/utils"
 	"net/http"
@@ -18,11 +20,13 @@ func NewReportHandler(service service.ReportService) *ReportHandler {
 // @Summary Get sales report for today
 // @Description Get total revenue, total transactions, and best selling product for today
 // @Tags report
+// @Security BearerAuth
 // @Produce json
+// @Param outlet query string false "Outlet code (defaults to all outlets)"
 // @Success 200 {object} utils.JSONResponse{data=models.SalesReport}
 // @Router /api/report/today [get]
 func (h *ReportHandler) GetTodayReport(w http.ResponseWriter, r *http.Request) {
-	report, err := h.service.GetTodayReport()
+	report, err := h.service.GetTodayReport(r.URL.Query().Get("outlet"))
 	if err != nil {
 		utils.ErrorResponse(w, http.StatusInternalServerError, "Failed to fetch today's report", err.Error())
 		return
@@ -33,36 +37,99 @@ func (h *ReportHandler) GetTodayReport(w http.ResponseWriter, r *http.Request) {
 // @Summary Get sales report by date range
 // @Description Get total revenue, total transactions, and best selling product for a specific date range
 // @Tags report
+// @Security BearerAuth
 // @Produce json
 // @Param start_date query string true "Start date (YYYY-MM-DD)"
 // @Param end_date query string true "End date (YYYY-MM-DD)"
+// @Param outlet query string false "Outlet code (defaults to all outlets)"
 // @Success 200 {object} utils.JSONResponse{data=models.SalesReport}
 // @Router /api/report [get]
 func (h *ReportHandler) GetReportByRange(w http.ResponseWriter, r *http.Request) {
+	startDate, endDate, ok := dateRange(w, r)
+	if !ok {
+		return
+	}
+
+	report, err := h.service.GetReportByRange(startDate, endDate, r.URL.Query().Get("outlet"))
+	if err != nil {
+		utils.ErrorResponse(w, http.StatusInternalServerError, "Failed to fetch report", err.Error())
+		return
+	}
+	utils.SuccessResponse(w, http.StatusOK, "Success", report)
+}
+
+// @Summary Get the inventory valuation
+// @Description Get the value of the stock on hand of every product under the configured costing method (average or fifo), with variants under their parent. Needs inventory.manage or purchasing.manage besides reports.view.
+// @Tags report
+// @Security BearerAuth
+// @Produce json
+// @Param outlet query string false "Outlet code (defaults to all outlets)"
+// @Success 200 {object} utils.JSONResponse{data=models.InventoryValuation}
+// @Failure 403 {object} utils.JSONResponse
+// @Router /api/report/inventory-valuation [get]
+func (h *ReportHandler) GetInventoryValuation(w http.ResponseWriter, r *http.Request) {
+	valuation, err := h.service.GetInventoryValuation(r.URL.Query().Get("outlet"))
+	if err != nil {
+		utils.ErrorResponse(w, http.StatusInternalServerError, "Failed to fetch inventory valuation", err.Error())
+		return
+	}
+	utils.SuccessResponse(w, http.StatusOK, "Success", valuation)
+}
+
+// @Summary Get the profit report
+// @Description Get revenue, cost of goods sold, gross profit and margin % for a date range, optionally broken down by product, variant, category or day. Needs inventory.manage or purchasing.manage besides reports.view.
+// @Tags report
+// @Security BearerAuth
+// @Produce json
+// @Param start_date query string true "Start date (YYYY-MM-DD)"
+// @Param end_date query string true "End date (YYYY-MM-DD)"
+// @Param group_by query string false "Break down by product, variant, category or day; product rolls variants up to their parent"
+// @Param outlet query string false "Outlet code (defaults to all outlets)"
+// @Success 200 {object} utils.JSONResponse{data=models.ProfitReport}
+// @Failure 400 {object} utils.JSONResponse
+// @Failure 403 {object} utils.JSONResponse
+// @Router /api/report/profit [get]
+func (h *ReportHandler) GetProfitReport(w http.ResponseWriter, r *http.Request) {
+	startDate, endDate, ok := dateRange(w, r)
+	if !ok {
+		return
+	}
+	groupBy := models.ProfitGrouping(r.URL.Query().Get("group_by"))
+
+	report, err := h.service.GetProfitReport(startDate, endDate, groupBy, r.URL.Query().Get("outlet"))
+	if errors.Is(err, utils.ErrInvalidProfitGrouping) {
+		utils.ErrorResponse(w, http.StatusBadRequest, err.Error(), "Bad Request")
+		return
+	}
+	if err != nil {
+		utils.ErrorResponse(w, http.StatusInternalServerError, "Failed to fetch profit report", err.Error())
+		return
+	}
+	utils.SuccessResponse(w, http.StatusOK, "Success", report)
+}
+
+// dateRange reads the start_date and end_date query parameters (YYYY-MM-DD)
+// and writes the error response when either is missing or malformed.
+func dateRange(w http.ResponseWriter, r *http.Request) (startDate, endDate time.Time, ok bool) {
 	startDateStr := r.URL.Query().Get("start_date")
 	endDateStr := r.URL.Query().Get("end_date")
 
 	if startDateStr == "" || endDateStr == "" {
 		utils.ErrorResponse(w, http.StatusBadRequest, "Invalid date range", "start_date and end_date are required")
-		return
+		return startDate, endDate, false
 	}
 
 	startDate, err := time.Parse("2006-01-02", startDateStr)
 	if err != nil {
 		utils.ErrorResponse(w, http.StatusBadRequest, "Invalid start_date format", "Expected YYYY-MM-DD")
-		return
+		return startDate, endDate, false
 	}
 
-	endDate, err := time.Parse("2006-01-02", endDateStr)
+	endDate, err = time.Parse("2006-01-02", endDateStr)
 	if err != nil {
 		utils.ErrorResponse(w, http.StatusBadRequest, "Invalid end_date format", "Expected YYYY-MM-DD")
-		return
+		return startDate, endDate, false
 	}
 
-	report, err := h.service.GetReportByRange(startDate, endDate)
-	if err != nil {
-		utils.ErrorResponse(w, http.StatusInternalServerError, "Failed to fetch report", err.Error())
-		return
-	}
-	utils.SuccessResponse(w, http.StatusOK, "Success", report)
+	return startDate, endDate, true
 }
@@ -0,0 +1,157 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"kasir-api-go/internal/models"
+	"kasir-api-go/internal/service"
+	"kasir-api-go/internal/utils"
+	"net/http"
+	"strings"
+)
+
+type RoleHandler struct {
+	service service.RoleService
+}
+
+func NewRoleHandler(service service.RoleService) *RoleHandler {
+	return &RoleHandler{
+		service: service,
+	}
+}
+
+// @Summary List all roles
+// @Description Get a list of all roles with their permissions
+// @Tags roles
+// @Security BearerAuth
+// @Produce json
+// @Success 200 {object} utils.JSONResponse{data=[]models.Role}
+// @Router /api/roles [get]
+func (h *RoleHandler) GetRoles(w http.ResponseWriter, r *http.Request) {
+	roles, err := h.service.GetAll()
+	if err != nil {
+		utils.ErrorResponse(w, http.StatusInternalServerError, "Failed to fetch roles", err.Error())
+		return
+	}
+	utils.SuccessResponse(w, http.StatusOK, "Success", roles)
+}
+
+// @Summary List all permissions
+// @Description Get every permission that can be granted to a role
+// @Tags roles
+// @Security BearerAuth
+// @Produce json
+// @Success 200 {object} utils.JSONResponse{data=[]string}
+// @Router /api/permissions [get]
+func (h *RoleHandler) GetPermissions(w http.ResponseWriter, r *http.Request) {
+	utils.SuccessResponse(w, http.StatusOK, "Success", models.Permissions)
+}
+
+// @Summary Create a new role
+// @Description Add a role with a set of permissions
+// @Tags roles
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param role body models.Role true "Role object"
+// @Success 201 {object} utils.JSONResponse{data=models.Role}
+// @Failure 400 {object} utils.JSONResponse
+// @Failure 409 {object} utils.JSONResponse
+// @Router /api/roles [post]
+func (h *RoleHandler) CreateRole(w http.ResponseWriter, r *http.Request) {
+	var role models.Role
+	if err := json.NewDecoder(r.Body).Decode(&role); err != nil {
+		utils.ErrorResponse(w, http.StatusBadRequest, "Invalid request body", err.Error())
+		return
+	}
+
+	createdRole, err := h.service.Create(role)
+	if err != nil {
+		writeRoleError(w, err)
+		return
+	}
+	utils.SuccessResponse(w, http.StatusCreated, "Role created successfully", createdRole)
+}
+
+// @Summary Get a role detail
+// @Description Get a role and its permissions by name
+// @Tags roles
+// @Security BearerAuth
+// @Produce json
+// @Param name path string true "Role name"
+// @Success 200 {object} utils.JSONResponse{data=models.Role}
+// @Failure 404 {object} utils.JSONResponse
+// @Router /api/roles/{name} [get]
+func (h *RoleHandler) GetRoleDetail(w http.ResponseWriter, r *http.Request) {
+	name := models.UserRole(strings.TrimPrefix(r.URL.Path, "/api/roles/"))
+
+	role, err := h.service.GetByName(name)
+	if err != nil {
+		writeRoleError(w, err)
+		return
+	}
+	utils.SuccessResponse(w, http.StatusOK, "Success", role)
+}
+
+// @Summary Update a role
+// @Description Replace the description and permissions of a role. The owner role cannot be changed.
+// @Tags roles
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param name path string true "Role name"
+// @Param role body models.Role true "Role object"
+// @Success 200 {object} utils.JSONResponse{data=models.Role}
+// @Failure 400 {object} utils.JSONResponse
+// @Failure 404 {object} utils.JSONResponse
+// @Failure 409 {object} utils.JSONResponse
+// @Router /api/roles/{name} [put]
+func (h *RoleHandler) UpdateRole(w http.ResponseWriter, r *http.Request) {
+	name := models.UserRole(strings.TrimPrefix(r.URL.Path, "/api/roles/"))
+
+	var role models.Role
+	if err := json.NewDecoder(r.Body).Decode(&role); err != nil {
+		utils.ErrorResponse(w, http.StatusBadRequest, "Invalid request body", err.Error())
+		return
+	}
+
+	updatedRole, err := h.service.Update(name, role)
+	if err != nil {
+		writeRoleError(w, err)
+		return
+	}
+	utils.SuccessResponse(w, http.StatusOK, "Role updated successfully", updatedRole)
+}
+
+// @Summary Delete a role
+// @Description Remove a role that no user holds. The owner role cannot be deleted.
+// @Tags roles
+// @Security BearerAuth
+// @Produce json
+// @Param name path string true "Role name"
+// @Success 200 {object} utils.JSONResponse
+// @Failure 404 {object} utils.JSONResponse
+// @Failure 409 {object} utils.JSONResponse
+// @Router /api/roles/{name} [delete]
+func (h *RoleHandler) DeleteRole(w http.ResponseWriter, r *http.Request) {
+	name := models.UserRole(strings.TrimPrefix(r.URL.Path, "/api/roles/"))
+
+	if err := h.service.Delete(name); err != nil {
+		writeRoleError(w, err)
+		return
+	}
+	utils.SuccessResponse(w, http.StatusOK, "Role deleted successfully", nil)
+}
+
+func writeRoleError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, utils.ErrRoleNotFound):
+		utils.ErrorResponse(w, http.StatusNotFound, "Role not found", err.Error())
+	case errors.Is(err, utils.ErrRoleExists), errors.Is(err, utils.ErrRoleInUse), errors.Is(err, utils.ErrRoleLocked):
+		utils.ErrorResponse(w, http.StatusConflict, err.Error(), "Conflict")
+	case errors.Is(err, utils.ErrInvalidRole):
+		utils.ErrorResponse(w, http.StatusBadRequest, err.Error(), "Bad Request")
+	default:
+		utils.ErrorResponse(w, http.StatusInternalServerError, "Failed to process role", err.Error())
+	}
+}
@@ -0,0 +1,189 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"kasir-api-go/internal/middleware"
+	"kasir-api-go/internal/models"
+	"kasir-api-go/internal/service"
+	"kasir-api-go/internal/utils"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+type ShiftHandler struct {
+	service service.ShiftService
+}
+
+func NewShiftHandler(service service.ShiftService) *ShiftHandler {
+	return &ShiftHandler{
+		service: service,
+	}
+}
+
+// @Summary List shifts
+// @Description Get all cashier shifts, most recent first
+// @Tags shifts
+// @Security BearerAuth
+// @Produce json
+// @Success 200 {object} utils.JSONResponse{data=[]models.Shift}
+// @Router /api/shifts [get]
+func (h *ShiftHandler) GetShifts(w http.ResponseWriter, r *http.Request) {
+	shifts, err := h.service.GetAll()
+	if err != nil {
+		utils.ErrorResponse(w, http.StatusInternalServerError, "Failed to fetch shifts", err.Error())
+		return
+	}
+	utils.SuccessResponse(w, http.StatusOK, "Success", shifts)
+}
+
+// @Summary Open a shift
+// @Description Start a shift for the signed-in cashier on the till with the opening cash float. Checkouts are linked to the open shift.
+// @Tags shifts
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param X-Outlet-Code header string false "Outlet code (defaults to the user's outlet, then the default outlet)"
+// @Param request body models.OpenShiftRequest true "Open Shift Request object"
+// @Success 201 {object} utils.JSONResponse{data=models.Shift}
+// @Failure 400 {object} utils.JSONResponse
+// @Failure 409 {object} utils.JSONResponse
+// @Router /api/shifts [post]
+func (h *ShiftHandler) OpenShift(w http.ResponseWriter, r *http.Request) {
+	var req models.OpenShiftRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		utils.ErrorResponse(w, http.StatusBadRequest, "Invalid request body", err.Error())
+		return
+	}
+
+	actor, _ := middleware.UserFromContext(r.Context())
+	outlet, _ := middleware.OutletFromContext(r.Context())
+	shift, err := h.service.Open(req, outlet.Code, actor)
+	if err != nil {
+		writeShiftError(w, err)
+		return
+	}
+	utils.SuccessResponse(w, http.StatusCreated, "Shift opened successfully", shift)
+}
+
+// @Summary Get the current shift
+// @Description Get the shift open on the till with its running cash summary
+// @Tags shifts
+// @Security BearerAuth
+// @Produce json
+// @Param X-Outlet-Code header string false "Outlet code (defaults to the user's outlet, then the default outlet)"
+// @Success 200 {object} utils.JSONResponse{data=models.Shift}
+// @Failure 404 {object} utils.JSONResponse
+// @Router /api/shifts/current [get]
+func (h *ShiftHandler) GetCurrentShift(w http.ResponseWriter, r *http.Request) {
+	outlet, _ := middleware.OutletFromContext(r.Context())
+	shift, err := h.service.GetCurrent(outlet.Code)
+	if err != nil {
+		writeShiftError(w, err)
+		return
+	}
+	utils.SuccessResponse(w, http.StatusOK, "Success", shift)
+}
+
+// @Summary Get a shift detail
+// @Description Get a shift with its cash movements and summary
+// @Tags shifts
+// @Security BearerAuth
+// @Produce json
+// @Param id path int true "Shift ID"
+// @Success 200 {object} utils.JSONResponse{data=models.Shift}
+// @Failure 404 {object} utils.JSONResponse
+// @Router /api/shifts/{id} [get]
+func (h *ShiftHandler) GetShiftDetail(w http.ResponseWriter, r *http.Request) {
+	shift, err := h.service.GetByID(shiftIDFromPath(r.URL.Path))
+	if err != nil {
+		writeShiftError(w, err)
+		return
+	}
+	utils.SuccessResponse(w, http.StatusOK, "Success", shift)
+}
+
+// @Summary Record a cash movement
+// @Description Record cash put into (cash_in) or taken out of (cash_out) the drawer during an open shift of the caller's outlet
+// @Tags shifts
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param X-Outlet-Code header string false "Outlet code (defaults to the user's outlet, then the default outlet)"
+// @Param id path int true "Shift ID"
+// @Param request body models.CashMovementRequest true "Cash Movement Request object"
+// @Success 201 {object} utils.JSONResponse{data=models.CashMovement}
+// @Failure 400 {object} utils.JSONResponse
+// @Failure 403 {object} utils.JSONResponse
+// @Failure 404 {object} utils.JSONResponse
+// @Failure 409 {object} utils.JSONResponse
+// @Router /api/shifts/{id}/cash-movements [post]
+func (h *ShiftHandler) AddCashMovement(w http.ResponseWriter, r *http.Request) {
+	var req models.CashMovementRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		utils.ErrorResponse(w, http.StatusBadRequest, "Invalid request body", err.Error())
+		return
+	}
+
+	actor, _ := middleware.UserFromContext(r.Context())
+	outlet, _ := middleware.OutletFromContext(r.Context())
+	movement, err := h.service.AddCashMovement(shiftIDFromPath(r.URL.Path), req, outlet.Code, actor)
+	if err != nil {
+		writeShiftError(w, err)
+		return
+	}
+	utils.SuccessResponse(w, http.StatusCreated, "Cash movement recorded successfully", movement)
+}
+
+// @Summary Close a shift
+// @Description Close a shift of the caller's outlet with the counted cash and get the expected vs. counted cash summary
+// @Tags shifts
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param X-Outlet-Code header string false "Outlet code (defaults to the user's outlet, then the default outlet)"
+// @Param id path int true "Shift ID"
+// @Param request body models.CloseShiftRequest true "Close Shift Request object"
+// @Success 200 {object} utils.JSONResponse{data=models.Shift}
+// @Failure 400 {object} utils.JSONResponse
+// @Failure 403 {object} utils.JSONResponse
+// @Failure 404 {object} utils.JSONResponse
+// @Failure 409 {object} utils.JSONResponse
+// @Router /api/shifts/{id}/close [post]
+func (h *ShiftHandler) CloseShift(w http.ResponseWriter, r *http.Request) {
+	var req models.CloseShiftRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		utils.ErrorResponse(w, http.StatusBadRequest, "Invalid request body", err.Error())
+		return
+	}
+
+	outlet, _ := middleware.OutletFromContext(r.Context())
+	shift, err := h.service.Close(shiftIDFromPath(r.URL.Path), req, outlet.Code)
+	if err != nil {
+		writeShiftError(w, err)
+		return
+	}
+	utils.SuccessResponse(w, http.StatusOK, "Shift closed successfully", shift)
+}
+
+func writeShiftError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, utils.ErrShiftNotFound), errors.Is(err, utils.ErrNoOpenShift):
+		utils.ErrorResponse(w, http.StatusNotFound, err.Error(), "Not Found")
+	case errors.Is(err, utils.ErrShiftAlreadyOpen), errors.Is(err, utils.ErrShiftClosed):
+		utils.ErrorResponse(w, http.StatusConflict, err.Error(), "Conflict")
+	case errors.Is(err, utils.ErrShiftOtherOutlet):
+		utils.ErrorResponse(w, http.StatusForbidden, err.Error(), "Forbidden")
+	case errors.Is(err, utils.ErrInvalidShift), errors.Is(err, utils.ErrInvalidCashMovement):
+		utils.ErrorResponse(w, http.StatusBadRequest, err.Error(), "Bad Request")
+	default:
+		utils.ErrorResponse(w, http.StatusInternalServerError, "Failed to process shift", err.Error())
+	}
+}
+
+func shiftIDFromPath(path string) int {
+	idStr, _, _ := strings.Cut(strings.TrimPrefix(path, "/api/shifts/"), "/")
+	id, _ := strconv.Atoi(idStr)
+	return id
+}
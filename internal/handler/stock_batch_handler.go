@@ -0,0 +1,38 @@
+package handler
+
+import (
+	"kasir-api-go/internal/service"
+	"kasir-api-go/internal/utils"
+	"net/http"
+	"strconv"
+)
+
+type StockBatchHandler struct {
+	service service.StockBatchService
+}
+
+func NewStockBatchHandler(service service.StockBatchService) *StockBatchHandler {
+	return &StockBatchHandler{
+		service: service,
+	}
+}
+
+// @Summary List batches nearing expiry
+// @Description Get the batches in stock that expire within the given number of days, soonest first, including those already expired
+// @Tags inventory
+// @Security BearerAuth
+// @Produce json
+// @Param days query int false "Days ahead to look (defaults to INVENTORY_EXPIRY_WARNING_DAYS)"
+// @Param outlet query string false "Outlet code (defaults to all outlets)"
+// @Success 200 {object} utils.JSONResponse{data=models.ExpiringBatches}
+// @Router /api/inventory/expiring [get]
+func (h *StockBatchHandler) GetExpiringBatches(w http.ResponseWriter, r *http.Request) {
+	days, _ := strconv.Atoi(r.URL.Query().Get("days"))
+
+	expiring, err := h.service.GetExpiring(days, r.URL.Query().Get("outlet"))
+	if err != nil {
+		utils.ErrorResponse(w, http.StatusInternalServerError, "Failed to fetch expiring batches", err.Error())
+		return
+	}
+	utils.SuccessResponse(w, http.StatusOK, "Success", expiring)
+}
@@ -0,0 +1,198 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"kasir-api-go/internal/middleware"
+	"kasir-api-go/internal/models"
+	"kasir-api-go/internal/service"
+	"kasir-api-go/internal/utils"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+type StockTakeHandler struct {
+	service service.StockTakeService
+}
+
+func NewStockTakeHandler(service service.StockTakeService) *StockTakeHandler {
+	return &StockTakeHandler{
+		service: service,
+	}
+}
+
+// @Summary List stock takes
+// @Description Get all physical inventory counts, most recent first
+// @Tags stock-takes
+// @Security BearerAuth
+// @Produce json
+// @Success 200 {object} utils.JSONResponse{data=[]models.StockTake}
+// @Router /api/stock-takes [get]
+func (h *StockTakeHandler) GetStockTakes(w http.ResponseWriter, r *http.Request) {
+	takes, err := h.service.GetAll()
+	if err != nil {
+		utils.ErrorResponse(w, http.StatusInternalServerError, "Failed to fetch stock takes", err.Error())
+		return
+	}
+	utils.SuccessResponse(w, http.StatusOK, "Success", stockTakesFor(r, takes))
+}
+
+// @Summary Open a stock take
+// @Description Start a physical inventory count at the caller's outlet, snapshotting its stock of every product
+// @Tags stock-takes
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param X-Outlet-Code header string false "Outlet code (defaults to the user's outlet, then the default outlet)"
+// @Param request body models.OpenStockTakeRequest true "Open Stock Take Request object"
+// @Success 201 {object} utils.JSONResponse{data=models.StockTake}
+// @Failure 400 {object} utils.JSONResponse
+// @Failure 409 {object} utils.JSONResponse
+// @Router /api/stock-takes [post]
+func (h *StockTakeHandler) OpenStockTake(w http.ResponseWriter, r *http.Request) {
+	var req models.OpenStockTakeRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		utils.ErrorResponse(w, http.StatusBadRequest, "Invalid request body", err.Error())
+		return
+	}
+
+	actor, _ := middleware.UserFromContext(r.Context())
+	outlet, _ := middleware.OutletFromContext(r.Context())
+	take, err := h.service.Open(req, actor, outlet.ID)
+	if err != nil {
+		writeStockTakeError(w, err)
+		return
+	}
+	utils.SuccessResponse(w, http.StatusCreated, "Stock take opened successfully", stockTakeFor(r, take))
+}
+
+// @Summary Get a stock take detail
+// @Description Get a stock take with its items, variances and variance value summary
+// @Tags stock-takes
+// @Security BearerAuth
+// @Produce json
+// @Param id path int true "Stock Take ID"
+// @Success 200 {object} utils.JSONResponse{data=models.StockTake}
+// @Failure 404 {object} utils.JSONResponse
+// @Router /api/stock-takes/{id} [get]
+func (h *StockTakeHandler) GetStockTakeDetail(w http.ResponseWriter, r *http.Request) {
+	take, err := h.service.GetByID(stockTakeIDFromPath(r.URL.Path))
+	if err != nil {
+		writeStockTakeError(w, err)
+		return
+	}
+	utils.SuccessResponse(w, http.StatusOK, "Success", stockTakeFor(r, take))
+}
+
+// @Summary Submit counted quantities
+// @Description Record a batch of counted quantities on an open stock take. Counting a product again replaces its earlier count.
+// @Tags stock-takes
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param id path int true "Stock Take ID"
+// @Param request body models.StockCountRequest true "Stock Count Request object"
+// @Success 200 {object} utils.JSONResponse{data=models.StockTake}
+// @Failure 400 {object} utils.JSONResponse
+// @Failure 403 {object} utils.JSONResponse
+// @Failure 404 {object} utils.JSONResponse
+// @Failure 409 {object} utils.JSONResponse
+// @Router /api/stock-takes/{id}/counts [post]
+func (h *StockTakeHandler) SubmitCounts(w http.ResponseWriter, r *http.Request) {
+	var req models.StockCountRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		utils.ErrorResponse(w, http.StatusBadRequest, "Invalid request body", err.Error())
+		return
+	}
+
+	actor, _ := middleware.UserFromContext(r.Context())
+	take, err := h.service.SubmitCounts(stockTakeIDFromPath(r.URL.Path), req, actor)
+	if err != nil {
+		writeStockTakeError(w, err)
+		return
+	}
+	utils.SuccessResponse(w, http.StatusOK, "Counts recorded successfully", stockTakeFor(r, take))
+}
+
+// @Summary Approve a stock take
+// @Description Adjust the stock of every counted product by its variance and close the stock take
+// @Tags stock-takes
+// @Security BearerAuth
+// @Produce json
+// @Param id path int true "Stock Take ID"
+// @Success 200 {object} utils.JSONResponse{data=models.StockTake}
+// @Failure 403 {object} utils.JSONResponse
+// @Failure 404 {object} utils.JSONResponse
+// @Failure 409 {object} utils.JSONResponse
+// @Router /api/stock-takes/{id}/approve [post]
+func (h *StockTakeHandler) ApproveStockTake(w http.ResponseWriter, r *http.Request) {
+	actor, _ := middleware.UserFromContext(r.Context())
+	take, err := h.service.Approve(stockTakeIDFromPath(r.URL.Path), actor)
+	if err != nil {
+		writeStockTakeError(w, err)
+		return
+	}
+	utils.SuccessResponse(w, http.StatusOK, "Stock take approved successfully", stockTakeFor(r, take))
+}
+
+// @Summary Cancel a stock take
+// @Description Close a stock take without adjusting any stock
+// @Tags stock-takes
+// @Security BearerAuth
+// @Produce json
+// @Param id path int true "Stock Take ID"
+// @Success 200 {object} utils.JSONResponse{data=models.StockTake}
+// @Failure 403 {object} utils.JSONResponse
+// @Failure 404 {object} utils.JSONResponse
+// @Failure 409 {object} utils.JSONResponse
+// @Router /api/stock-takes/{id}/cancel [post]
+func (h *StockTakeHandler) CancelStockTake(w http.ResponseWriter, r *http.Request) {
+	actor, _ := middleware.UserFromContext(r.Context())
+	take, err := h.service.Cancel(stockTakeIDFromPath(r.URL.Path), actor)
+	if err != nil {
+		writeStockTakeError(w, err)
+		return
+	}
+	utils.SuccessResponse(w, http.StatusOK, "Stock take cancelled successfully", stockTakeFor(r, take))
+}
+
+// stockTakeFor leaves the values out of take unless the caller may see costs.
+func stockTakeFor(r *http.Request, take models.StockTake) models.StockTake {
+	if actor, _ := middleware.UserFromContext(r.Context()); actor.CanViewCost() {
+		return take
+	}
+	return take.WithoutCost()
+}
+
+func stockTakesFor(r *http.Request, takes []models.StockTake) []models.StockTake {
+	if actor, _ := middleware.UserFromContext(r.Context()); actor.CanViewCost() {
+		return takes
+	}
+	hidden := make([]models.StockTake, len(takes))
+	for i, take := range takes {
+		hidden[i] = take.WithoutCost()
+	}
+	return hidden
+}
+
+func writeStockTakeError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, utils.ErrStockTakeNotFound):
+		utils.ErrorResponse(w, http.StatusNotFound, err.Error(), "Not Found")
+	case errors.Is(err, utils.ErrStockTakeAlreadyOpen), errors.Is(err, utils.ErrStockTakeNotOpen):
+		utils.ErrorResponse(w, http.StatusConflict, err.Error(), "Conflict")
+	case errors.Is(err, utils.ErrOutletForbidden):
+		utils.ErrorResponse(w, http.StatusForbidden, err.Error(), "Forbidden")
+	case errors.Is(err, utils.ErrInvalidStockCount):
+		utils.ErrorResponse(w, http.StatusBadRequest, err.Error(), "Bad Request")
+	default:
+		utils.ErrorResponse(w, http.StatusInternalServerError, "Failed to process stock take", err.Error())
+	}
+}
+
+func stockTakeIDFromPath(path string) int {
+	idStr, _, _ := strings.Cut(strings.TrimPrefix(path, "/api/stock-takes/"), "/")
+	id, _ := strconv.Atoi(idStr)
+	return id
+}
@@ -0,0 +1,238 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"kasir-api-go/internal/middleware"
+	"kasir-api-go/internal/models"
+	"kasir-api-go/internal/service"
+	"kasir-api-go/internal/utils"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+type StockTransferHandler struct {
+	service service.StockTransferService
+}
+
+func NewStockTransferHandler(service service.StockTransferService) *StockTransferHandler {
+	return &StockTransferHandler{
+		service: service,
+	}
+}
+
+// @Summary List stock transfers
+// @Description Get stock transfers between outlets, most recent first. Transfers with status sent are in transit.
+// @Tags stock-transfers
+// @Security BearerAuth
+// @Produce json
+// @Param status query string false "Only transfers with this status (draft, sent, received or cancelled)"
+// @Param outlet query string false "Only transfers from or to this outlet code (defaults to all outlets)"
+// @Success 200 {object} utils.JSONResponse{data=[]models.StockTransfer}
+// @Router /api/stock-transfers [get]
+func (h *StockTransferHandler) GetStockTransfers(w http.ResponseWriter, r *http.Request) {
+	status := models.StockTransferStatus(r.URL.Query().Get("status"))
+	transfers, err := h.service.GetAll(status, r.URL.Query().Get("outlet"))
+	if err != nil {
+		utils.ErrorResponse(w, http.StatusInternalServerError, "Failed to fetch stock transfers", err.Error())
+		return
+	}
+	utils.SuccessResponse(w, http.StatusOK, "Success", transfers)
+}
+
+// @Summary Draft a stock transfer
+// @Description Draft a transfer of goods to another outlet. The goods come from from_outlet_id, or from the caller's outlet when it is left out.
+// @Tags stock-transfers
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param X-Outlet-Code header string false "Outlet code (defaults to the user's outlet, then the default outlet)"
+// @Param request body models.StockTransferRequest true "Stock Transfer Request object"
+// @Success 201 {object} utils.JSONResponse{data=models.StockTransfer}
+// @Failure 400 {object} utils.JSONResponse
+// @Failure 403 {object} utils.JSONResponse
+// @Router /api/stock-transfers [post]
+func (h *StockTransferHandler) CreateStockTransfer(w http.ResponseWriter, r *http.Request) {
+	var req models.StockTransferRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		utils.ErrorResponse(w, http.StatusBadRequest, "Invalid request body", err.Error())
+		return
+	}
+
+	actor, _ := middleware.UserFromContext(r.Context())
+	outlet, _ := middleware.OutletFromContext(r.Context())
+	transfer, err := h.service.Create(req, actor, outlet.ID)
+	if err != nil {
+		writeStockTransferError(w, err)
+		return
+	}
+	utils.SuccessResponse(w, http.StatusCreated, "Stock transfer created successfully", transfer)
+}
+
+// @Summary Get a stock transfer detail
+// @Description Get a stock transfer with its lines, the lots they were sent in and, once received, their discrepancies
+// @Tags stock-transfers
+// @Security BearerAuth
+// @Produce json
+// @Param id path int true "Stock Transfer ID"
+// @Success 200 {object} utils.JSONResponse{data=models.StockTransfer}
+// @Failure 404 {object} utils.JSONResponse
+// @Router /api/stock-transfers/{id} [get]
+func (h *StockTransferHandler) GetStockTransferDetail(w http.ResponseWriter, r *http.Request) {
+	transfer, err := h.service.GetByID(stockTransferIDFromPath(r.URL.Path))
+	if err != nil {
+		writeStockTransferError(w, err)
+		return
+	}
+	utils.SuccessResponse(w, http.StatusOK, "Success", transfer)
+}
+
+// @Summary Update a draft stock transfer
+// @Description Replace the outlets, note and lines of a transfer that has not been sent yet. The origin stays as it is when from_outlet_id is left out.
+// @Tags stock-transfers
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param id path int true "Stock Transfer ID"
+// @Param request body models.StockTransferRequest true "Stock Transfer Request object"
+// @Success 200 {object} utils.JSONResponse{data=models.StockTransfer}
+// @Failure 400 {object} utils.JSONResponse
+// @Failure 403 {object} utils.JSONResponse
+// @Failure 404 {object} utils.JSONResponse
+// @Failure 409 {object} utils.JSONResponse
+// @Router /api/stock-transfers/{id} [put]
+func (h *StockTransferHandler) UpdateStockTransfer(w http.ResponseWriter, r *http.Request) {
+	var req models.StockTransferRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		utils.ErrorResponse(w, http.StatusBadRequest, "Invalid request body", err.Error())
+		return
+	}
+
+	actor, _ := middleware.UserFromContext(r.Context())
+	transfer, err := h.service.Update(stockTransferIDFromPath(r.URL.Path), req, actor)
+	if err != nil {
+		writeStockTransferError(w, err)
+		return
+	}
+	utils.SuccessResponse(w, http.StatusOK, "Stock transfer updated successfully", transfer)
+}
+
+// @Summary Send a stock transfer
+// @Description Take the goods of a draft out of the origin's stock. They are in transit until the destination receives them.
+// @Tags stock-transfers
+// @Security BearerAuth
+// @Produce json
+// @Param id path int true "Stock Transfer ID"
+// @Success 200 {object} utils.JSONResponse{data=models.StockTransfer}
+// @Failure 400 {object} utils.JSONResponse
+// @Failure 403 {object} utils.JSONResponse
+// @Failure 404 {object} utils.JSONResponse
+// @Failure 409 {object} utils.JSONResponse
+// @Router /api/stock-transfers/{id}/send [post]
+func (h *StockTransferHandler) SendStockTransfer(w http.ResponseWriter, r *http.Request) {
+	actor, _ := middleware.UserFromContext(r.Context())
+	transfer, err := h.service.Send(stockTransferIDFromPath(r.URL.Path), actor)
+	if err != nil {
+		writeStockTransferError(w, err)
+		return
+	}
+	utils.SuccessResponse(w, http.StatusOK, "Stock transfer sent successfully", transfer)
+}
+
+// @Summary Receive a stock transfer
+// @Description Add the goods of a transfer in transit to the destination's stock. Lines that are not listed are received as sent; list the lines that arrived short or over to record the discrepancy.
+// @Tags stock-transfers
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param id path int true "Stock Transfer ID"
+// @Param request body models.ReceiveStockTransferRequest true "Receive Stock Transfer Request object"
+// @Success 200 {object} utils.JSONResponse{data=models.StockTransfer}
+// @Failure 400 {object} utils.JSONResponse
+// @Failure 403 {object} utils.JSONResponse
+// @Failure 404 {object} utils.JSONResponse
+// @Failure 409 {object} utils.JSONResponse
+// @Router /api/stock-transfers/{id}/receive [post]
+func (h *StockTransferHandler) ReceiveStockTransfer(w http.ResponseWriter, r *http.Request) {
+	var req models.ReceiveStockTransferRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		utils.ErrorResponse(w, http.StatusBadRequest, "Invalid request body", err.Error())
+		return
+	}
+
+	actor, _ := middleware.UserFromContext(r.Context())
+	transfer, err := h.service.Receive(stockTransferIDFromPath(r.URL.Path), req, actor)
+	if err != nil {
+		writeStockTransferError(w, err)
+		return
+	}
+	utils.SuccessResponse(w, http.StatusOK, "Stock transfer received successfully", transfer)
+}
+
+// @Summary Cancel a stock transfer
+// @Description Drop a transfer that has not been sent yet
+// @Tags stock-transfers
+// @Security BearerAuth
+// @Produce json
+// @Param id path int true "Stock Transfer ID"
+// @Success 200 {object} utils.JSONResponse{data=models.StockTransfer}
+// @Failure 403 {object} utils.JSONResponse
+// @Failure 404 {object} utils.JSONResponse
+// @Failure 409 {object} utils.JSONResponse
+// @Router /api/stock-transfers/{id}/cancel [post]
+func (h *StockTransferHandler) CancelStockTransfer(w http.ResponseWriter, r *http.Request) {
+	actor, _ := middleware.UserFromContext(r.Context())
+	transfer, err := h.service.Cancel(stockTransferIDFromPath(r.URL.Path), actor)
+	if err != nil {
+		writeStockTransferError(w, err)
+		return
+	}
+	utils.SuccessResponse(w, http.StatusOK, "Stock transfer cancelled successfully", transfer)
+}
+
+// @Summary Get the transfer discrepancy report
+// @Description Get the lines of transfers received in a date range that arrived short or over, valued at cost
+// @Tags stock-transfers
+// @Security BearerAuth
+// @Produce json
+// @Param start_date query string true "Start date (YYYY-MM-DD)"
+// @Param end_date query string true "End date (YYYY-MM-DD)"
+// @Param outlet query string false "Only transfers from or to this outlet code (defaults to all outlets)"
+// @Success 200 {object} utils.JSONResponse{data=models.TransferDiscrepancyReport}
+// @Failure 400 {object} utils.JSONResponse
+// @Router /api/stock-transfers/discrepancies [get]
+func (h *StockTransferHandler) GetDiscrepancyReport(w http.ResponseWriter, r *http.Request) {
+	startDate, endDate, ok := dateRange(w, r)
+	if !ok {
+		return
+	}
+
+	report, err := h.service.GetDiscrepancyReport(startDate, endDate, r.URL.Query().Get("outlet"))
+	if err != nil {
+		utils.ErrorResponse(w, http.StatusInternalServerError, "Failed to fetch discrepancy report", err.Error())
+		return
+	}
+	utils.SuccessResponse(w, http.StatusOK, "Success", report)
+}
+
+func writeStockTransferError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, utils.ErrStockTransferNotFound):
+		utils.ErrorResponse(w, http.StatusNotFound, err.Error(), "Not Found")
+	case errors.Is(err, utils.ErrStockTransferNotDraft), errors.Is(err, utils.ErrStockTransferNotSent):
+		utils.ErrorResponse(w, http.StatusConflict, err.Error(), "Conflict")
+	case errors.Is(err, utils.ErrOutletForbidden):
+		utils.ErrorResponse(w, http.StatusForbidden, err.Error(), "Forbidden")
+	case errors.Is(err, utils.ErrInvalidStockTransfer), errors.Is(err, utils.ErrInvalidTransferReceipt):
+		utils.ErrorResponse(w, http.StatusBadRequest, err.Error(), "Bad Request")
+	default:
+		utils.ErrorResponse(w, http.StatusInternalServerError, "Failed to process stock transfer", err.Error())
+	}
+}
+
+func stockTransferIDFromPath(path string) int {
+	idStr, _, _ := strings.Cut(strings.TrimPrefix(path, "/api/stock-transfers/"), "/")
+	id, _ := strconv.Atoi(idStr)
+	return id
+}
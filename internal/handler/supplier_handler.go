@@ -0,0 +1,148 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"kasir-api-go/internal/models"
+	"kasir-api-go/internal/service"
+	"kasir-api-go/internal/utils"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+type SupplierHandler struct {
+	service service.SupplierService
+}
+
+func NewSupplierHandler(service service.SupplierService) *SupplierHandler {
+	return &SupplierHandler{
+		service: service,
+	}
+}
+
+// @Summary List all suppliers
+// @Description Get a list of all suppliers
+// @Tags suppliers
+// @Security BearerAuth
+// @Produce json
+// @Success 200 {object} utils.JSONResponse{data=[]models.Supplier}
+// @Router /api/suppliers [get]
+func (h *SupplierHandler) GetSuppliers(w http.ResponseWriter, r *http.Request) {
+	suppliers, err := h.service.GetAll()
+	if err != nil {
+		utils.ErrorResponse(w, http.StatusInternalServerError, "Failed to fetch suppliers", err.Error())
+		return
+	}
+	utils.SuccessResponse(w, http.StatusOK, "Success", suppliers)
+}
+
+// @Summary Create a new supplier
+// @Description Add a supplier that purchase orders can be placed with
+// @Tags suppliers
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param supplier body models.Supplier true "Supplier object"
+// @Success 201 {object} utils.JSONResponse{data=models.Supplier}
+// @Failure 400 {object} utils.JSONResponse
+// @Router /api/suppliers [post]
+func (h *SupplierHandler) CreateSupplier(w http.ResponseWriter, r *http.Request) {
+	var supplier models.Supplier
+	if err := json.NewDecoder(r.Body).Decode(&supplier); err != nil {
+		utils.ErrorResponse(w, http.StatusBadRequest, "Invalid request body", err.Error())
+		return
+	}
+
+	createdSupplier, err := h.service.Create(supplier)
+	if err != nil {
+		writeSupplierError(w, err)
+		return
+	}
+	utils.SuccessResponse(w, http.StatusCreated, "Supplier created successfully", createdSupplier)
+}
+
+// @Summary Get a supplier detail
+// @Description Get details of a supplier by ID
+// @Tags suppliers
+// @Security BearerAuth
+// @Produce json
+// @Param id path int true "Supplier ID"
+// @Success 200 {object} utils.JSONResponse{data=models.Supplier}
+// @Failure 404 {object} utils.JSONResponse
+// @Router /api/suppliers/{id} [get]
+func (h *SupplierHandler) GetSupplierDetail(w http.ResponseWriter, r *http.Request) {
+	idStr := strings.TrimPrefix(r.URL.Path, "/api/suppliers/")
+	id, _ := strconv.Atoi(idStr)
+
+	supplier, err := h.service.GetByID(id)
+	if err != nil {
+		writeSupplierError(w, err)
+		return
+	}
+	utils.SuccessResponse(w, http.StatusOK, "Success", supplier)
+}
+
+// @Summary Update a supplier
+// @Description Update an existing supplier
+// @Tags suppliers
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param id path int true "Supplier ID"
+// @Param supplier body models.Supplier true "Supplier object"
+// @Success 200 {object} utils.JSONResponse{data=models.Supplier}
+// @Failure 400 {object} utils.JSONResponse
+// @Failure 404 {object} utils.JSONResponse
+// @Router /api/suppliers/{id} [put]
+func (h *SupplierHandler) UpdateSupplier(w http.ResponseWriter, r *http.Request) {
+	idStr := strings.TrimPrefix(r.URL.Path, "/api/suppliers/")
+	id, _ := strconv.Atoi(idStr)
+
+	var supplier models.Supplier
+	if err := json.NewDecoder(r.Body).Decode(&supplier); err != nil {
+		utils.ErrorResponse(w, http.StatusBadRequest, "Invalid request body", err.Error())
+		return
+	}
+
+	updatedSupplier, err := h.service.Update(id, supplier)
+	if err != nil {
+		writeSupplierError(w, err)
+		return
+	}
+	utils.SuccessResponse(w, http.StatusOK, "Supplier updated successfully", updatedSupplier)
+}
+
+// @Summary Delete a supplier
+// @Description Remove a supplier that has no purchase orders
+// @Tags suppliers
+// @Security BearerAuth
+// @Produce json
+// @Param id path int true "Supplier ID"
+// @Success 200 {object} utils.JSONResponse
+// @Failure 404 {object} utils.JSONResponse
+// @Failure 409 {object} utils.JSONResponse
+// @Router /api/suppliers/{id} [delete]
+func (h *SupplierHandler) DeleteSupplier(w http.ResponseWriter, r *http.Request) {
+	idStr := strings.TrimPrefix(r.URL.Path, "/api/suppliers/")
+	id, _ := strconv.Atoi(idStr)
+
+	if err := h.service.Delete(id); err != nil {
+		writeSupplierError(w, err)
+		return
+	}
+	utils.SuccessResponse(w, http.StatusOK, "Supplier deleted successfully", nil)
+}
+
+func writeSupplierError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, utils.ErrSupplierNotFound):
+		utils.ErrorResponse(w, http.StatusNotFound, err.Error(), "Not Found")
+	case errors.Is(err, utils.ErrSupplierInUse):
+		utils.ErrorResponse(w, http.StatusConflict, err.Error(), "Conflict")
+	case errors.Is(err, utils.ErrInvalidSupplier):
+		utils.ErrorResponse(w, http.StatusBadRequest, err.Error(), "Bad Request")
+	default:
+		utils.ErrorResponse(w, http.StatusInternalServerError, "Failed to process supplier", err.Error())
+	}
+}
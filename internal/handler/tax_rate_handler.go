@@ -0,0 +1,147 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"kasir-api-go/internal/models"
+	"kasir-api-go/internal/service"
+	"kasir-api-go/internal/utils"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+type TaxRateHandler struct {
+	service service.TaxRateService
+}
+
+func NewTaxRateHandler(service service.TaxRateService) *TaxRateHandler {
+	return &TaxRateHandler{
+		service: service,
+	}
+}
+
+// @Summary List all tax rates
+// @Description Get a list of all tax rates
+// @Tags tax-rates
+// @Security BearerAuth
+// @Produce json
+// @Success 200 {object} utils.JSONResponse{data=[]models.TaxRate}
+// @Router /api/tax-rates [get]
+func (h *TaxRateHandler) GetTaxRates(w http.ResponseWriter, r *http.Request) {
+	taxRates, err := h.service.GetAll()
+	if err != nil {
+		utils.ErrorResponse(w, http.StatusInternalServerError, "Failed to fetch tax rates", err.Error())
+		return
+	}
+	utils.SuccessResponse(w, http.StatusOK, "Success", taxRates)
+}
+
+// @Summary Create a new tax rate
+// @Description Add a new tax rate that can be assigned to products and categories
+// @Tags tax-rates
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param taxRate body models.TaxRate true "Tax rate object"
+// @Success 201 {object} utils.JSONResponse{data=models.TaxRate}
+// @Failure 400 {object} utils.JSONResponse
+// @Router /api/tax-rates [post]
+func (h *TaxRateHandler) CreateTaxRate(w http.ResponseWriter, r *http.Request) {
+	var taxRate models.TaxRate
+	if err := json.NewDecoder(r.Body).Decode(&taxRate); err != nil {
+		utils.ErrorResponse(w, http.StatusBadRequest, "Invalid request body", err.Error())
+		return
+	}
+
+	createdTaxRate, err := h.service.Create(taxRate)
+	if errors.Is(err, utils.ErrInvalidTaxRate) {
+		utils.ErrorResponse(w, http.StatusBadRequest, err.Error(), "Bad Request")
+		return
+	}
+	if err != nil {
+		utils.ErrorResponse(w, http.StatusInternalServerError, "Failed to create tax rate", err.Error())
+		return
+	}
+
+	utils.SuccessResponse(w, http.StatusCreated, "Tax rate created successfully", createdTaxRate)
+}
+
+// @Summary Get a tax rate detail
+// @Description Get details of a tax rate by ID
+// @Tags tax-rates
+// @Security BearerAuth
+// @Produce json
+// @Param id path int true "Tax rate ID"
+// @Success 200 {object} utils.JSONResponse{data=models.TaxRate}
+// @Failure 404 {object} utils.JSONResponse
+// @Router /api/tax-rates/{id} [get]
+func (h *TaxRateHandler) GetTaxRateDetail(w http.ResponseWriter, r *http.Request) {
+	idStr := strings.TrimPrefix(r.URL.Path, "/api/tax-rates/")
+	id, _ := strconv.Atoi(idStr)
+
+	taxRate, err := h.service.GetByID(id)
+	if err != nil {
+		utils.ErrorResponse(w, http.StatusNotFound, "Tax rate not found", "Tax rate not found")
+		return
+	}
+	utils.SuccessResponse(w, http.StatusOK, "Success", taxRate)
+}
+
+// @Summary Update a tax rate
+// @Description Update an existing tax rate
+// @Tags tax-rates
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param id path int true "Tax rate ID"
+// @Param taxRate body models.TaxRate true "Tax rate object"
+// @Success 200 {object} utils.JSONResponse{data=models.TaxRate}
+// @Failure 400 {object} utils.JSONResponse
+// @Failure 404 {object} utils.JSONResponse
+// @Router /api/tax-rates/{id} [put]
+func (h *TaxRateHandler) UpdateTaxRate(w http.ResponseWriter, r *http.Request) {
+	idStr := strings.TrimPrefix(r.URL.Path, "/api/tax-rates/")
+	id, _ := strconv.Atoi(idStr)
+
+	var taxRate models.TaxRate
+	if err := json.NewDecoder(r.Body).Decode(&taxRate); err != nil {
+		utils.ErrorResponse(w, http.StatusBadRequest, "Invalid request body", err.Error())
+		return
+	}
+
+	updatedTaxRate, err := h.service.Update(id, taxRate)
+	if errors.Is(err, utils.ErrInvalidTaxRate) {
+		utils.ErrorResponse(w, http.StatusBadRequest, err.Error(), "Bad Request")
+		return
+	}
+	if errors.Is(err, utils.ErrTaxRateNotFound) {
+		utils.ErrorResponse(w, http.StatusNotFound, "Tax rate not found", "Tax rate not found")
+		return
+	}
+	if err != nil {
+		utils.ErrorResponse(w, http.StatusInternalServerError, "Failed to update tax rate", err.Error())
+		return
+	}
+	utils.SuccessResponse(w, http.StatusOK, "Tax rate updated successfully", updatedTaxRate)
+}
+
+// @Summary Delete a tax rate
+// @Description Remove a tax rate
+// @Tags tax-rates
+// @Security BearerAuth
+// @Produce json
+// @Param id path int true "Tax rate ID"
+// @Success 200 {object} utils.JSONResponse
+// @Failure 404 {object} utils.JSONResponse
+// @Router /api/tax-rates/{id} [delete]
+func (h *TaxRateHandler) DeleteTaxRate(w http.ResponseWriter, r *http.Request) {
+	idStr := strings.TrimPrefix(r.URL.Path, "/api/tax-rates/")
+	id, _ := strconv.Atoi(idStr)
+
+	if err := h.service.Delete(id); err != nil {
+		utils.ErrorResponse(w, http.StatusNotFound, "Tax rate not found", "Tax rate not found")
+		return
+	}
+	utils.SuccessResponse(w, http.StatusOK, "Tax rate deleted successfully", nil)
+}
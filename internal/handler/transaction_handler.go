@@ -2,6 +2,8 @@ package handler
 
 import (
 	"encoding/json"
+	"errors"
+	"kasir-api-go/internal/middleware"
 	"kasir-api-go/internal/models"
 	"kasir-api-go/internal/service"
 	"kasir-api-go/internal/utils"
@@ -21,28 +23,56 @@ func NewTransactionHandler(service service.TransactionService) *TransactionHandl
 }
 
 // @Summary List all transactions
-// @Description Get a list of all transactions including their details
+// @Description Get a list of all transactions including their details, or the single transaction with the given invoice number
 // @Tags transactions
+// @Security BearerAuth
 // @Produce json
+// @Param invoice_number query string false "Invoice number to look up at the caller's outlet, e.g. INV/20261017/0001"
 // @Success 200 {object} utils.JSONResponse{data=[]models.Transaction}
+// @Failure 404 {object} utils.JSONResponse
 // @Router /api/transactions [get]
 func (h *TransactionHandler) GetTransactions(w http.ResponseWriter, r *http.Request) {
+	if invoiceNumber := r.URL.Query().Get("invoice_number"); invoiceNumber != "" {
+		outlet, _ := middleware.OutletFromContext(r.Context())
+		transaction, err := h.service.GetTransactionByInvoiceNumber(outlet.Code, invoiceNumber)
+		if errors.Is(err, utils.ErrTransactionNotFound) {
+			utils.ErrorResponse(w, http.StatusNotFound, "Transaction not found", err.Error())
+			return
+		}
+		if err != nil {
+			utils.ErrorResponse(w, http.StatusInternalServerError, "Failed to fetch transaction", err.Error())
+			return
+		}
+		utils.SuccessResponse(w, http.StatusOK, "Success", transactionFor(r, transaction))
+		return
+	}
+
 	transactions, err := h.service.GetAllTransactions()
 	if err != nil {
 		utils.ErrorResponse(w, http.StatusInternalServerError, "Failed to fetch transactions", err.Error())
 		return
 	}
+	if actor, _ := middleware.UserFromContext(r.Context()); !actor.CanViewCost() {
+		for i := range transactions {
+			transactions[i] = transactions[i].WithoutCost()
+		}
+	}
 	utils.SuccessResponse(w, http.StatusOK, "Success", transactions)
 }
 
 // @Summary Checkout transactions
-// @Description Create a new transaction from multiple items and update stock
+// @Description Create a new transaction from multiple items, record the payment and update stock. An item sells a variant with variant_id.
 // @Tags transactions
+// @Security BearerAuth
 // @Accept json
 // @Produce json
+// @Param Idempotency-Key header string false "Key that makes retries of the same checkout replay the original response"
+// @Param X-Outlet-Code header string false "Outlet selling (defaults to the user's outlet, then the default outlet)"
 // @Param request body models.CheckoutRequest true "Checkout Request object"
 // @Success 201 {object} models.Transaction
 // @Failure 400 {string} string "Invalid request body"
+// @Failure 403 {string} string "A price override or large discount needs a supervisor approval"
+// @Failure 409 {string} string "Idempotency key reused with a different request, or the day is closed"
 // @Failure 500 {string} string "Internal server error"
 // @Router /api/checkout [post]
 func (h *TransactionHandler) HandleCheckout(w http.ResponseWriter, r *http.Request) {
@@ -62,7 +92,29 @@ func (h *TransactionHandler) Checkout(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	transaction, err := h.service.Checkout(req.Items)
+	// Retries carrying the same key replay the original transaction
+	req.IdempotencyKey = strings.TrimSpace(r.Header.Get("Idempotency-Key"))
+	req.Outlet, _ = middleware.OutletFromContext(r.Context())
+	req.Actor, _ = middleware.UserFromContext(r.Context())
+
+	transaction, err := h.service.Checkout(req)
+	if errors.Is(err, utils.ErrIdempotencyKeyConflict) || errors.Is(err, utils.ErrDayClosed) {
+		http.Error(w, err.Error(), http.StatusConflict)
+		return
+	}
+	if isPaymentError(err) || errors.Is(err, utils.ErrInvalidIdempotencyKey) || errors.Is(err, utils.ErrInvalidOverride) ||
+		errors.Is(err, utils.ErrInvalidVariant) || errors.Is(err, utils.ErrInvalidItemQuantity) {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if errors.Is(err, utils.ErrApprovalRequired) || errors.Is(err, utils.ErrApprovalDenied) {
+		http.Error(w, err.Error(), http.StatusForbidden)
+		return
+	}
+	if errors.Is(err, utils.ErrAccountLocked) {
+		http.Error(w, err.Error(), http.StatusTooManyRequests)
+		return
+	}
 	if err != nil {
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
@@ -70,25 +122,144 @@ func (h *TransactionHandler) Checkout(w http.ResponseWriter, r *http.Request) {
 
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(http.StatusCreated)
-	json.NewEncoder(w).Encode(transaction)
+	json.NewEncoder(w).Encode(transactionFor(r, transaction))
+}
+
+// transactionFor leaves the cost of goods sold out of transaction unless the
+// caller may see it.
+func transactionFor(r *http.Request, transaction models.Transaction) models.Transaction {
+	if actor, _ := middleware.UserFromContext(r.Context()); actor.CanViewCost() {
+		return transaction
+	}
+	return transaction.WithoutCost()
+}
+
+func refundFor(r *http.Request, refund models.Refund) models.Refund {
+	if actor, _ := middleware.UserFromContext(r.Context()); actor.CanViewCost() {
+		return refund
+	}
+	return refund.WithoutCost()
+}
+
+func isPaymentError(err error) bool {
+	return errors.Is(err, utils.ErrInvalidPaymentMethod) ||
+		errors.Is(err, utils.ErrInvalidPaymentAmount) ||
+		errors.Is(err, utils.ErrInsufficientPayment) ||
+		errors.Is(err, utils.ErrNonCashOverpayment)
 }
 
 // @Summary Get a transaction detail
-// @Description Get details of a transaction by ID
+// @Description Get details of a transaction by ID, including the payment breakdown
 // @Tags transactions
+// @Security BearerAuth
 // @Produce json
 // @Param id path int true "Transaction ID"
 // @Success 200 {object} utils.JSONResponse{data=models.Transaction}
 // @Failure 404 {object} utils.JSONResponse
 // @Router /api/transactions/{id} [get]
 func (h *TransactionHandler) GetTransactionDetail(w http.ResponseWriter, r *http.Request) {
-	idStr := strings.TrimPrefix(r.URL.Path, "/api/transactions/")
-	id, _ := strconv.Atoi(idStr)
+	id := transactionIDFromPath(r.URL.Path)
 
 	transaction, err := h.service.GetTransactionByID(id)
-	if err != nil {
+	if errors.Is(err, utils.ErrTransactionNotFound) {
 		utils.ErrorResponse(w, http.StatusNotFound, "Transaction not found", "Transaction not found")
 		return
 	}
-	utils.SuccessResponse(w, http.StatusOK, "Success", transaction)
+	if err != nil {
+		utils.ErrorResponse(w, http.StatusInternalServerError, "Failed to fetch transaction", err.Error())
+		return
+	}
+	utils.SuccessResponse(w, http.StatusOK, "Success", transactionFor(r, transaction))
+}
+
+// @Summary Void a transaction
+// @Description Cancel a whole transaction of the current business day and return every item to stock. Only sales of the caller's outlet can be voided unless the caller has outlets.manage.
+// @Tags transactions
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param id path int true "Transaction ID"
+// @Param X-Outlet-Code header string false "Outlet code (defaults to the user's outlet, then the default outlet)"
+// @Param request body models.VoidRequest true "Void Request object"
+// @Success 200 {object} utils.JSONResponse{data=models.Refund}
+// @Failure 400 {object} utils.JSONResponse
+// @Failure 403 {object} utils.JSONResponse
+// @Failure 404 {object} utils.JSONResponse
+// @Failure 409 {object} utils.JSONResponse
+// @Router /api/transactions/{id}/void [post]
+func (h *TransactionHandler) VoidTransaction(w http.ResponseWriter, r *http.Request) {
+	id := transactionIDFromPath(r.URL.Path)
+
+	var req models.VoidRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		utils.ErrorResponse(w, http.StatusBadRequest, "Invalid request body", err.Error())
+		return
+	}
+	req.Actor, _ = middleware.UserFromContext(r.Context())
+	req.Outlet, _ = middleware.OutletFromContext(r.Context())
+
+	refund, err := h.service.VoidTransaction(id, req)
+	if err != nil {
+		writeRefundError(w, err)
+		return
+	}
+	utils.SuccessResponse(w, http.StatusOK, "Transaction voided successfully", refundFor(r, refund))
+}
+
+// @Summary Refund transaction items
+// @Description Return part of a transaction and put the returned items back in stock. Only sales of the caller's outlet can be refunded unless the caller has outlets.manage.
+// @Tags transactions
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param id path int true "Transaction ID"
+// @Param X-Outlet-Code header string false "Outlet code (defaults to the user's outlet, then the default outlet)"
+// @Param request body models.RefundRequest true "Refund Request object"
+// @Success 200 {object} utils.JSONResponse{data=models.Refund}
+// @Failure 400 {object} utils.JSONResponse
+// @Failure 403 {object} utils.JSONResponse
+// @Failure 404 {object} utils.JSONResponse
+// @Failure 409 {object} utils.JSONResponse
+// @Router /api/transactions/{id}/refund [post]
+func (h *TransactionHandler) RefundTransaction(w http.ResponseWriter, r *http.Request) {
+	id := transactionIDFromPath(r.URL.Path)
+
+	var req models.RefundRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		utils.ErrorResponse(w, http.StatusBadRequest, "Invalid request body", err.Error())
+		return
+	}
+	req.Actor, _ = middleware.UserFromContext(r.Context())
+	req.Outlet, _ = middleware.OutletFromContext(r.Context())
+
+	refund, err := h.service.RefundTransaction(id, req)
+	if err != nil {
+		writeRefundError(w, err)
+		return
+	}
+	utils.SuccessResponse(w, http.StatusOK, "Transaction refunded successfully", refundFor(r, refund))
+}
+
+func writeRefundError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, utils.ErrTransactionNotFound):
+		utils.ErrorResponse(w, http.StatusNotFound, "Transaction not found", err.Error())
+	case errors.Is(err, utils.ErrTransactionNotRefundable), errors.Is(err, utils.ErrDayClosed), errors.Is(err, utils.ErrVoidNotToday):
+		utils.ErrorResponse(w, http.StatusConflict, err.Error(), "Conflict")
+	case errors.Is(err, utils.ErrRefundReasonRequired), errors.Is(err, utils.ErrInvalidRefundQuantity):
+		utils.ErrorResponse(w, http.StatusBadRequest, err.Error(), "Bad Request")
+	case errors.Is(err, utils.ErrApprovalRequired), errors.Is(err, utils.ErrApprovalDenied), errors.Is(err, utils.ErrOutletForbidden):
+		utils.ErrorResponse(w, http.StatusForbidden, err.Error(), "Forbidden")
+	case errors.Is(err, utils.ErrAccountLocked):
+		utils.ErrorResponse(w, http.StatusTooManyRequests, err.Error(), "Too Many Requests")
+	default:
+		utils.ErrorResponse(w, http.StatusInternalServerError, "Failed to refund transaction", err.Error())
+	}
+}
+
+// transactionIDFromPath reads the id from /api/transactions/{id}[/action].
+func transactionIDFromPath(path string) int {
+	idStr, _, _ := strings.Cut(strings.TrimPrefix(path, "/api/transactions/"), "/")
+	id, _ := strconv.Atoi(idStr)
+	return id
 }
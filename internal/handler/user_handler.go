@@ -0,0 +1,145 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"kasir-api-go/internal/models"
+	"kasir-api-go/internal/service"
+	"kasir-api-go/internal/utils"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+type UserHandler struct {
+	service service.UserService
+}
+
+func NewUserHandler(service service.UserService) *UserHandler {
+	return &UserHandler{
+		service: service,
+	}
+}
+
+// @Summary List all users
+// @Description Get a list of all users
+// @Tags users
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {object} utils.JSONResponse{data=[]models.User}
+// @Router /api/users [get]
+func (h *UserHandler) GetUsers(w http.ResponseWriter, r *http.Request) {
+	users, err := h.service.GetAll()
+	if err != nil {
+		utils.ErrorResponse(w, http.StatusInternalServerError, "Failed to fetch users", err.Error())
+		return
+	}
+	utils.SuccessResponse(w, http.StatusOK, "Success", users)
+}
+
+// @Summary Create a new user
+// @Description Add a user with a password and, for the till, an optional PIN
+// @Tags users
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param request body models.CreateUserRequest true "Create User Request object"
+// @Success 201 {object} utils.JSONResponse{data=models.User}
+// @Failure 400 {object} utils.JSONResponse
+// @Failure 409 {object} utils.JSONResponse
+// @Router /api/users [post]
+func (h *UserHandler) CreateUser(w http.ResponseWriter, r *http.Request) {
+	var req models.CreateUserRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		utils.ErrorResponse(w, http.StatusBadRequest, "Invalid request body", err.Error())
+		return
+	}
+
+	user, err := h.service.Create(req)
+	if err != nil {
+		writeUserError(w, err)
+		return
+	}
+	utils.SuccessResponse(w, http.StatusCreated, "User created successfully", user)
+}
+
+// @Summary Get a user detail
+// @Description Get details of a user by ID
+// @Tags users
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "User ID"
+// @Success 200 {object} utils.JSONResponse{data=models.User}
+// @Failure 404 {object} utils.JSONResponse
+// @Router /api/users/{id} [get]
+func (h *UserHandler) GetUserDetail(w http.ResponseWriter, r *http.Request) {
+	id, _ := strconv.Atoi(strings.TrimPrefix(r.URL.Path, "/api/users/"))
+
+	user, err := h.service.GetByID(id)
+	if err != nil {
+		writeUserError(w, err)
+		return
+	}
+	utils.SuccessResponse(w, http.StatusOK, "Success", user)
+}
+
+// @Summary Update a user
+// @Description Update a user's profile, role, password or PIN. Tokens issued before the change stop working.
+// @Tags users
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "User ID"
+// @Param request body models.UpdateUserRequest true "Update User Request object"
+// @Success 200 {object} utils.JSONResponse{data=models.User}
+// @Failure 400 {object} utils.JSONResponse
+// @Failure 404 {object} utils.JSONResponse
+// @Router /api/users/{id} [put]
+func (h *UserHandler) UpdateUser(w http.ResponseWriter, r *http.Request) {
+	id, _ := strconv.Atoi(strings.TrimPrefix(r.URL.Path, "/api/users/"))
+
+	var req models.UpdateUserRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		utils.ErrorResponse(w, http.StatusBadRequest, "Invalid request body", err.Error())
+		return
+	}
+
+	user, err := h.service.Update(id, req)
+	if err != nil {
+		writeUserError(w, err)
+		return
+	}
+	utils.SuccessResponse(w, http.StatusOK, "User updated successfully", user)
+}
+
+// @Summary Delete a user
+// @Description Remove a user
+// @Tags users
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "User ID"
+// @Success 200 {object} utils.JSONResponse
+// @Failure 404 {object} utils.JSONResponse
+// @Router /api/users/{id} [delete]
+func (h *UserHandler) DeleteUser(w http.ResponseWriter, r *http.Request) {
+	id, _ := strconv.Atoi(strings.TrimPrefix(r.URL.Path, "/api/users/"))
+
+	if err := h.service.Delete(id); err != nil {
+		writeUserError(w, err)
+		return
+	}
+	utils.SuccessResponse(w, http.StatusOK, "User deleted successfully", nil)
+}
+
+func writeUserError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, utils.ErrUserNotFound):
+		utils.ErrorResponse(w, http.StatusNotFound, "User not found", err.Error())
+	case errors.Is(err, utils.ErrUsernameTaken):
+		utils.ErrorResponse(w, http.StatusConflict, err.Error(), "Conflict")
+	case errors.Is(err, utils.ErrInvalidUser):
+		utils.ErrorResponse(w, http.StatusBadRequest, err.Error(), "Bad Request")
+	default:
+		utils.ErrorResponse(w, http.StatusInternalServerError, "Failed to process user", err.Error())
+	}
+}
@@ -0,0 +1,92 @@
+package middleware
+
+import (
+	"context"
+	"errors"
+	"kasir-api-go/internal/models"
+	"kasir-api-go/internal/service"
+	"kasir-api-go/internal/utils"
+	"net/http"
+	"strings"
+)
+
+type contextKey string
+
+const userContextKey contextKey = "user"
+
+// Auth requires a valid bearer access token on every /api/ route except the
+// public ones, and puts the signed-in user in the request context. Routes
+// outside /api/, such as the Swagger UI and static files, stay open.
+func Auth(authService service.AuthService, publicPaths ...string) func(http.Handler) http.Handler {
+	public := make(map[string]bool, len(publicPaths))
+	for _, path := range publicPaths {
+		public[path] = true
+	}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if !strings.HasPrefix(r.URL.Path, "/api/") || public[r.URL.Path] {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			scheme, token, found := strings.Cut(r.Header.Get("Authorization"), " ")
+			if !found || !strings.EqualFold(scheme, "Bearer") || token == "" {
+				utils.ErrorResponse(w, http.StatusUnauthorized, "Unauthorized", utils.ErrUnauthorized.Error())
+				return
+			}
+
+			user, err := authService.Authenticate(strings.TrimSpace(token))
+			if errors.Is(err, utils.ErrUnauthorized) {
+				utils.ErrorResponse(w, http.StatusUnauthorized, "Unauthorized", err.Error())
+				return
+			}
+			if err != nil {
+				utils.ErrorResponse(w, http.StatusInternalServerError, "Failed to authenticate", err.Error())
+				return
+			}
+
+			next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), userContextKey, user)))
+		})
+	}
+}
+
+// RequirePermission lets only users whose role grants perm through. It must
+// run behind Auth.
+func RequirePermission(perm models.Permission, next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		user, ok := UserFromContext(r.Context())
+		if !ok {
+			utils.ErrorResponse(w, http.StatusUnauthorized, "Unauthorized", utils.ErrUnauthorized.Error())
+			return
+		}
+		if !user.Can(perm) {
+			utils.ErrorResponse(w, http.StatusForbidden, "Forbidden", utils.ErrForbidden.Error())
+			return
+		}
+		next(w, r)
+	}
+}
+
+// RequireCostView lets only users who may see what goods cost through. It
+// must run behind Auth.
+func RequireCostView(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		user, ok := UserFromContext(r.Context())
+		if !ok {
+			utils.ErrorResponse(w, http.StatusUnauthorized, "Unauthorized", utils.ErrUnauthorized.Error())
+			return
+		}
+		if !user.CanViewCost() {
+			utils.ErrorResponse(w, http.StatusForbidden, "Forbidden", utils.ErrForbidden.Error())
+			return
+		}
+		next(w, r)
+	}
+}
+
+// UserFromContext returns the user Auth put in the request context.
+func UserFromContext(ctx context.Context) (models.User, bool) {
+	user, ok := ctx.Value(userContextKey).(models.User)
+	return user, ok
+}
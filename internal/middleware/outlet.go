@@ -0,0 +1,52 @@
+package middleware
+
+import (
+	"context"
+	"errors"
+	"kasir-api-go/internal/models"
+	"kasir-api-go/internal/service"
+	"kasir-api-go/internal/utils"
+	"net/http"
+)
+
+const outletContextKey contextKey = "outlet"
+
+// OutletHeader names the outlet a terminal works at.
+const OutletHeader = "X-Outlet-Code"
+
+// Outlet puts the outlet a signed-in request is made from in the request
+// context: the one in the X-Outlet-Code header, the user's own outlet or the
+// default one. It must run behind Auth.
+func Outlet(outletService service.OutletService) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			user, ok := UserFromContext(r.Context())
+			if !ok {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			outlet, err := outletService.Resolve(user, r.Header.Get(OutletHeader))
+			if errors.Is(err, utils.ErrOutletForbidden) {
+				utils.ErrorResponse(w, http.StatusForbidden, err.Error(), "Forbidden")
+				return
+			}
+			if errors.Is(err, utils.ErrOutletNotFound) {
+				utils.ErrorResponse(w, http.StatusBadRequest, err.Error(), "Bad Request")
+				return
+			}
+			if err != nil {
+				utils.ErrorResponse(w, http.StatusInternalServerError, "Failed to resolve outlet", err.Error())
+				return
+			}
+
+			next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), outletContextKey, outlet)))
+		})
+	}
+}
+
+// OutletFromContext returns the outlet Outlet put in the request context.
+func OutletFromContext(ctx context.Context) (models.Outlet, bool) {
+	outlet, ok := ctx.Value(outletContextKey).(models.Outlet)
+	return outlet, ok
+}
@@ -0,0 +1,47 @@
+package models
+
+import "time"
+
+type ApprovalAction string
+
+const (
+	ApprovalActionVoid          ApprovalAction = "void"
+	ApprovalActionRefund        ApprovalAction = "refund"
+	ApprovalActionPriceOverride ApprovalAction = "price_override"
+	ApprovalActionDiscount      ApprovalAction = "discount"
+	ApprovalActionPriceChange   ApprovalAction = "price_change"
+)
+
+// Approval is the audit record of a sensitive action and who signed it off.
+// ApprovedBy is the requester themselves when their own role allows the
+// action; otherwise it is the supervisor whose PIN or approval token came
+// with the request.
+type Approval struct {
+	ID            int            `json:"id"`
+	Action        ApprovalAction `json:"action"`
+	RequestedByID int            `json:"requested_by_id"`
+	RequestedBy   string         `json:"requested_by"`
+	ApprovedByID  int            `json:"approved_by_id"`
+	ApprovedBy    string         `json:"approved_by"`
+	TransactionID *int           `json:"transaction_id,omitempty"`
+	ProductID     *int           `json:"product_id,omitempty"`
+	Details       string         `json:"details"`
+	CreatedAt     time.Time      `json:"created_at"`
+	// TokenID is the approval token the approver signed off with, spent
+	// when the approval is recorded
+	TokenID string `json:"-"`
+}
+
+// ApprovalRequest carries a supervisor's sign-off with a request made by a
+// user who may not perform the action alone: either the supervisor's
+// username and PIN entered at the till, or an approval token they issued.
+type ApprovalRequest struct {
+	Username string `json:"username,omitempty"`
+	PIN      string `json:"pin,omitempty"`
+	Token    string `json:"token,omitempty"`
+}
+
+type ApprovalToken struct {
+	Token     string `json:"token"`
+	ExpiresIn int    `json:"expires_in"`
+}
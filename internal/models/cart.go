@@ -0,0 +1,62 @@
+package models
+
+import "time"
+
+type CartStatus string
+
+const (
+	CartStatusOpen       CartStatus = "open"
+	CartStatusHeld       CartStatus = "held"
+	CartStatusCheckedOut CartStatus = "checked_out"
+	CartStatusExpired    CartStatus = "expired"
+)
+
+// Cart is a basket that can be parked and resumed before checkout, such as an
+// open bill for a restaurant table. Label identifies it at the till, and it is
+// checked out at the outlet it was opened at.
+type Cart struct {
+	ID            int        `json:"id"`
+	OutletID      int        `json:"outlet_id"`
+	OutletCode    string     `json:"outlet_code"`
+	Label         string     `json:"label"`
+	Status        CartStatus `json:"status"`
+	TransactionID *int       `json:"transaction_id,omitempty"`
+	Items         []CartItem `json:"items"`
+	CreatedAt     time.Time  `json:"created_at"`
+	UpdatedAt     time.Time  `json:"updated_at"`
+	ExpiresAt     time.Time  `json:"expires_at"`
+}
+
+type CartItem struct {
+	ID          int    `json:"id"`
+	CartID      int    `json:"cart_id"`
+	ProductID   int    `json:"product_id"`
+	ProductName string `json:"product_name"`
+	UnitPrice   int    `json:"unit_price"`
+	Quantity    int    `json:"quantity"`
+}
+
+type CreateCartRequest struct {
+	Label string `json:"label"`
+}
+
+type CartItemRequest struct {
+	ProductID int `json:"product_id"`
+	Quantity  int `json:"quantity"`
+}
+
+// CartCheckoutRequest carries the payment and the basket discount for a cart;
+// the items come from the cart itself.
+type CartCheckoutRequest struct {
+	PaymentMethod  PaymentMethod     `json:"payment_method,omitempty"`
+	PaidAmount     int               `json:"paid_amount,omitempty"`
+	Reference      string            `json:"reference,omitempty"`
+	Payments       []CheckoutPayment `json:"payments,omitempty"`
+	ManualDiscount int               `json:"manual_discount,omitempty"`
+	Approval       *ApprovalRequest  `json:"approval,omitempty"`
+
+	// Set by the handler, never read from the body
+	IdempotencyKey string `json:"-"`
+	Outlet         Outlet `json:"-"`
+	Actor          User   `json:"-"`
+}
@@ -0,0 +1,39 @@
+package models
+
+import "time"
+
+// DailyClosing freezes the figures of a business day at an outlet. Once a day
+// is closed no sale, void or refund can be recorded on it; its sales can
+// still be refunded on a later day.
+type DailyClosing struct {
+	ID           int       `json:"id"`
+	OutletCode   string    `json:"outlet_code"`
+	BusinessDate string    `json:"business_date"`
+	ClosedBy     string    `json:"closed_by"`
+	ClosedAt     time.Time `json:"closed_at"`
+	Report       ZReport   `json:"report"`
+}
+
+// ZReport is the end-of-day report stored with a closing. Voids are counted
+// separately because Sales leaves voided transactions out. Refunds made on
+// the day count against it, whichever day the sale was made, and the payment
+// totals are net of the money paid back.
+type ZReport struct {
+	Sales         SalesReport    `json:"sales"`
+	PaymentTotals []PaymentTotal `json:"payment_totals"`
+	VoidCount     int            `json:"void_count"`
+	VoidAmount    int            `json:"void_amount"`
+	RefundCount   int            `json:"refund_count"`
+	TopProducts   []ProductSales `json:"top_products"`
+}
+
+type ProductSales struct {
+	Name    string `json:"name"`
+	QtySold int    `json:"qty_sold"`
+	Revenue int    `json:"revenue"`
+}
+
+// CloseDayRequest closes Date (YYYY-MM-DD), or today when it is empty.
+type CloseDayRequest struct {
+	Date string `json:"date"`
+}
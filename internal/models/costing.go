@@ -0,0 +1,35 @@
+package models
+
+// CostingMethod is how the cost of goods sold is worked out. Average values
+// them at the moving average purchase cost of the product; FIFO at the cost
+// of the oldest receipts still in stock.
+type CostingMethod string
+
+const (
+	CostingAverage CostingMethod = "average"
+	CostingFIFO    CostingMethod = "fifo"
+)
+
+func (m CostingMethod) IsValid() bool {
+	return m == CostingAverage || m == CostingFIFO
+}
+
+// InventoryValuation is the value of the stock on hand under a costing
+// method, at one outlet or at all of them.
+type InventoryValuation struct {
+	Method     CostingMethod      `json:"method"`
+	Outlet     string             `json:"outlet,omitempty"`
+	TotalValue int                `json:"total_value"`
+	Products   []ProductValuation `json:"products"`
+}
+
+// ProductValuation is the value of the stock of a product. The stock and
+// value of a product with variants include those of its Variants.
+type ProductValuation struct {
+	ProductID   int                `json:"product_id"`
+	ProductName string             `json:"product_name"`
+	Stock       int                `json:"stock"`
+	AverageCost int                `json:"average_cost"`
+	Value       int                `json:"value"`
+	Variants    []ProductValuation `json:"variants,omitempty"`
+}
@@ -0,0 +1,24 @@
+package models
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// InvoiceFormat builds invoice numbers from a pattern such as
+// "INV/{date}/{seq}". {date} is the business day as YYYYMMDD, {outlet} the
+// outlet code and {seq} the daily sequence padded to Digits.
+type InvoiceFormat struct {
+	Pattern string
+	Digits  int
+}
+
+// Number returns the invoice number of the seq-th sale of the day at outlet.
+func (f InvoiceFormat) Number(outlet string, day time.Time, seq int) string {
+	return strings.NewReplacer(
+		"{date}", day.Format("20060102"),
+		"{outlet}", outlet,
+		"{seq}", fmt.Sprintf("%0*d", f.Digits, seq),
+	).Replace(f.Pattern)
+}
@@ -0,0 +1,32 @@
+package models
+
+import (
+	"testing"
+	"time"
+)
+
+func TestInvoiceFormatNumber(t *testing.T) {
+	day := time.Date(2026, 10, 17, 15, 4, 5, 0, time.UTC)
+
+	tests := []struct {
+		name   string
+		format InvoiceFormat
+		outlet string
+		seq    int
+		want   string
+	}{
+		{"date and sequence", InvoiceFormat{Pattern: "INV/{date}/{seq}", Digits: 4}, "MAIN", 7, "INV/20261017/0007"},
+		{"outlet", InvoiceFormat{Pattern: "{outlet}-{date}-{seq}", Digits: 3}, "JKT01", 12, "JKT01-20261017-012"},
+		{"sequence longer than the padding", InvoiceFormat{Pattern: "INV/{seq}", Digits: 2}, "MAIN", 123, "INV/123"},
+		{"no padding", InvoiceFormat{Pattern: "INV/{seq}"}, "MAIN", 5, "INV/5"},
+		{"placeholder used twice", InvoiceFormat{Pattern: "{outlet}/{seq}/{outlet}", Digits: 2}, "BDG", 1, "BDG/01/BDG"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.format.Number(tt.outlet, day, tt.seq); got != tt.want {
+				t.Errorf("Number(%q, %d) = %q, want %q", tt.outlet, tt.seq, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,53 @@
+package models
+
+import "time"
+
+// LowStockEvent is raised by checkout when a sale takes a product at an
+// outlet from its minimum stock or above to below it.
+type LowStockEvent struct {
+	ID            int       `json:"id"`
+	ProductID     int       `json:"product_id"`
+	ProductName   string    `json:"product_name"`
+	OutletID      int       `json:"outlet_id"`
+	OutletCode    string    `json:"outlet_code"`
+	Stock         int       `json:"stock"`
+	MinStock      int       `json:"min_stock"`
+	TransactionID *int      `json:"transaction_id,omitempty"`
+	CreatedAt     time.Time `json:"created_at"`
+}
+
+// ReorderSuggestion is a product below its minimum stock at an outlet. The
+// suggested quantity covers the outlet's average daily sales of the window
+// for as many days again on top of the minimum, and is never less than the
+// reorder quantity.
+type ReorderSuggestion struct {
+	ProductID         int     `json:"product_id"`
+	ProductName       string  `json:"product_name"`
+	OutletID          int     `json:"outlet_id"`
+	OutletCode        string  `json:"outlet_code"`
+	Stock             int     `json:"stock"`
+	MinStock          int     `json:"min_stock"`
+	ReorderQuantity   int     `json:"reorder_quantity"`
+	SoldQuantity      int     `json:"sold_quantity"`
+	AverageDailySales float64 `json:"average_daily_sales"`
+	SuggestedQuantity int     `json:"suggested_quantity"`
+}
+
+// Suggest works out the average daily sales and the suggested order quantity
+// from the quantity sold over windowDays.
+func (s *ReorderSuggestion) Suggest(windowDays int) {
+	s.AverageDailySales = float64(s.SoldQuantity*100/windowDays) / 100
+
+	s.SuggestedQuantity = s.MinStock - s.Stock + s.SoldQuantity
+	if s.SuggestedQuantity < s.ReorderQuantity {
+		s.SuggestedQuantity = s.ReorderQuantity
+	}
+}
+
+// LowStockReport lists the products below their minimum stock at one outlet
+// or at all of them, based on the sales of the last WindowDays days.
+type LowStockReport struct {
+	WindowDays int                 `json:"window_days"`
+	Outlet     string              `json:"outlet,omitempty"`
+	Products   []ReorderSuggestion `json:"products"`
+}
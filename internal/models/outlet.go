@@ -0,0 +1,23 @@
+package models
+
+// Outlet is a store or warehouse holding its own stock. Products and
+// categories are shared by every outlet. The code is what transactions,
+// shifts and closings are recorded under, so it cannot change once created.
+type Outlet struct {
+	ID      int    `json:"id"`
+	Code    string `json:"code"`
+	Name    string `json:"name"`
+	Address string `json:"address"`
+}
+
+// OutletStock is the stock of a product at one outlet. InTransit is what has
+// been sent to the outlet from other outlets and not received yet, and is
+// not part of Stock.
+type OutletStock struct {
+	OutletID    int    `json:"outlet_id"`
+	OutletCode  string `json:"outlet_code"`
+	ProductID   int    `json:"product_id"`
+	ProductName string `json:"product_name,omitempty"`
+	Stock       int    `json:"stock"`
+	InTransit   int    `json:"in_transit"`
+}
@@ -0,0 +1,36 @@
+package models
+
+type PaymentMethod string
+
+const (
+	PaymentMethodCash      PaymentMethod = "cash"
+	PaymentMethodDebitCard PaymentMethod = "debit_card"
+	PaymentMethodQRIS      PaymentMethod = "qris"
+	PaymentMethodTransfer  PaymentMethod = "transfer"
+)
+
+// IsValid reports whether the method is one of the supported payment methods.
+func (m PaymentMethod) IsValid() bool {
+	switch m {
+	case PaymentMethodCash, PaymentMethodDebitCard, PaymentMethodQRIS, PaymentMethodTransfer:
+		return true
+	}
+	return false
+}
+
+type Payment struct {
+	ID             int           `json:"id"`
+	TransactionID  int           `json:"transaction_id"`
+	Method         PaymentMethod `json:"method"`
+	Amount         int           `json:"amount"`
+	TenderedAmount int           `json:"tendered_amount"`
+	Reference      string        `json:"reference,omitempty"`
+}
+
+// CheckoutPayment is a single tender line submitted at checkout. Amount is what
+// the customer handed over for that method; only cash lines may exceed the bill.
+type CheckoutPayment struct {
+	Method    PaymentMethod `json:"method"`
+	Amount    int           `json:"amount"`
+	Reference string        `json:"reference,omitempty"`
+}
@@ -1,11 +1,68 @@
 package models
 
+// Product is an item for sale. Cost is the moving average purchase cost; it
+// values the opening stock of a new product and is kept up to date by goods
+// receipts afterwards. Stock is the total of every outlet; Stocks breaks it
+// down by outlet on the product detail and is never read from the body. A
+// sale taking the stock of an outlet below MinStock raises a low-stock
+// event, and ReorderQuantity is the least to order then.
+//
+// A product with a ParentID is a variant of that product, like a size or a
+// colour, named after its parent and VariantName. It has its own SKU,
+// barcode, price and stock and shares the category and tax rate of its
+// parent, which is not sold itself once it has variants. Variants lists them
+// on the detail of the parent.
 type Product struct {
-	ID       int       `json:"id"`
-	Name     string    `json:"name"`
-	Price    int       `json:"price"`
-	Stock    int       `json:"stock"`
-	Category *Category `json:"category"`
+	ID              int       `json:"id"`
+	ParentID        *int      `json:"parent_id,omitempty"`
+	Name            string    `json:"name"`
+	VariantName     string    `json:"variant_name,omitempty"`
+	SKU             string    `json:"sku,omitempty"`
+	Barcode         string    `json:"barcode,omitempty"`
+	Price           int       `json:"price"`
+	Cost            int       `json:"cost,omitempty"`
+	Stock           int       `json:"stock"`
+	MinStock        int       `json:"min_stock"`
+	ReorderQuantity int       `json:"reorder_quantity"`
+	Category        *Category `json:"category"`
+	TaxRateID       *int      `json:"tax_rate_id,omitempty"`
+
+	Stocks   []OutletStock `json:"stocks,omitempty"`
+	Variants []Product     `json:"variants,omitempty"`
+}
+
+// WithoutCost returns the product and its variants with their cost left out,
+// for users who may not see it.
+func (p Product) WithoutCost() Product {
+	p.Cost = 0
+	if p.Variants != nil {
+		variants := make([]Product, len(p.Variants))
+		for i, v := range p.Variants {
+			variants[i] = v.WithoutCost()
+		}
+		p.Variants = variants
+	}
+	return p
+}
+
+// VariantProductName is the name of the variant variantName of the product
+// named parentName.
+func VariantProductName(parentName, variantName string) string {
+	return parentName + " - " + variantName
+}
+
+// UpdateProductRequest is a product update. Changing the price needs a role
+// allowing price overrides or an Approval.
+type UpdateProductRequest struct {
+	Product
+	Approval *ApprovalRequest `json:"approval,omitempty"`
+	// Stock is the new stock at the caller's outlet; left out, the stock
+	// stays as it is
+	Stock *int `json:"stock,omitempty"`
+
+	// Set by the handler, never read from the body
+	OutletID int  `json:"-"`
+	Actor    User `json:"-"`
 }
 
 type BestSellingProduct struct {
@@ -14,7 +71,13 @@ type BestSellingProduct struct {
 }
 
 type SalesReport struct {
+	GrossRevenue       int                `json:"gross_revenue"`
+	TotalDiscount      int                `json:"total_discount"`
+	TotalRefund        int                `json:"total_refund"`
+	TotalTax           int                `json:"total_tax"`
+	TotalServiceCharge int                `json:"total_service_charge"`
 	TotalRevenue       int                `json:"total_revenue"`
 	TotalTransactions  int                `json:"total_transaksi"`
 	BestSellingProduct BestSellingProduct `json:"produk_terlaris"`
+	TaxSummary         []TaxSummary       `json:"tax_summary"`
 }
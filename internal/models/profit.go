@@ -0,0 +1,53 @@
+package models
+
+import "math"
+
+// ProfitGrouping is how a profit report is broken down.
+type ProfitGrouping string
+
+const (
+	ProfitByProduct  ProfitGrouping = "product"
+	ProfitByVariant  ProfitGrouping = "variant"
+	ProfitByCategory ProfitGrouping = "category"
+	ProfitByDay      ProfitGrouping = "day"
+)
+
+func (g ProfitGrouping) IsValid() bool {
+	return g == ProfitByProduct || g == ProfitByVariant || g == ProfitByCategory || g == ProfitByDay
+}
+
+// ProfitReport is the gross profit of the sales in a date range. Revenue is
+// the line subtotals after discounts, without tax and service charge, and
+// both revenue and COGS are net of the refunds made in the range.
+type ProfitReport struct {
+	StartDate   string         `json:"start_date"`
+	EndDate     string         `json:"end_date"`
+	GroupBy     ProfitGrouping `json:"group_by,omitempty"`
+	Outlet      string         `json:"outlet,omitempty"`
+	Revenue     int            `json:"revenue"`
+	COGS        int            `json:"cogs"`
+	GrossProfit int            `json:"gross_profit"`
+	Margin      float64        `json:"margin"`
+	Lines       []ProfitLine   `json:"lines,omitempty"`
+}
+
+// ProfitLine is the profit of one product, variant, category or day. ID is
+// the product or category id, and Name the product or category name or the
+// day (YYYY-MM-DD). Variants are rolled up to their parent product unless
+// grouped by variant.
+type ProfitLine struct {
+	ID          *int    `json:"id,omitempty"`
+	Name        string  `json:"name"`
+	Revenue     int     `json:"revenue"`
+	COGS        int     `json:"cogs"`
+	GrossProfit int     `json:"gross_profit"`
+	Margin      float64 `json:"margin"`
+}
+
+// MarginPercent is gross profit as a percentage of revenue, to two decimals.
+func MarginPercent(grossProfit, revenue int) float64 {
+	if revenue == 0 {
+		return 0
+	}
+	return math.Round(float64(grossProfit)*10000/float64(revenue)) / 100
+}
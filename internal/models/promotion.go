@@ -0,0 +1,176 @@
+package models
+
+import "time"
+
+type PromotionType string
+
+const (
+	PromotionTypePercentage  PromotionType = "percentage"
+	PromotionTypeFixedAmount PromotionType = "fixed_amount"
+	PromotionTypeBuyXGetY    PromotionType = "buy_x_get_y"
+)
+
+type PromotionScope string
+
+const (
+	PromotionScopeItem   PromotionScope = "item"
+	PromotionScopeBasket PromotionScope = "basket"
+)
+
+// Promotion is a discount rule evaluated at checkout.
+//
+// Item promotions apply per line to the targeted product or category (or to
+// every product when neither is set): a percentage off, a fixed amount off
+// each unit, or FreeQuantity free units for every BuyQuantity bought.
+// Basket promotions apply once to the whole basket when its subtotal reaches
+// MinPurchase. StartTime and EndTime ("15:04") restrict the rule to a daily
+// window such as a happy hour.
+type Promotion struct {
+	ID           int            `json:"id"`
+	Name         string         `json:"name"`
+	Type         PromotionType  `json:"type"`
+	Scope        PromotionScope `json:"scope"`
+	Value        int            `json:"value"`
+	BuyQuantity  int            `json:"buy_quantity,omitempty"`
+	FreeQuantity int            `json:"free_quantity,omitempty"`
+	MinPurchase  int            `json:"min_purchase,omitempty"`
+	ProductID    *int           `json:"product_id,omitempty"`
+	CategoryID   *int           `json:"category_id,omitempty"`
+	StartDate    *time.Time     `json:"start_date,omitempty"`
+	EndDate      *time.Time     `json:"end_date,omitempty"`
+	StartTime    string         `json:"start_time,omitempty"`
+	EndTime      string         `json:"end_time,omitempty"`
+	Active       bool           `json:"active"`
+}
+
+// AppliedDiscount records a promotion applied to a transaction. Line
+// discounts carry the detail they were applied to; basket discounts do not.
+type AppliedDiscount struct {
+	ID                  int    `json:"id"`
+	TransactionID       int    `json:"transaction_id"`
+	TransactionDetailID *int   `json:"transaction_detail_id,omitempty"`
+	PromotionID         *int   `json:"promotion_id,omitempty"`
+	PromotionName       string `json:"promotion_name"`
+	Amount              int    `json:"amount"`
+}
+
+// IsActiveAt reports whether the promotion can be applied at t.
+func (p Promotion) IsActiveAt(t time.Time) bool {
+	if !p.Active {
+		return false
+	}
+	if p.StartDate != nil && t.Before(*p.StartDate) {
+		return false
+	}
+	if p.EndDate != nil && !t.Before(*p.EndDate) {
+		return false
+	}
+	if p.StartTime != "" && p.EndTime != "" {
+		clock := t.Format("15:04")
+		if p.StartTime <= p.EndTime {
+			return clock >= p.StartTime && clock < p.EndTime
+		}
+		// Window that wraps past midnight, e.g. 22:00-02:00
+		return clock >= p.StartTime || clock < p.EndTime
+	}
+	return true
+}
+
+// LineDiscount returns the discount an item promotion gives on a line. It
+// never exceeds the line gross amount.
+func (p Promotion) LineDiscount(productID, categoryID, unitPrice, qty int) int {
+	if p.Scope != PromotionScopeItem {
+		return 0
+	}
+	if p.ProductID != nil && *p.ProductID != productID {
+		return 0
+	}
+	if p.CategoryID != nil && *p.CategoryID != categoryID {
+		return 0
+	}
+
+	gross := unitPrice * qty
+	discount := 0
+	switch p.Type {
+	case PromotionTypePercentage:
+		discount = gross * p.Value / 100
+	case PromotionTypeFixedAmount:
+		discount = p.Value * qty
+	case PromotionTypeBuyXGetY:
+		if p.BuyQuantity > 0 && p.FreeQuantity > 0 {
+			discount = qty / (p.BuyQuantity + p.FreeQuantity) * p.FreeQuantity * unitPrice
+		}
+	}
+	return min(discount, gross)
+}
+
+// BasketDiscount returns the discount a basket promotion gives on the basket
+// subtotal. It never exceeds the subtotal.
+func (p Promotion) BasketDiscount(subtotal int) int {
+	if p.Scope != PromotionScopeBasket || subtotal < p.MinPurchase {
+		return 0
+	}
+
+	discount := 0
+	switch p.Type {
+	case PromotionTypePercentage:
+		discount = subtotal * p.Value / 100
+	case PromotionTypeFixedAmount:
+		discount = p.Value
+	}
+	return min(discount, subtotal)
+}
+
+// BestLineDiscount picks the item promotion giving the largest discount on a
+// line. Item promotions do not stack.
+func BestLineDiscount(promotions []Promotion, productID, categoryID, unitPrice, qty int) (int, *Promotion) {
+	best, bestIdx := 0, -1
+	for i, p := range promotions {
+		if d := p.LineDiscount(productID, categoryID, unitPrice, qty); d > best {
+			best, bestIdx = d, i
+		}
+	}
+	if bestIdx < 0 {
+		return 0, nil
+	}
+	return best, &promotions[bestIdx]
+}
+
+// BestBasketDiscount picks the basket promotion giving the largest discount
+// on the basket subtotal. Basket promotions do not stack.
+func BestBasketDiscount(promotions []Promotion, subtotal int) (int, *Promotion) {
+	best, bestIdx := 0, -1
+	for i, p := range promotions {
+		if d := p.BasketDiscount(subtotal); d > best {
+			best, bestIdx = d, i
+		}
+	}
+	if bestIdx < 0 {
+		return 0, nil
+	}
+	return best, &promotions[bestIdx]
+}
+
+// AllocateBasketDiscount spreads a basket discount over the lines in
+// proportion to their subtotals, so refunds of a line give back its net
+// price. The last line absorbs the rounding remainder.
+func AllocateBasketDiscount(details []TransactionDetail, discount int) {
+	subtotal := 0
+	for _, d := range details {
+		subtotal += d.Subtotal
+	}
+	if discount <= 0 || subtotal <= 0 {
+		return
+	}
+
+	remaining := discount
+	for i := range details {
+		share := discount * details[i].Subtotal / subtotal
+		if i == len(details)-1 {
+			share = remaining
+		}
+		details[i].DiscountAmount += share
+		details[i].Subtotal -= share
+		remaining -= share
+	}
+}
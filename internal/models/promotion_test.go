@@ -0,0 +1,115 @@
+package models
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestBestBasketDiscount(t *testing.T) {
+	promotions := []Promotion{
+		{Name: "10% over 50k", Type: PromotionTypePercentage, Scope: PromotionScopeBasket, Value: 10, MinPurchase: 50000},
+		{Name: "7k off", Type: PromotionTypeFixedAmount, Scope: PromotionScopeBasket, Value: 7000},
+		{Name: "half price item", Type: PromotionTypePercentage, Scope: PromotionScopeItem, Value: 50},
+	}
+
+	tests := []struct {
+		name       string
+		promotions []Promotion
+		subtotal   int
+		want       int
+		wantName   string
+	}{
+		{"percentage beats fixed", promotions, 100000, 10000, "10% over 50k"},
+		{"fixed beats percentage", promotions, 60000, 7000, "7k off"},
+		{"first wins a tie", promotions, 70000, 7000, "10% over 50k"},
+		{"minimum purchase not reached", promotions, 40000, 7000, "7k off"},
+		{"capped at the subtotal", promotions, 5000, 5000, "7k off"},
+		{"item promotions are ignored", promotions[2:], 100000, 0, ""},
+		{"no promotions", nil, 100000, 0, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, promotion := BestBasketDiscount(tt.promotions, tt.subtotal)
+			name := ""
+			if promotion != nil {
+				name = promotion.Name
+			}
+			if got != tt.want || name != tt.wantName {
+				t.Errorf("BestBasketDiscount(%d) = (%d, %q), want (%d, %q)", tt.subtotal, got, name, tt.want, tt.wantName)
+			}
+		})
+	}
+}
+
+func TestAllocateBasketDiscount(t *testing.T) {
+	tests := []struct {
+		name          string
+		subtotals     []int
+		discounts     []int
+		discount      int
+		wantSubtotals []int
+		wantDiscounts []int
+	}{
+		{
+			name:          "in proportion to the subtotals",
+			subtotals:     []int{30000, 20000, 50000},
+			discounts:     []int{0, 0, 0},
+			discount:      10000,
+			wantSubtotals: []int{27000, 18000, 45000},
+			wantDiscounts: []int{3000, 2000, 5000},
+		},
+		{
+			name:          "last line takes the remainder",
+			subtotals:     []int{1000, 1000, 1000},
+			discounts:     []int{0, 0, 0},
+			discount:      100,
+			wantSubtotals: []int{967, 967, 966},
+			wantDiscounts: []int{33, 33, 34},
+		},
+		{
+			name:          "adds to line discounts",
+			subtotals:     []int{4000, 6000},
+			discounts:     []int{500, 0},
+			discount:      1000,
+			wantSubtotals: []int{3600, 5400},
+			wantDiscounts: []int{900, 600},
+		},
+		{
+			name:          "no discount",
+			subtotals:     []int{4000, 6000},
+			discounts:     []int{0, 0},
+			discount:      0,
+			wantSubtotals: []int{4000, 6000},
+			wantDiscounts: []int{0, 0},
+		},
+		{
+			name:          "free basket",
+			subtotals:     []int{0, 0},
+			discounts:     []int{0, 0},
+			discount:      1000,
+			wantSubtotals: []int{0, 0},
+			wantDiscounts: []int{0, 0},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			details := make([]TransactionDetail, len(tt.subtotals))
+			for i := range details {
+				details[i] = TransactionDetail{Subtotal: tt.subtotals[i], DiscountAmount: tt.discounts[i]}
+			}
+
+			AllocateBasketDiscount(details, tt.discount)
+
+			subtotals := make([]int, len(details))
+			discounts := make([]int, len(details))
+			for i, d := range details {
+				subtotals[i], discounts[i] = d.Subtotal, d.DiscountAmount
+			}
+			if !reflect.DeepEqual(subtotals, tt.wantSubtotals) || !reflect.DeepEqual(discounts, tt.wantDiscounts) {
+				t.Errorf("subtotals %v and discounts %v, want %v and %v", subtotals, discounts, tt.wantSubtotals, tt.wantDiscounts)
+			}
+		})
+	}
+}
@@ -0,0 +1,119 @@
+package models
+
+import "time"
+
+type PurchaseOrderStatus string
+
+const (
+	PurchaseOrderStatusOpen              PurchaseOrderStatus = "open"
+	PurchaseOrderStatusPartiallyReceived PurchaseOrderStatus = "partially_received"
+	PurchaseOrderStatusReceived          PurchaseOrderStatus = "received"
+	PurchaseOrderStatusCancelled         PurchaseOrderStatus = "cancelled"
+)
+
+// IsOutstanding reports whether goods can still be received on the order.
+func (s PurchaseOrderStatus) IsOutstanding() bool {
+	return s == PurchaseOrderStatusOpen || s == PurchaseOrderStatusPartiallyReceived
+}
+
+// PurchaseOrder is an order placed with a supplier for delivery to an
+// outlet. Goods are received against it in one or more goods receipts, each
+// adding to the outlet's stock, and can be sent back in purchase returns. TotalCost is the expected cost of the whole
+// order and OutstandingValue the expected cost of what is still to come.
+type PurchaseOrder struct {
+	ID               int                 `json:"id"`
+	SupplierID       int                 `json:"supplier_id"`
+	SupplierName     string              `json:"supplier_name"`
+	OutletID         int                 `json:"outlet_id"`
+	OutletCode       string              `json:"outlet_code"`
+	Status           PurchaseOrderStatus `json:"status"`
+	ExpectedDate     string              `json:"expected_date,omitempty"`
+	Note             string              `json:"note,omitempty"`
+	CreatedBy        string              `json:"created_by,omitempty"`
+	CreatedAt        time.Time           `json:"created_at"`
+	UpdatedAt        time.Time           `json:"updated_at"`
+	TotalCost        int                 `json:"total_cost"`
+	OutstandingValue int                 `json:"outstanding_value"`
+	Items            []PurchaseOrderItem `json:"items,omitempty"`
+	Receipts         []GoodsReceipt      `json:"receipts,omitempty"`
+	Returns          []PurchaseReturn    `json:"returns,omitempty"`
+}
+
+// PurchaseOrderItem is the line of a product on a purchase order.
+// OutstandingQuantity is what is still to be received, and is zero once the
+// order is received or cancelled.
+type PurchaseOrderItem struct {
+	ID                  int    `json:"id"`
+	ProductID           *int   `json:"product_id"`
+	ProductName         string `json:"product_name"`
+	Quantity            int    `json:"quantity"`
+	UnitCost            int    `json:"unit_cost"`
+	ReceivedQuantity    int    `json:"received_quantity"`
+	ReturnedQuantity    int    `json:"returned_quantity"`
+	OutstandingQuantity int    `json:"outstanding_quantity"`
+}
+
+// GoodsReceipt is a delivery received against a purchase order.
+type GoodsReceipt struct {
+	ID              int            `json:"id"`
+	PurchaseOrderID int            `json:"purchase_order_id"`
+	Note            string         `json:"note,omitempty"`
+	ReceivedBy      string         `json:"received_by,omitempty"`
+	CreatedAt       time.Time      `json:"created_at"`
+	Items           []PurchaseLine `json:"items"`
+}
+
+// PurchaseReturn is goods of a purchase order sent back to the supplier.
+type PurchaseReturn struct {
+	ID              int            `json:"id"`
+	PurchaseOrderID int            `json:"purchase_order_id"`
+	Reason          string         `json:"reason"`
+	ReturnedBy      string         `json:"returned_by,omitempty"`
+	CreatedAt       time.Time      `json:"created_at"`
+	Items           []PurchaseLine `json:"items"`
+}
+
+// PurchaseLine is a product received or returned at a unit cost. Received
+// lines carry the lot they came in.
+type PurchaseLine struct {
+	ProductID   *int   `json:"product_id"`
+	ProductName string `json:"product_name"`
+	Quantity    int    `json:"quantity"`
+	UnitCost    int    `json:"unit_cost"`
+	BatchNumber string `json:"batch_number,omitempty"`
+	ExpiryDate  string `json:"expiry_date,omitempty"`
+}
+
+// CreatePurchaseOrderRequest places an order. OutletID is the outlet the
+// goods are delivered to and defaults to the caller's outlet.
+type CreatePurchaseOrderRequest struct {
+	SupplierID   int                   `json:"supplier_id"`
+	OutletID     *int                  `json:"outlet_id,omitempty"`
+	ExpectedDate string                `json:"expected_date"`
+	Note         string                `json:"note"`
+	Items        []PurchaseLineRequest `json:"items"`
+}
+
+// PurchaseLineRequest is a line of a purchase order, a goods receipt or a
+// purchase return. UnitCost is required on a purchase order; on receipts and
+// returns it defaults to the cost on the order. BatchNumber and ExpiryDate
+// (YYYY-MM-DD) are the lot of the goods and are only read on receipts.
+type PurchaseLineRequest struct {
+	ProductID   int    `json:"product_id"`
+	Quantity    int    `json:"quantity"`
+	UnitCost    *int   `json:"unit_cost,omitempty"`
+	BatchNumber string `json:"batch_number,omitempty"`
+	ExpiryDate  string `json:"expiry_date,omitempty"`
+}
+
+// ReceiveGoodsRequest receives a delivery. Without items, everything still
+// outstanding on the order is received.
+type ReceiveGoodsRequest struct {
+	Note  string                `json:"note"`
+	Items []PurchaseLineRequest `json:"items"`
+}
+
+type PurchaseReturnRequest struct {
+	Reason string                `json:"reason"`
+	Items  []PurchaseLineRequest `json:"items"`
+}
@@ -0,0 +1,77 @@
+package models
+
+import "time"
+
+type RefundType string
+
+const (
+	RefundTypeVoid   RefundType = "void"
+	RefundTypeRefund RefundType = "refund"
+)
+
+type Refund struct {
+	ID            int          `json:"id"`
+	TransactionID int          `json:"transaction_id"`
+	ShiftID       *int         `json:"shift_id,omitempty"`
+	Type          RefundType   `json:"type"`
+	Amount        int          `json:"amount"`
+	Reason        string       `json:"reason"`
+	PerformedBy   string       `json:"performed_by"`
+	CreatedAt     time.Time    `json:"created_at"`
+	Items         []RefundItem `json:"items,omitempty"`
+}
+
+type RefundItem struct {
+	ID                  int `json:"id"`
+	RefundID            int `json:"refund_id"`
+	TransactionDetailID int `json:"transaction_detail_id"`
+	ProductID           int `json:"product_id"`
+	Quantity            int `json:"quantity"`
+	Amount              int `json:"amount"`
+	CostAmount          int `json:"cost_amount,omitempty"`
+}
+
+// WithoutCost returns the refund with the cost of the returned goods left
+// out, for users who may not see it.
+func (r Refund) WithoutCost() Refund {
+	if r.Items != nil {
+		items := make([]RefundItem, len(r.Items))
+		for i, item := range r.Items {
+			item.CostAmount = 0
+			items[i] = item
+		}
+		r.Items = items
+	}
+	return r
+}
+
+// VoidRequest and RefundRequest need a role allowing voids or an Approval.
+// They are performed by the signed-in user, and only reverse sales made at
+// Outlet unless it is left empty.
+type VoidRequest struct {
+	Reason   string           `json:"reason"`
+	Approval *ApprovalRequest `json:"approval,omitempty"`
+
+	// Set by the handler and service, never read from the body
+	PerformedBy string     `json:"-"`
+	Actor       User       `json:"-"`
+	Outlet      Outlet     `json:"-"`
+	Approvals   []Approval `json:"-"`
+}
+
+type RefundRequestItem struct {
+	ProductID int `json:"product_id"`
+	Quantity  int `json:"quantity"`
+}
+
+type RefundRequest struct {
+	Reason   string              `json:"reason"`
+	Items    []RefundRequestItem `json:"items"`
+	Approval *ApprovalRequest    `json:"approval,omitempty"`
+
+	// Set by the handler and service, never read from the body
+	PerformedBy string     `json:"-"`
+	Actor       User       `json:"-"`
+	Outlet      Outlet     `json:"-"`
+	Approvals   []Approval `json:"-"`
+}
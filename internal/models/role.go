@@ -0,0 +1,68 @@
+package models
+
+// Permission is an action a role can be granted. Routes are guarded by the
+// permission they need.
+type Permission string
+
+const (
+	PermissionCatalogView      Permission = "catalog.view"
+	PermissionCatalogManage    Permission = "catalog.manage"
+	PermissionSalesCheckout    Permission = "sales.checkout"
+	PermissionSalesVoid        Permission = "sales.void"
+	PermissionPricesOverride   Permission = "prices.override"
+	PermissionDiscountsLarge   Permission = "discounts.large"
+	PermissionShiftsOperate    Permission = "shifts.operate"
+	PermissionClosingsManage   Permission = "closings.manage"
+	PermissionInventoryCount   Permission = "inventory.count"
+	PermissionInventoryManage  Permission = "inventory.manage"
+	PermissionPurchasingManage Permission = "purchasing.manage"
+	PermissionOutletsManage    Permission = "outlets.manage"
+	PermissionReportsView      Permission = "reports.view"
+	PermissionUsersManage      Permission = "users.manage"
+	PermissionRolesManage      Permission = "roles.manage"
+)
+
+// Permissions lists every permission that can be granted to a role.
+var Permissions = []Permission{
+	PermissionCatalogView,
+	PermissionCatalogManage,
+	PermissionSalesCheckout,
+	PermissionSalesVoid,
+	PermissionPricesOverride,
+	PermissionDiscountsLarge,
+	PermissionShiftsOperate,
+	PermissionClosingsManage,
+	PermissionInventoryCount,
+	PermissionInventoryManage,
+	PermissionPurchasingManage,
+	PermissionOutletsManage,
+	PermissionReportsView,
+	PermissionUsersManage,
+	PermissionRolesManage,
+}
+
+func (p Permission) IsValid() bool {
+	for _, known := range Permissions {
+		if p == known {
+			return true
+		}
+	}
+	return false
+}
+
+// Role is a named set of permissions assigned to users. The owner role always
+// holds every permission and cannot be changed or deleted.
+type Role struct {
+	Name        UserRole     `json:"name"`
+	Description string       `json:"description"`
+	Permissions []Permission `json:"permissions"`
+}
+
+// GrantedPermissions returns the permissions a role holds given the ones
+// stored for it.
+func GrantedPermissions(role UserRole, stored []Permission) []Permission {
+	if role == UserRoleOwner {
+		return Permissions
+	}
+	return stored
+}
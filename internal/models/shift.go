@@ -0,0 +1,98 @@
+package models
+
+import "time"
+
+type ShiftStatus string
+
+const (
+	ShiftStatusOpen   ShiftStatus = "open"
+	ShiftStatusClosed ShiftStatus = "closed"
+)
+
+type CashMovementType string
+
+const (
+	CashMovementIn  CashMovementType = "cash_in"
+	CashMovementOut CashMovementType = "cash_out"
+)
+
+// Shift is a cashier's session on the till of an outlet. Checkouts, voids and
+// refunds made while it is open are linked to it. ExpectedCash, CountedCash
+// and CashDifference are filled in when the shift is closed.
+type Shift struct {
+	ID             int            `json:"id"`
+	OutletCode     string         `json:"outlet_code"`
+	CashierID      *int           `json:"cashier_id,omitempty"`
+	CashierName    string         `json:"cashier_name"`
+	Status         ShiftStatus    `json:"status"`
+	OpeningFloat   int            `json:"opening_float"`
+	ExpectedCash   *int           `json:"expected_cash,omitempty"`
+	CountedCash    *int           `json:"counted_cash,omitempty"`
+	CashDifference *int           `json:"cash_difference,omitempty"`
+	Note           string         `json:"note,omitempty"`
+	OpenedAt       time.Time      `json:"opened_at"`
+	ClosedAt       *time.Time     `json:"closed_at,omitempty"`
+	CashMovements  []CashMovement `json:"cash_movements,omitempty"`
+	Summary        *ShiftSummary  `json:"summary,omitempty"`
+}
+
+// CashMovement is cash put into or taken out of the drawer outside of a sale,
+// such as extra change or a petty cash payment. CreatedBy is the name of the
+// user who recorded it.
+type CashMovement struct {
+	ID        int              `json:"id"`
+	ShiftID   int              `json:"shift_id"`
+	Type      CashMovementType `json:"type"`
+	Amount    int              `json:"amount"`
+	Reason    string           `json:"reason"`
+	CreatedBy string           `json:"created_by,omitempty"`
+	CreatedAt time.Time        `json:"created_at"`
+}
+
+// ShiftSummary reconciles the drawer of a shift. Sales is the sales report
+// restricted to the shift's transactions.
+type ShiftSummary struct {
+	Sales         SalesReport    `json:"sales"`
+	PaymentTotals []PaymentTotal `json:"payment_totals"`
+	OpeningFloat  int            `json:"opening_float"`
+	CashSales     int            `json:"cash_sales"`
+	CashRefunds   int            `json:"cash_refunds"`
+	CashIn        int            `json:"cash_in"`
+	CashOut       int            `json:"cash_out"`
+	ExpectedCash  int            `json:"expected_cash"`
+	CountedCash   *int           `json:"counted_cash,omitempty"`
+	Difference    *int           `json:"difference,omitempty"`
+}
+
+// PaymentTotal is the amount taken with one payment method, after change.
+type PaymentTotal struct {
+	Method PaymentMethod `json:"method"`
+	Amount int           `json:"amount"`
+}
+
+// Reconcile works out the cash that should be in the drawer and, once the
+// drawer has been counted, how far off the count is.
+func (s *ShiftSummary) Reconcile(countedCash *int) {
+	s.ExpectedCash = s.OpeningFloat + s.CashSales - s.CashRefunds + s.CashIn - s.CashOut
+	s.CountedCash = countedCash
+	s.Difference = nil
+	if countedCash != nil {
+		difference := *countedCash - s.ExpectedCash
+		s.Difference = &difference
+	}
+}
+
+type OpenShiftRequest struct {
+	OpeningFloat int `json:"opening_float"`
+}
+
+type CashMovementRequest struct {
+	Type   CashMovementType `json:"type"`
+	Amount int              `json:"amount"`
+	Reason string           `json:"reason"`
+}
+
+type CloseShiftRequest struct {
+	CountedCash int    `json:"counted_cash"`
+	Note        string `json:"note"`
+}
@@ -0,0 +1,38 @@
+package models
+
+import "time"
+
+// StockBatch is a lot of a product in stock at an outlet. Goods receipts bring batches in
+// with their number and expiry date, and refunds return goods to the batches
+// they were sold from; other stock coming in, like adjustments, becomes a
+// batch without either. Stock going out is taken first-expired-first-out,
+// ending with the batches without an expiry date.
+type StockBatch struct {
+	ID                int       `json:"id"`
+	ProductID         int       `json:"product_id"`
+	ProductName       string    `json:"product_name"`
+	OutletID          int       `json:"outlet_id"`
+	OutletCode        string    `json:"outlet_code"`
+	BatchNumber       string    `json:"batch_number,omitempty"`
+	ExpiryDate        string    `json:"expiry_date,omitempty"`
+	DaysToExpiry      *int      `json:"days_to_expiry,omitempty"`
+	Quantity          int       `json:"quantity"`
+	RemainingQuantity int       `json:"remaining_quantity"`
+	ReceivedAt        time.Time `json:"received_at"`
+}
+
+// StockLot is a quantity of a product from one batch. Goods that were not in
+// any batch make a lot without a batch number or expiry date.
+type StockLot struct {
+	BatchNumber string `json:"batch_number,omitempty"`
+	ExpiryDate  string `json:"expiry_date,omitempty"`
+	Quantity    int    `json:"quantity"`
+}
+
+// ExpiringBatches lists the batches in stock that expire within Days days,
+// including those already expired, at one outlet or at all of them.
+type ExpiringBatches struct {
+	Days    int          `json:"days"`
+	Outlet  string       `json:"outlet,omitempty"`
+	Batches []StockBatch `json:"batches"`
+}
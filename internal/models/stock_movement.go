@@ -0,0 +1,60 @@
+package models
+
+import "time"
+
+type StockMovementReason string
+
+const (
+	StockMovementSale           StockMovementReason = "sale"
+	StockMovementRefund         StockMovementReason = "refund"
+	StockMovementAdjustment     StockMovementReason = "adjustment"
+	StockMovementReceipt        StockMovementReason = "receipt"
+	StockMovementTransfer       StockMovementReason = "transfer"
+	StockMovementPurchaseReturn StockMovementReason = "purchase_return"
+)
+
+// Reference documents a stock movement can point at
+const (
+	StockReferenceTransaction    = "transaction"
+	StockReferenceRefund         = "refund"
+	StockReferenceProduct        = "product"
+	StockReferenceStockTake      = "stock_take"
+	StockReferenceGoodsReceipt   = "goods_receipt"
+	StockReferencePurchaseReturn = "purchase_return"
+	StockReferenceStockTransfer  = "stock_transfer"
+)
+
+// StockMovement is one entry of the stock ledger. Every change to a
+// product's stock at an outlet writes one in the same database transaction,
+// so the deltas of a product at an outlet add up to its stock there and
+// BalanceAfter is that stock right after the change. The product name is
+// snapshotted so the ledger outlives the product.
+type StockMovement struct {
+	ID            int                 `json:"id"`
+	ProductID     int                 `json:"product_id"`
+	ProductName   string              `json:"product_name"`
+	OutletID      int                 `json:"outlet_id"`
+	OutletCode    string              `json:"outlet_code,omitempty"`
+	Delta         int                 `json:"delta"`
+	BalanceAfter  int                 `json:"balance_after"`
+	Reason        StockMovementReason `json:"reason"`
+	ReferenceType string              `json:"reference_type,omitempty"`
+	ReferenceID   *int                `json:"reference_id,omitempty"`
+	UserID        *int                `json:"user_id,omitempty"`
+	Username      string              `json:"username,omitempty"`
+	Note          string              `json:"note,omitempty"`
+	CreatedAt     time.Time           `json:"created_at"`
+
+	// Costing, not part of the ledger. UnitCost values goods coming in, or
+	// nil for the product's average cost. For goods going out, moveStock sets
+	// CostAmount to what they cost under Costing.
+	UnitCost   *int          `json:"-"`
+	Costing    CostingMethod `json:"-"`
+	CostAmount int           `json:"-"`
+
+	// Lot of goods coming in, not part of the ledger either. For goods going
+	// out, moveStock sets TakenLots to the batches they were taken from.
+	BatchNumber string     `json:"-"`
+	ExpiryDate  string     `json:"-"`
+	TakenLots   []StockLot `json:"-"`
+}
@@ -0,0 +1,123 @@
+package models
+
+import "time"
+
+type StockTakeStatus string
+
+const (
+	StockTakeStatusOpen      StockTakeStatus = "open"
+	StockTakeStatusApproved  StockTakeStatus = "approved"
+	StockTakeStatusCancelled StockTakeStatus = "cancelled"
+)
+
+// StockTake is a physical inventory count at an outlet. Opening it snapshots
+// the outlet's stock of every product, and counting a product snapshots its
+// stock again; approving it adjusts each counted product there by its
+// variance, so movements made after the count are kept.
+type StockTake struct {
+	ID         int               `json:"id"`
+	OutletID   int               `json:"outlet_id"`
+	OutletCode string            `json:"outlet_code"`
+	Status     StockTakeStatus   `json:"status"`
+	Note       string            `json:"note,omitempty"`
+	OpenedBy   string            `json:"opened_by"`
+	OpenedAt   time.Time         `json:"opened_at"`
+	ClosedBy   string            `json:"closed_by,omitempty"`
+	ClosedAt   *time.Time        `json:"closed_at,omitempty"`
+	Items      []StockTakeItem   `json:"items,omitempty"`
+	Summary    *StockTakeSummary `json:"summary,omitempty"`
+}
+
+// StockTakeItem is a product as snapshotted when the count was opened, with
+// the quantity counted so far. SystemQuantity is the stock when it was last
+// counted, or when the count was opened. UnitValue is the product cost at
+// the opening and values the variance.
+type StockTakeItem struct {
+	ProductID       int        `json:"product_id"`
+	ProductName     string     `json:"product_name"`
+	SystemQuantity  int        `json:"system_quantity"`
+	UnitValue       int        `json:"unit_value,omitempty"`
+	CountedQuantity *int       `json:"counted_quantity,omitempty"`
+	Variance        *int       `json:"variance,omitempty"`
+	VarianceValue   *int       `json:"variance_value,omitempty"`
+	CountedBy       string     `json:"counted_by,omitempty"`
+	CountedAt       *time.Time `json:"counted_at,omitempty"`
+}
+
+// StockTakeSummary is the variance value report of a count. Products that
+// were not counted are left out of the variances.
+type StockTakeSummary struct {
+	ProductCount     int `json:"product_count"`
+	CountedCount     int `json:"counted_count"`
+	VarianceCount    int `json:"variance_count"`
+	ShortageQuantity int `json:"shortage_quantity"`
+	ShortageValue    int `json:"shortage_value,omitempty"`
+	SurplusQuantity  int `json:"surplus_quantity"`
+	SurplusValue     int `json:"surplus_value,omitempty"`
+	NetVarianceValue int `json:"net_variance_value,omitempty"`
+}
+
+// Reconcile works out the variance of every counted item and the summary of
+// the count.
+func (t *StockTake) Reconcile() {
+	summary := StockTakeSummary{ProductCount: len(t.Items)}
+	for i := range t.Items {
+		item := &t.Items[i]
+		item.Variance, item.VarianceValue = nil, nil
+		if item.CountedQuantity == nil {
+			continue
+		}
+
+		variance := *item.CountedQuantity - item.SystemQuantity
+		value := variance * item.UnitValue
+		item.Variance, item.VarianceValue = &variance, &value
+
+		summary.CountedCount++
+		switch {
+		case variance < 0:
+			summary.VarianceCount++
+			summary.ShortageQuantity -= variance
+			summary.ShortageValue -= value
+		case variance > 0:
+			summary.VarianceCount++
+			summary.SurplusQuantity += variance
+			summary.SurplusValue += value
+		}
+		summary.NetVarianceValue += value
+	}
+	t.Summary = &summary
+}
+
+// WithoutCost returns the stock take with the values of its items and
+// variances left out, for users who may not see costs.
+func (t StockTake) WithoutCost() StockTake {
+	if t.Items != nil {
+		items := make([]StockTakeItem, len(t.Items))
+		for i, item := range t.Items {
+			item.UnitValue, item.VarianceValue = 0, nil
+			items[i] = item
+		}
+		t.Items = items
+	}
+	if t.Summary != nil {
+		summary := *t.Summary
+		summary.ShortageValue, summary.SurplusValue, summary.NetVarianceValue = 0, 0, 0
+		t.Summary = &summary
+	}
+	return t
+}
+
+type OpenStockTakeRequest struct {
+	Note string `json:"note"`
+}
+
+// StockCountRequest submits a batch of counted quantities. Counting a
+// product again replaces its earlier count.
+type StockCountRequest struct {
+	Items []StockCount `json:"items"`
+}
+
+type StockCount struct {
+	ProductID       int `json:"product_id"`
+	CountedQuantity int `json:"counted_quantity"`
+}
@@ -0,0 +1,115 @@
+package models
+
+import "time"
+
+type StockTransferStatus string
+
+const (
+	StockTransferStatusDraft     StockTransferStatus = "draft"
+	StockTransferStatusSent      StockTransferStatus = "sent"
+	StockTransferStatusReceived  StockTransferStatus = "received"
+	StockTransferStatusCancelled StockTransferStatus = "cancelled"
+)
+
+// StockTransfer moves goods from one outlet to another. A draft can still be
+// changed; sending it takes the goods out of the origin's stock, and they
+// stay in transit until the destination receives them into its stock.
+// DiscrepancyCount is the number of lines received in a different quantity
+// than was sent.
+type StockTransfer struct {
+	ID               int                 `json:"id"`
+	FromOutletID     int                 `json:"from_outlet_id"`
+	FromOutletCode   string              `json:"from_outlet_code"`
+	ToOutletID       int                 `json:"to_outlet_id"`
+	ToOutletCode     string              `json:"to_outlet_code"`
+	Status           StockTransferStatus `json:"status"`
+	Note             string              `json:"note,omitempty"`
+	CreatedBy        string              `json:"created_by,omitempty"`
+	CreatedAt        time.Time           `json:"created_at"`
+	UpdatedAt        time.Time           `json:"updated_at"`
+	SentBy           string              `json:"sent_by,omitempty"`
+	SentAt           *time.Time          `json:"sent_at,omitempty"`
+	ReceivedBy       string              `json:"received_by,omitempty"`
+	ReceivedAt       *time.Time          `json:"received_at,omitempty"`
+	ReceiptNote      string              `json:"receipt_note,omitempty"`
+	ItemCount        int                 `json:"item_count"`
+	TotalQuantity    int                 `json:"total_quantity"`
+	DiscrepancyCount int                 `json:"discrepancy_count"`
+	Items            []StockTransferItem `json:"items,omitempty"`
+}
+
+// StockTransferItem is the line of a product on a transfer. UnitCost is what
+// a unit cost when it was sent and is what it is received at. Once received,
+// Discrepancy is the quantity received less the quantity sent, valued at
+// UnitCost. Lots are the batches the goods were taken from.
+type StockTransferItem struct {
+	ProductID        *int       `json:"product_id"`
+	ProductName      string     `json:"product_name"`
+	Quantity         int        `json:"quantity"`
+	UnitCost         int        `json:"unit_cost"`
+	ReceivedQuantity *int       `json:"received_quantity,omitempty"`
+	Discrepancy      *int       `json:"discrepancy,omitempty"`
+	DiscrepancyValue *int       `json:"discrepancy_value,omitempty"`
+	Lots             []StockLot `json:"lots,omitempty"`
+}
+
+// StockTransferRequest creates a transfer or replaces a draft. FromOutletID
+// defaults to the caller's outlet on a new transfer, and to the current
+// origin on a draft.
+type StockTransferRequest struct {
+	FromOutletID *int                       `json:"from_outlet_id,omitempty"`
+	ToOutletID   int                        `json:"to_outlet_id"`
+	Note         string                     `json:"note"`
+	Items        []StockTransferLineRequest `json:"items"`
+}
+
+type StockTransferLineRequest struct {
+	ProductID int `json:"product_id"`
+	Quantity  int `json:"quantity"`
+}
+
+// ReceiveStockTransferRequest receives a transfer at its destination. Lines
+// that are not listed are received as sent, so only discrepancies need to be
+// listed.
+type ReceiveStockTransferRequest struct {
+	Note  string                 `json:"note"`
+	Items []ReceivedTransferLine `json:"items"`
+}
+
+type ReceivedTransferLine struct {
+	ProductID        int `json:"product_id"`
+	ReceivedQuantity int `json:"received_quantity"`
+}
+
+// TransferDiscrepancy is a line of a received transfer that arrived in a
+// different quantity than was sent.
+type TransferDiscrepancy struct {
+	TransferID       int       `json:"transfer_id"`
+	FromOutletCode   string    `json:"from_outlet_code"`
+	ToOutletCode     string    `json:"to_outlet_code"`
+	ReceivedBy       string    `json:"received_by,omitempty"`
+	ReceivedAt       time.Time `json:"received_at"`
+	ProductID        *int      `json:"product_id"`
+	ProductName      string    `json:"product_name"`
+	SentQuantity     int       `json:"sent_quantity"`
+	ReceivedQuantity int       `json:"received_quantity"`
+	Discrepancy      int       `json:"discrepancy"`
+	UnitCost         int       `json:"unit_cost"`
+	DiscrepancyValue int       `json:"discrepancy_value"`
+}
+
+// TransferDiscrepancyReport lists the discrepancies of the transfers received
+// between StartDate and EndDate, both inclusive, at or from one outlet or
+// any of them. Shortages are goods lost on the way and surpluses goods that
+// arrived without being sent, both valued at cost.
+type TransferDiscrepancyReport struct {
+	StartDate        string                `json:"start_date"`
+	EndDate          string                `json:"end_date"`
+	Outlet           string                `json:"outlet,omitempty"`
+	ShortageQuantity int                   `json:"shortage_quantity"`
+	ShortageValue    int                   `json:"shortage_value"`
+	SurplusQuantity  int                   `json:"surplus_quantity"`
+	SurplusValue     int                   `json:"surplus_value"`
+	NetValue         int                   `json:"net_value"`
+	Lines            []TransferDiscrepancy `json:"lines"`
+}
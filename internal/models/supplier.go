@@ -0,0 +1,11 @@
+package models
+
+// Supplier is a vendor that purchase orders are placed with.
+type Supplier struct {
+	ID          int    `json:"id"`
+	Name        string `json:"name"`
+	ContactName string `json:"contact_name"`
+	Phone       string `json:"phone"`
+	Email       string `json:"email"`
+	Address     string `json:"address"`
+}
@@ -0,0 +1,39 @@
+package models
+
+import "math"
+
+// TaxRate is a tax such as PPN that can be assigned to a product or a
+// category. Rate is a percentage. When Inclusive is set the selling price
+// already contains the tax; otherwise the tax is added on top of it.
+type TaxRate struct {
+	ID        int     `json:"id"`
+	Name      string  `json:"name"`
+	Rate      float64 `json:"rate"`
+	Inclusive bool    `json:"inclusive"`
+}
+
+// Split returns the taxable base and the tax for an amount charged at this
+// rate. For inclusive rates the tax is carved out of the amount, for
+// exclusive rates it is added to it.
+func (t TaxRate) Split(amount int) (base, tax int) {
+	if t.Rate <= 0 {
+		return amount, 0
+	}
+	if t.Inclusive {
+		base = int(math.Round(float64(amount) * 100 / (100 + t.Rate)))
+		return base, amount - base
+	}
+	return amount, int(math.Round(float64(amount) * t.Rate / 100))
+}
+
+// PercentOf returns rate percent of amount, rounded to the nearest rupiah.
+func PercentOf(amount int, rate float64) int {
+	return int(math.Round(float64(amount) * rate / 100))
+}
+
+type TaxSummary struct {
+	TaxName       string  `json:"tax_name"`
+	Rate          float64 `json:"rate"`
+	TaxableAmount int     `json:"taxable_amount"`
+	TaxAmount     int     `json:"tax_amount"`
+}
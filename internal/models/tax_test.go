@@ -0,0 +1,30 @@
+package models
+
+import "testing"
+
+func TestTaxRateSplit(t *testing.T) {
+	tests := []struct {
+		name     string
+		rate     TaxRate
+		amount   int
+		wantBase int
+		wantTax  int
+	}{
+		{"no rate", TaxRate{}, 10000, 10000, 0},
+		{"negative rate", TaxRate{Rate: -5}, 10000, 10000, 0},
+		{"exclusive", TaxRate{Rate: 11}, 10000, 10000, 1100},
+		{"exclusive rounds to the nearest rupiah", TaxRate{Rate: 11}, 999, 999, 110},
+		{"inclusive", TaxRate{Rate: 11, Inclusive: true}, 11100, 10000, 1100},
+		{"inclusive rounds the base", TaxRate{Rate: 11, Inclusive: true}, 10000, 9009, 991},
+		{"zero amount", TaxRate{Rate: 11, Inclusive: true}, 0, 0, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			base, tax := tt.rate.Split(tt.amount)
+			if base != tt.wantBase || tax != tt.wantTax {
+				t.Errorf("Split(%d) = (%d, %d), want (%d, %d)", tt.amount, base, tax, tt.wantBase, tt.wantTax)
+			}
+		})
+	}
+}
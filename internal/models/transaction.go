@@ -2,28 +2,120 @@ package models
 
 import "time"
 
+type TransactionStatus string
+
+const (
+	TransactionStatusCompleted         TransactionStatus = "completed"
+	TransactionStatusVoided            TransactionStatus = "voided"
+	TransactionStatusPartiallyRefunded TransactionStatus = "partially_refunded"
+	TransactionStatusRefunded          TransactionStatus = "refunded"
+)
+
 type Transaction struct {
-	ID          int                 `json:"id"`
-	TotalAmount int                 `json:"total_amount"`
-	CreatedAt   time.Time           `json:"created_at"`
-	Details     []TransactionDetail `json:"details,omitempty"`
+	ID             int                 `json:"id"`
+	InvoiceNumber  string              `json:"invoice_number"`
+	OutletCode     string              `json:"outlet_code"`
+	ShiftID        *int                `json:"shift_id,omitempty"`
+	Status         TransactionStatus   `json:"status"`
+	GrossAmount    int                 `json:"gross_amount"`
+	DiscountAmount int                 `json:"discount_amount"`
+	SubtotalAmount int                 `json:"subtotal_amount"`
+	TaxAmount      int                 `json:"tax_amount"`
+	ServiceCharge  int                 `json:"service_charge"`
+	TotalAmount    int                 `json:"total_amount"`
+	RefundedAmount int                 `json:"refunded_amount"`
+	PaidAmount     int                 `json:"paid_amount"`
+	ChangeAmount   int                 `json:"change_amount"`
+	CreatedAt      time.Time           `json:"created_at"`
+	Details        []TransactionDetail `json:"details,omitempty"`
+	Payments       []Payment           `json:"payments,omitempty"`
+	Discounts      []AppliedDiscount   `json:"discounts,omitempty"`
+	LowStock       []LowStockEvent     `json:"low_stock,omitempty"`
 }
 
+// TransactionDetail keeps a snapshot of the product name and unit price taken
+// at checkout, so the line survives later renames, price changes and deletes.
+// ProductID is 0 once the product has been deleted. A variant sold keeps the
+// id and name of its parent product too. Subtotal is the taxable
+// line amount after DiscountAmount (including its share of basket discounts);
+// tax and the line's share of the service charge are kept apart from it.
 type TransactionDetail struct {
-	ID            int      `json:"id"`
-	TransactionID int      `json:"transaction_id"`
-	ProductID     int      `json:"product_id"`
-	ProductName   string   `json:"product_name"`
-	Product       *Product `json:"product,omitempty"`
-	Quantity      int      `json:"quantity"`
-	Subtotal      int      `json:"subtotal"`
+	ID                int      `json:"id"`
+	TransactionID     int      `json:"transaction_id"`
+	ProductID         int      `json:"product_id"`
+	ProductName       string   `json:"product_name"`
+	SKU               string   `json:"sku,omitempty"`
+	ParentProductID   *int     `json:"parent_product_id,omitempty"`
+	ParentProductName string   `json:"parent_product_name,omitempty"`
+	Product           *Product `json:"product,omitempty"`
+	UnitPrice         int      `json:"unit_price"`
+	Quantity          int      `json:"quantity"`
+	RefundedQuantity  int      `json:"refunded_quantity"`
+	DiscountAmount    int      `json:"discount_amount"`
+	Subtotal          int      `json:"subtotal"`
+	TaxName           string   `json:"tax_name,omitempty"`
+	TaxRate           float64  `json:"tax_rate"`
+	TaxAmount         int      `json:"tax_amount"`
+	ServiceCharge     int      `json:"service_charge"`
+	CostAmount        int      `json:"cost_amount,omitempty"`
 }
 
+// WithoutCost returns the transaction with the cost of goods sold of its
+// lines left out, for users who may not see it.
+func (t Transaction) WithoutCost() Transaction {
+	if t.Details != nil {
+		details := make([]TransactionDetail, len(t.Details))
+		for i, d := range t.Details {
+			d.CostAmount = 0
+			details[i] = d
+		}
+		t.Details = details
+	}
+	return t
+}
+
+// LineTotal is what the customer pays for the line.
+func (d TransactionDetail) LineTotal() int {
+	return d.Subtotal + d.TaxAmount + d.ServiceCharge
+}
+
+// TaxIncluded reports whether the line tax was carved out of the shelf price
+// rather than added on top of it.
+func (d TransactionDetail) TaxIncluded() bool {
+	return d.TaxAmount > 0 && d.Subtotal+d.TaxAmount == d.UnitPrice*d.Quantity-d.DiscountAmount
+}
+
+// CheckoutItem sells Quantity units of a product, or of its variant with
+// VariantID; ProductID can be left out when selling a variant. UnitPrice
+// overrides the shelf price for the line.
 type CheckoutItem struct {
-	ProductID int `json:"product_id"`
-	Quantity  int `json:"quantity"`
+	ProductID int  `json:"product_id"`
+	VariantID *int `json:"variant_id,omitempty"`
+	Quantity  int  `json:"quantity"`
+	UnitPrice *int `json:"unit_price,omitempty"`
 }
 
+// CheckoutRequest accepts either a single payment (PaymentMethod and
+// PaidAmount) or a split tender through Payments. ManualDiscount is taken off
+// the basket after the promotions. Price overrides, and manual discounts
+// above the approval threshold, need a role allowing them or an Approval.
 type CheckoutRequest struct {
-	Items []CheckoutItem `json:"items"`
+	Items          []CheckoutItem    `json:"items"`
+	PaymentMethod  PaymentMethod     `json:"payment_method,omitempty"`
+	PaidAmount     int               `json:"paid_amount,omitempty"`
+	Reference      string            `json:"reference,omitempty"`
+	Payments       []CheckoutPayment `json:"payments,omitempty"`
+	ManualDiscount int               `json:"manual_discount,omitempty"`
+	Approval       *ApprovalRequest  `json:"approval,omitempty"`
+
+	// Set by the handler and service, never read from the body
+	IdempotencyKey    string        `json:"-"`
+	RequestHash       string        `json:"-"`
+	ServiceChargeRate float64       `json:"-"`
+	CartID            int           `json:"-"`
+	Outlet            Outlet        `json:"-"`
+	InvoiceFormat     InvoiceFormat `json:"-"`
+	CostingMethod     CostingMethod `json:"-"`
+	Actor             User          `json:"-"`
+	Approvals         []Approval    `json:"-"`
 }
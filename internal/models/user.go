@@ -0,0 +1,95 @@
+package models
+
+import "time"
+
+// UserRole names a Role. The built-in ones are created by the migrations;
+// more can be added through the roles API.
+type UserRole string
+
+const (
+	UserRoleOwner   UserRole = "owner"
+	UserRoleManager UserRole = "manager"
+	UserRoleCashier UserRole = "cashier"
+)
+
+// User is someone who signs in to the API. Passwords and PINs are only kept
+// as bcrypt hashes. TokenVersion is embedded in issued tokens; bumping it
+// revokes every token the user holds. A user assigned to an outlet always
+// works at that outlet.
+type User struct {
+	ID           int          `json:"id"`
+	Username     string       `json:"username"`
+	Name         string       `json:"name"`
+	Role         UserRole     `json:"role"`
+	Permissions  []Permission `json:"permissions"`
+	OutletID     *int         `json:"outlet_id,omitempty"`
+	OutletCode   string       `json:"outlet_code,omitempty"`
+	Active       bool         `json:"active"`
+	HasPIN       bool         `json:"has_pin"`
+	CreatedAt    time.Time    `json:"created_at"`
+	UpdatedAt    time.Time    `json:"updated_at"`
+	PasswordHash string       `json:"-"`
+	PINHash      string       `json:"-"`
+	TokenVersion int          `json:"-"`
+
+	FailedLoginAttempts int        `json:"-"`
+	LockedUntil         *time.Time `json:"-"`
+}
+
+// Can reports whether the user's role grants p.
+func (u User) Can(p Permission) bool {
+	for _, granted := range u.Permissions {
+		if granted == p {
+			return true
+		}
+	}
+	return false
+}
+
+// CanViewCost reports whether the user may see what goods cost, which takes
+// a permission to manage inventory or purchasing.
+func (u User) CanViewCost() bool {
+	return u.Can(PermissionInventoryManage) || u.Can(PermissionPurchasingManage)
+}
+
+type CreateUserRequest struct {
+	Username string   `json:"username"`
+	Name     string   `json:"name"`
+	Password string   `json:"password"`
+	PIN      string   `json:"pin,omitempty"`
+	Role     UserRole `json:"role"`
+	OutletID *int     `json:"outlet_id,omitempty"`
+}
+
+// UpdateUserRequest leaves the password, PIN and active flag unchanged when
+// they are omitted.
+type UpdateUserRequest struct {
+	Name     string   `json:"name"`
+	Password string   `json:"password,omitempty"`
+	PIN      string   `json:"pin,omitempty"`
+	Role     UserRole `json:"role"`
+	OutletID *int     `json:"outlet_id,omitempty"`
+	Active   *bool    `json:"active,omitempty"`
+}
+
+type LoginRequest struct {
+	Username string `json:"username"`
+	Password string `json:"password"`
+}
+
+type PINLoginRequest struct {
+	Username string `json:"username"`
+	PIN      string `json:"pin"`
+}
+
+type RefreshTokenRequest struct {
+	RefreshToken string `json:"refresh_token"`
+}
+
+type AuthTokens struct {
+	AccessToken  string `json:"access_token"`
+	RefreshToken string `json:"refresh_token"`
+	TokenType    string `json:"token_type"`
+	ExpiresIn    int    `json:"expires_in"`
+	User         User   `json:"user"`
+}
@@ -0,0 +1,87 @@
+package repository
+
+import (
+	"database/sql"
+	"fmt"
+	"kasir-api-go/internal/models"
+	"kasir-api-go/internal/utils"
+)
+
+type ApprovalRepository interface {
+	GetAll(action models.ApprovalAction) ([]models.Approval, error)
+}
+
+type postgresApprovalRepository struct {
+	db *sql.DB
+}
+
+func NewPostgresApprovalRepository(db *sql.DB) ApprovalRepository {
+	return &postgresApprovalRepository{db: db}
+}
+
+// GetAll lists the approvals newest first, optionally only those of one
+// action.
+func (r *postgresApprovalRepository) GetAll(action models.ApprovalAction) ([]models.Approval, error) {
+	rows, err := r.db.Query(`
+		SELECT id, action, COALESCE(requested_by_id, 0), requested_by, COALESCE(approved_by_id, 0), approved_by,
+			transaction_id, product_id, details, created_at
+		FROM approvals
+		WHERE $1 = '' OR action = $1
+		ORDER BY created_at DESC, id DESC`, action)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	approvals := []models.Approval{}
+	for rows.Next() {
+		var a models.Approval
+		var transactionID, productID sql.NullInt64
+		err := rows.Scan(&a.ID, &a.Action, &a.RequestedByID, &a.RequestedBy, &a.ApprovedByID, &a.ApprovedBy,
+			&transactionID, &productID, &a.Details, &a.CreatedAt)
+		if err != nil {
+			return nil, err
+		}
+		a.TransactionID = nullableInt(transactionID)
+		a.ProductID = nullableInt(productID)
+		approvals = append(approvals, a)
+	}
+
+	return approvals, rows.Err()
+}
+
+// insertApprovals writes approvals to the audit trail inside the transaction
+// of the action they approve, spending the approval tokens they were given
+// with. One token may approve several steps of the same action, such as a
+// price override and a large discount in one checkout, but no other action.
+func insertApprovals(tx *sql.Tx, approvals []models.Approval) error {
+	spent := map[string]bool{}
+	for i := range approvals {
+		a := &approvals[i]
+		err := tx.QueryRow(`
+			INSERT INTO approvals (action, requested_by_id, requested_by, approved_by_id, approved_by, transaction_id, product_id, details)
+			VALUES ($1, NULLIF($2, 0), $3, NULLIF($4, 0), $5, $6, $7, $8) RETURNING id, created_at`,
+			a.Action, a.RequestedByID, a.RequestedBy, a.ApprovedByID, a.ApprovedBy, a.TransactionID, a.ProductID, a.Details).
+			Scan(&a.ID, &a.CreatedAt)
+		if err != nil {
+			return err
+		}
+
+		if a.TokenID == "" || spent[a.TokenID] {
+			continue
+		}
+		res, err := tx.Exec(`
+			INSERT INTO used_approval_tokens (token_id, approval_id) VALUES ($1, $2)
+			ON CONFLICT (token_id) DO NOTHING`, a.TokenID, a.ID)
+		if err != nil {
+			return err
+		}
+		if n, err := res.RowsAffected(); err != nil {
+			return err
+		} else if n == 0 {
+			return fmt.Errorf("%w: the approval token has already been used", utils.ErrApprovalDenied)
+		}
+		spent[a.TokenID] = true
+	}
+	return nil
+}
@@ -0,0 +1,223 @@
+package repository
+
+import (
+	"database/sql"
+	"kasir-api-go/internal/models"
+	"kasir-api-go/internal/utils"
+	"time"
+)
+
+type CartRepository interface {
+	Create(cart models.Cart) (models.Cart, error)
+	GetAll(status models.CartStatus) ([]models.Cart, error)
+	GetByID(id int) (models.Cart, error)
+	AddItem(cartID, productID, quantity int, expiresAt time.Time) error
+	UpdateItem(cartID, productID, quantity int, expiresAt time.Time) error
+	RemoveItem(cartID, productID int, expiresAt time.Time) error
+	SetStatus(id int, from []models.CartStatus, to models.CartStatus, expiresAt time.Time) error
+	ExpireStale(now time.Time) (int64, error)
+}
+
+type postgresCartRepository struct {
+	db *sql.DB
+}
+
+func NewPostgresCartRepository(db *sql.DB) CartRepository {
+	return &postgresCartRepository{db: db}
+}
+
+func (r *postgresCartRepository) Create(cart models.Cart) (models.Cart, error) {
+	query := `INSERT INTO carts (outlet_id, label, status, expires_at) VALUES ($1, $2, $3, $4) RETURNING id, created_at, updated_at`
+	err := r.db.QueryRow(query, cart.OutletID, cart.Label, cart.Status, cart.ExpiresAt).Scan(&cart.ID, &cart.CreatedAt, &cart.UpdatedAt)
+	if err != nil {
+		return models.Cart{}, err
+	}
+	cart.Items = []models.CartItem{}
+	return cart, nil
+}
+
+func (r *postgresCartRepository) GetAll(status models.CartStatus) ([]models.Cart, error) {
+	query := cartSelect
+	var args []interface{}
+	if status != "" {
+		query += " WHERE c.status = $1"
+		args = append(args, status)
+	} else {
+		query += " WHERE c.status IN ('open', 'held')"
+	}
+	query += " ORDER BY c.updated_at DESC"
+
+	rows, err := r.db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	carts := []models.Cart{}
+	for rows.Next() {
+		c, err := scanCart(rows)
+		if err != nil {
+			return nil, err
+		}
+		carts = append(carts, c)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	for i := range carts {
+		items, err := r.getItems(carts[i].ID)
+		if err != nil {
+			return nil, err
+		}
+		carts[i].Items = items
+	}
+
+	return carts, nil
+}
+
+func (r *postgresCartRepository) GetByID(id int) (models.Cart, error) {
+	c, err := scanCart(r.db.QueryRow(cartSelect+` WHERE c.id = $1`, id))
+	if err == sql.ErrNoRows {
+		return c, utils.ErrCartNotFound
+	}
+	if err != nil {
+		return c, err
+	}
+
+	c.Items, err = r.getItems(c.ID)
+	return c, err
+}
+
+const cartSelect = `
+	SELECT c.id, c.outlet_id, o.code, c.label, c.status, c.transaction_id, c.created_at, c.updated_at, c.expires_at
+	FROM carts c
+	JOIN outlets o ON o.id = c.outlet_id`
+
+func scanCart(row rowScanner) (models.Cart, error) {
+	var c models.Cart
+	var transactionID sql.NullInt64
+	err := row.Scan(&c.ID, &c.OutletID, &c.OutletCode, &c.Label, &c.Status, &transactionID, &c.CreatedAt, &c.UpdatedAt, &c.ExpiresAt)
+	if err != nil {
+		return c, err
+	}
+	if transactionID.Valid {
+		id := int(transactionID.Int64)
+		c.TransactionID = &id
+	}
+	return c, nil
+}
+
+func (r *postgresCartRepository) getItems(cartID int) ([]models.CartItem, error) {
+	query := `
+		SELECT ci.id, ci.cart_id, ci.product_id, p.name, p.price, ci.quantity
+		FROM cart_items ci
+		JOIN products p ON ci.product_id = p.id
+		WHERE ci.cart_id = $1
+		ORDER BY ci.id`
+
+	rows, err := r.db.Query(query, cartID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	items := []models.CartItem{}
+	for rows.Next() {
+		var item models.CartItem
+		if err := rows.Scan(&item.ID, &item.CartID, &item.ProductID, &item.ProductName, &item.UnitPrice, &item.Quantity); err != nil {
+			return nil, err
+		}
+		items = append(items, item)
+	}
+
+	return items, rows.Err()
+}
+
+func (r *postgresCartRepository) AddItem(cartID, productID, quantity int, expiresAt time.Time) error {
+	return r.changeItems(cartID, expiresAt, `
+		INSERT INTO cart_items (cart_id, product_id, quantity) VALUES ($1, $2, $3)
+		ON CONFLICT (cart_id, product_id) DO UPDATE SET quantity = cart_items.quantity + EXCLUDED.quantity`,
+		cartID, productID, quantity)
+}
+
+func (r *postgresCartRepository) UpdateItem(cartID, productID, quantity int, expiresAt time.Time) error {
+	return r.changeItems(cartID, expiresAt, `UPDATE cart_items SET quantity = $3 WHERE cart_id = $1 AND product_id = $2`,
+		cartID, productID, quantity)
+}
+
+func (r *postgresCartRepository) RemoveItem(cartID, productID int, expiresAt time.Time) error {
+	return r.changeItems(cartID, expiresAt, `DELETE FROM cart_items WHERE cart_id = $1 AND product_id = $2`,
+		cartID, productID)
+}
+
+// changeItems edits the lines of an open cart and pushes its expiry back. The
+// cart row is locked so a concurrent checkout cannot pick up half an edit.
+func (r *postgresCartRepository) changeItems(cartID int, expiresAt time.Time, query string, args ...interface{}) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	var status models.CartStatus
+	err = tx.QueryRow("SELECT status FROM carts WHERE id = $1 FOR UPDATE", cartID).Scan(&status)
+	if err == sql.ErrNoRows {
+		return utils.ErrCartNotFound
+	}
+	if err != nil {
+		return err
+	}
+	if status != models.CartStatusOpen {
+		return utils.ErrCartNotOpen
+	}
+
+	result, err := tx.Exec(query, args...)
+	if err != nil {
+		return err
+	}
+	if rowsAffected, _ := result.RowsAffected(); rowsAffected == 0 {
+		return utils.ErrInvalidCartItem
+	}
+
+	_, err = tx.Exec("UPDATE carts SET updated_at = CURRENT_TIMESTAMP, expires_at = $1 WHERE id = $2", expiresAt, cartID)
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+func (r *postgresCartRepository) SetStatus(id int, from []models.CartStatus, to models.CartStatus, expiresAt time.Time) error {
+	allowed := make([]string, len(from))
+	for i, s := range from {
+		allowed[i] = string(s)
+	}
+
+	query := `
+		UPDATE carts SET status = $1, updated_at = CURRENT_TIMESTAMP, expires_at = $2
+		WHERE id = $3 AND status = ANY($4)`
+
+	result, err := r.db.Exec(query, to, expiresAt, id, allowed)
+	if err != nil {
+		return err
+	}
+
+	rowsAffected, _ := result.RowsAffected()
+	if rowsAffected == 0 {
+		if _, err := r.GetByID(id); err != nil {
+			return err
+		}
+		return utils.ErrCartNotOpen
+	}
+	return nil
+}
+
+func (r *postgresCartRepository) ExpireStale(now time.Time) (int64, error) {
+	result, err := r.db.Exec(`UPDATE carts SET status = 'expired', updated_at = CURRENT_TIMESTAMP
+		WHERE status IN ('open', 'held') AND expires_at < $1`, now)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
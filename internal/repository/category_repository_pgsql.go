@@ -14,7 +14,7 @@ func NewPostgresCategoryRepository(db *sql.DB) *PostgresCategoryRepository {
 }
 
 func (r *PostgresCategoryRepository) GetAll() []models.Category {
-	query := `SELECT id, name, description FROM categories ORDER BY id`
+	query := `SELECT id, name, description, tax_rate_id FROM categories ORDER BY id`
 
 	rows, err := r.db.Query(query)
 	if err != nil {
@@ -25,9 +25,14 @@ func (r *PostgresCategoryRepository) GetAll() []models.Category {
 	var categories []models.Category
 	for rows.Next() {
 		var c models.Category
-		if err := rows.Scan(&c.ID, &c.Name, &c.Description); err != nil {
+		var taxRateID sql.NullInt64
+		if err := rows.Scan(&c.ID, &c.Name, &c.Description, &taxRateID); err != nil {
 			continue
 		}
+		if taxRateID.Valid {
+			id := int(taxRateID.Int64)
+			c.TaxRateID = &id
+		}
 		categories = append(categories, c)
 	}
 
@@ -35,26 +40,32 @@ func (r *PostgresCategoryRepository) GetAll() []models.Category {
 }
 
 func (r *PostgresCategoryRepository) GetByID(id int) (models.Category, bool) {
-	query := `SELECT id, name, description FROM categories WHERE id = $1`
+	query := `SELECT id, name, description, tax_rate_id FROM categories WHERE id = $1`
 
 	var c models.Category
-	err := r.db.QueryRow(query, id).Scan(&c.ID, &c.Name, &c.Description)
+	var taxRateID sql.NullInt64
+	err := r.db.QueryRow(query, id).Scan(&c.ID, &c.Name, &c.Description, &taxRateID)
 	if err != nil {
 		return models.Category{}, false
 	}
 
+	if taxRateID.Valid {
+		id := int(taxRateID.Int64)
+		c.TaxRateID = &id
+	}
+
 	return c, true
 }
 
 func (r *PostgresCategoryRepository) Create(category models.Category) {
-	query := `INSERT INTO categories (id, name, description) VALUES ($1, $2, $3)`
-	r.db.Exec(query, category.ID, category.Name, category.Description)
+	query := `INSERT INTO categories (id, name, description, tax_rate_id) VALUES ($1, $2, $3, $4)`
+	r.db.Exec(query, category.ID, category.Name, category.Description, category.TaxRateID)
 }
 
 func (r *PostgresCategoryRepository) Update(id int, category models.Category) bool {
-	query := `UPDATE categories SET name = $1, description = $2, updated_at = CURRENT_TIMESTAMP WHERE id = $3`
+	query := `UPDATE categories SET name = $1, description = $2, tax_rate_id = $3, updated_at = CURRENT_TIMESTAMP WHERE id = $4`
 
-	result, err := r.db.Exec(query, category.Name, category.Description, id)
+	result, err := r.db.Exec(query, category.Name, category.Description, category.TaxRateID, id)
 	if err != nil {
 		return false
 	}
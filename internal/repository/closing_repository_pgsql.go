@@ -0,0 +1,151 @@
+package repository
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"kasir-api-go/internal/models"
+	"kasir-api-go/internal/utils"
+	"time"
+)
+
+type ClosingRepository interface {
+	Close(outletCode string, day *time.Time, actor models.User) (models.DailyClosing, error)
+	GetAll(outletCode string) ([]models.DailyClosing, error)
+	GetByDate(outletCode string, day time.Time) (models.DailyClosing, error)
+}
+
+type postgresClosingRepository struct {
+	db *sql.DB
+}
+
+func NewPostgresClosingRepository(db *sql.DB) ClosingRepository {
+	return &postgresClosingRepository{db: db}
+}
+
+// Close freezes the Z-report of a day, or of the current business day when day
+// is nil. Today is taken from the database clock, the same one sales are dated
+// by. The exclusive day lock waits for the sales, voids and refunds already in
+// progress on that day and keeps new ones out until the closing is stored.
+func (r *postgresClosingRepository) Close(outletCode string, day *time.Time, actor models.User) (models.DailyClosing, error) {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return models.DailyClosing{}, err
+	}
+	defer tx.Rollback()
+
+	today, err := businessDay(tx)
+	if err != nil {
+		return models.DailyClosing{}, err
+	}
+	start := time.Date(today.Year(), today.Month(), today.Day(), 0, 0, 0, 0, time.UTC)
+	if day != nil {
+		requested := time.Date(day.Year(), day.Month(), day.Day(), 0, 0, 0, 0, time.UTC)
+		if requested.After(start) {
+			return models.DailyClosing{}, fmt.Errorf("%w: cannot close a future day", utils.ErrInvalidClosing)
+		}
+		start = requested
+	}
+
+	if err := lockBusinessDay(tx, outletCode, start, true); err != nil {
+		return models.DailyClosing{}, err
+	}
+
+	report, err := zReport(tx, "t.outlet_code = $1 AND t.created_at >= $2 AND t.created_at < $3",
+		"t.outlet_code = $1 AND r.created_at >= $2 AND r.created_at < $3", outletCode, start, start.AddDate(0, 0, 1))
+	if err != nil {
+		return models.DailyClosing{}, err
+	}
+	body, err := json.Marshal(report)
+	if err != nil {
+		return models.DailyClosing{}, err
+	}
+
+	closing := models.DailyClosing{
+		OutletCode:   outletCode,
+		BusinessDate: start.Format("2006-01-02"),
+		ClosedBy:     actor.Name,
+		Report:       report,
+	}
+	err = tx.QueryRow(`
+		INSERT INTO daily_closings (outlet_code, business_date, closed_by, closed_by_id, report) VALUES ($1, $2, $3, $4, $5)
+		RETURNING id, closed_at`, closing.OutletCode, closing.BusinessDate, closing.ClosedBy, optionalID(actor.ID), string(body)).
+		Scan(&closing.ID, &closing.ClosedAt)
+	if err != nil {
+		return models.DailyClosing{}, err
+	}
+
+	return closing, tx.Commit()
+}
+
+const closingColumns = `id, outlet_code, to_char(business_date, 'YYYY-MM-DD'), closed_by, closed_at, report`
+
+func scanClosing(row rowScanner) (models.DailyClosing, error) {
+	var c models.DailyClosing
+	var report []byte
+	if err := row.Scan(&c.ID, &c.OutletCode, &c.BusinessDate, &c.ClosedBy, &c.ClosedAt, &report); err != nil {
+		return c, err
+	}
+	return c, json.Unmarshal(report, &c.Report)
+}
+
+func (r *postgresClosingRepository) GetAll(outletCode string) ([]models.DailyClosing, error) {
+	rows, err := r.db.Query(`SELECT `+closingColumns+` FROM daily_closings WHERE outlet_code = $1 ORDER BY business_date DESC`, outletCode)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	closings := []models.DailyClosing{}
+	for rows.Next() {
+		c, err := scanClosing(rows)
+		if err != nil {
+			return nil, err
+		}
+		closings = append(closings, c)
+	}
+
+	return closings, rows.Err()
+}
+
+func (r *postgresClosingRepository) GetByDate(outletCode string, day time.Time) (models.DailyClosing, error) {
+	c, err := scanClosing(r.db.QueryRow(`SELECT `+closingColumns+` FROM daily_closings WHERE outlet_code = $1 AND business_date = $2`,
+		outletCode, day.Format("2006-01-02")))
+	if err == sql.ErrNoRows {
+		return c, utils.ErrClosingNotFound
+	}
+	return c, err
+}
+
+// businessDay returns the date of the database clock when tx started, which
+// is the day every row tx creates is dated.
+func businessDay(tx *sql.Tx) (time.Time, error) {
+	var day time.Time
+	err := tx.QueryRow(`SELECT CURRENT_DATE`).Scan(&day)
+	return day, err
+}
+
+// lockBusinessDay takes the advisory lock of an outlet's business day until
+// the end of tx and fails when the day has been closed. Sales, voids and
+// refunds share the lock; closing the day takes it exclusively.
+func lockBusinessDay(tx *sql.Tx, outletCode string, day time.Time, exclusive bool) error {
+	lock := "pg_advisory_xact_lock_shared"
+	if exclusive {
+		lock = "pg_advisory_xact_lock"
+	}
+	date := day.Format("2006-01-02")
+	if _, err := tx.Exec(`SELECT `+lock+`(hashtext('daily_closing'), hashtext($1::text || '/' || $2::text))`, outletCode, date); err != nil {
+		return err
+	}
+
+	var closed bool
+	err := tx.QueryRow(`SELECT EXISTS (SELECT 1 FROM daily_closings WHERE outlet_code = $1 AND business_date = $2)`,
+		outletCode, date).Scan(&closed)
+	if err != nil {
+		return err
+	}
+	if closed {
+		return utils.ErrDayClosed
+	}
+	return nil
+}
@@ -0,0 +1,96 @@
+package repository
+
+import (
+	"database/sql"
+	"kasir-api-go/internal/models"
+	"time"
+)
+
+type LowStockRepository interface {
+	// GetBelowMinimum returns the products below their minimum stock at
+	// each outlet with the quantity the outlet sold since the given time. An
+	// empty outletCode covers every outlet.
+	GetBelowMinimum(since time.Time, outletCode string) ([]models.ReorderSuggestion, error)
+	GetEvents(limit int, outletCode string) ([]models.LowStockEvent, error)
+}
+
+type postgresLowStockRepository struct {
+	db *sql.DB
+}
+
+func NewPostgresLowStockRepository(db *sql.DB) LowStockRepository {
+	return &postgresLowStockRepository{db: db}
+}
+
+// GetBelowMinimum counts what was sold net of refunds; voided sales are
+// refunded in full and drop out. The minimum stock applies to every outlet.
+func (r *postgresLowStockRepository) GetBelowMinimum(since time.Time, outletCode string) ([]models.ReorderSuggestion, error) {
+	rows, err := r.db.Query(`
+		SELECT p.id, p.name, o.id, o.code, COALESCE(os.stock, 0), p.min_stock, p.reorder_quantity, COALESCE(s.sold, 0)
+		FROM products p
+		CROSS JOIN outlets o
+		LEFT JOIN outlet_stocks os ON os.product_id = p.id AND os.outlet_id = o.id
+		LEFT JOIN (
+			SELECT t.outlet_code, td.product_id, SUM(td.quantity - td.refunded_quantity) AS sold
+			FROM transaction_details td
+			JOIN transactions t ON t.id = td.transaction_id
+			WHERE t.created_at >= $1
+			GROUP BY t.outlet_code, td.product_id
+		) s ON s.product_id = p.id AND s.outlet_code = o.code
+		WHERE p.min_stock > 0 AND COALESCE(os.stock, 0) < p.min_stock AND ($2 = '' OR o.code = $2)
+		ORDER BY COALESCE(os.stock, 0) - p.min_stock, p.id, o.code`, since, outletCode)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	products := []models.ReorderSuggestion{}
+	for rows.Next() {
+		var s models.ReorderSuggestion
+		err := rows.Scan(&s.ProductID, &s.ProductName, &s.OutletID, &s.OutletCode, &s.Stock, &s.MinStock,
+			&s.ReorderQuantity, &s.SoldQuantity)
+		if err != nil {
+			return nil, err
+		}
+		products = append(products, s)
+	}
+
+	return products, rows.Err()
+}
+
+// GetEvents returns the latest low-stock events, newest first.
+func (r *postgresLowStockRepository) GetEvents(limit int, outletCode string) ([]models.LowStockEvent, error) {
+	rows, err := r.db.Query(`
+		SELECT e.id, e.product_id, e.product_name, e.outlet_id, o.code, e.stock, e.min_stock, e.transaction_id, e.created_at
+		FROM low_stock_events e
+		JOIN outlets o ON o.id = e.outlet_id
+		WHERE $2 = '' OR o.code = $2
+		ORDER BY e.created_at DESC, e.id DESC
+		LIMIT $1`, limit, outletCode)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	events := []models.LowStockEvent{}
+	for rows.Next() {
+		var e models.LowStockEvent
+		var transactionID sql.NullInt64
+		err := rows.Scan(&e.ID, &e.ProductID, &e.ProductName, &e.OutletID, &e.OutletCode, &e.Stock, &e.MinStock,
+			&transactionID, &e.CreatedAt)
+		if err != nil {
+			return nil, err
+		}
+		e.TransactionID = nullableInt(transactionID)
+		events = append(events, e)
+	}
+
+	return events, rows.Err()
+}
+
+// insertLowStockEvent records e inside tx and fills in its id and time.
+func insertLowStockEvent(tx *sql.Tx, e *models.LowStockEvent) error {
+	return tx.QueryRow(`INSERT INTO low_stock_events (product_id, product_name, outlet_id, stock, min_stock, transaction_id)
+		VALUES ($1, $2, $3, $4, $5, $6) RETURNING id, created_at`,
+		e.ProductID, e.ProductName, e.OutletID, e.Stock, e.MinStock, e.TransactionID).Scan(&e.ID, &e.CreatedAt)
+}
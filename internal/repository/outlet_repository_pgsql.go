@@ -0,0 +1,120 @@
+package repository
+
+import (
+	"database/sql"
+	"kasir-api-go/internal/models"
+	"kasir-api-go/internal/utils"
+)
+
+type OutletRepository interface {
+	GetAll() ([]models.Outlet, error)
+	GetByID(id int) (models.Outlet, error)
+	GetByCode(code string) (models.Outlet, error)
+	Create(outlet models.Outlet) (models.Outlet, error)
+	Update(id int, outlet models.Outlet) (models.Outlet, error)
+	// GetStock returns the stock of every product at an outlet.
+	GetStock(id int) ([]models.OutletStock, error)
+}
+
+type postgresOutletRepository struct {
+	db *sql.DB
+}
+
+func NewPostgresOutletRepository(db *sql.DB) OutletRepository {
+	return &postgresOutletRepository{db: db}
+}
+
+const outletColumns = `id, code, name, address`
+
+func scanOutlet(row rowScanner) (models.Outlet, error) {
+	var o models.Outlet
+	err := row.Scan(&o.ID, &o.Code, &o.Name, &o.Address)
+	return o, err
+}
+
+func (r *postgresOutletRepository) GetAll() ([]models.Outlet, error) {
+	rows, err := r.db.Query(`SELECT ` + outletColumns + ` FROM outlets ORDER BY code`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	outlets := []models.Outlet{}
+	for rows.Next() {
+		o, err := scanOutlet(rows)
+		if err != nil {
+			return nil, err
+		}
+		outlets = append(outlets, o)
+	}
+
+	return outlets, rows.Err()
+}
+
+func (r *postgresOutletRepository) GetByID(id int) (models.Outlet, error) {
+	o, err := scanOutlet(r.db.QueryRow(`SELECT `+outletColumns+` FROM outlets WHERE id = $1`, id))
+	if err == sql.ErrNoRows {
+		return o, utils.ErrOutletNotFound
+	}
+	return o, err
+}
+
+func (r *postgresOutletRepository) GetByCode(code string) (models.Outlet, error) {
+	o, err := scanOutlet(r.db.QueryRow(`SELECT `+outletColumns+` FROM outlets WHERE code = $1`, code))
+	if err == sql.ErrNoRows {
+		return o, utils.ErrOutletNotFound
+	}
+	return o, err
+}
+
+func (r *postgresOutletRepository) Create(outlet models.Outlet) (models.Outlet, error) {
+	query := `INSERT INTO outlets (code, name, address) VALUES ($1, $2, $3)
+		ON CONFLICT (code) DO NOTHING
+		RETURNING id`
+	err := r.db.QueryRow(query, outlet.Code, outlet.Name, outlet.Address).Scan(&outlet.ID)
+	if err == sql.ErrNoRows {
+		return models.Outlet{}, utils.ErrOutletExists
+	}
+	if err != nil {
+		return models.Outlet{}, err
+	}
+	return outlet, nil
+}
+
+// Update changes the name and address of an outlet; the code stays.
+func (r *postgresOutletRepository) Update(id int, outlet models.Outlet) (models.Outlet, error) {
+	o, err := scanOutlet(r.db.QueryRow(`
+		UPDATE outlets SET name = $1, address = $2, updated_at = CURRENT_TIMESTAMP
+		WHERE id = $3
+		RETURNING `+outletColumns, outlet.Name, outlet.Address, id))
+	if err == sql.ErrNoRows {
+		return o, utils.ErrOutletNotFound
+	}
+	return o, err
+}
+
+func (r *postgresOutletRepository) GetStock(id int) ([]models.OutletStock, error) {
+	rows, err := r.db.Query(`
+		SELECT o.id, o.code, p.id, p.name, COALESCE(s.stock, 0), COALESCE(t.quantity, 0)
+		FROM outlets o
+		CROSS JOIN products p
+		LEFT JOIN outlet_stocks s ON s.outlet_id = o.id AND s.product_id = p.id
+		LEFT JOIN (`+inTransitSelect+`) t ON t.outlet_id = o.id AND t.product_id = p.id
+		WHERE o.id = $1
+		ORDER BY p.name, p.id`, id)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	stocks := []models.OutletStock{}
+	for rows.Next() {
+		var s models.OutletStock
+		if err := rows.Scan(&s.OutletID, &s.OutletCode, &s.ProductID, &s.ProductName, &s.Stock, &s.InTransit); err != nil {
+			return nil, err
+		}
+		stocks = append(stocks, s)
+	}
+
+	return stocks, rows.Err()
+}
@@ -2,15 +2,28 @@ package repository
 
 import (
 	"kasir-api-go/internal/models"
+	"kasir-api-go/internal/utils"
 	"strings"
 )
 
 type ProductRepository interface {
 	GetAll(search string) []models.Product
+	// GetByID returns a product with its stock at each outlet and, for a
+	// parent product, its variants.
 	GetByID(id int) (models.Product, bool)
-	Create(product models.Product)
-	Update(id int, product models.Product) bool
-	Delete(id int) bool
+	GetVariants(parentID int) []models.Product
+	// GetByCode finds the product with code as its SKU or barcode.
+	GetByCode(code string) (models.Product, bool)
+	// Create and Update record the stock they set in the stock ledger,
+	// attributed to the user with userID. The stock goes in or out at the
+	// outlet with outletID; Update, given a stock, moves it by the
+	// difference from the current stock at that outlet, and records the
+	// approval of a price change in the same transaction.
+	Create(product models.Product, outletID, userID int) error
+	Update(id int, product models.Product, stock *int, outletID, userID int, approval *models.Approval) error
+	// Delete removes a product and its variants. Their sales and stock
+	// ledger are kept with the names snapshotted.
+	Delete(id int) error
 }
 
 type InMemoryProductRepository struct {
@@ -50,7 +63,7 @@ func (r *InMemoryProductRepository) GetAll(search string) []models.Product {
 	search = strings.ToLower(search)
 	var filtered []models.Product
 	for _, p := range r.products {
-		if strings.Contains(strings.ToLower(p.Name), search) {
+		if strings.Contains(strings.ToLower(p.Name), search) || strings.EqualFold(p.SKU, search) || strings.EqualFold(p.Barcode, search) {
 			filtered = append(filtered, p)
 		}
 	}
@@ -60,32 +73,57 @@ func (r *InMemoryProductRepository) GetAll(search string) []models.Product {
 func (r *InMemoryProductRepository) GetByID(id int) (models.Product, bool) {
 	for _, p := range r.products {
 		if p.ID == id {
+			p.Variants = r.GetVariants(id)
 			return p, true
 		}
 	}
 	return models.Product{}, false
 }
 
-func (r *InMemoryProductRepository) Create(product models.Product) {
+func (r *InMemoryProductRepository) GetVariants(parentID int) []models.Product {
+	var variants []models.Product
+	for _, p := range r.products {
+		if p.ParentID != nil && *p.ParentID == parentID {
+			variants = append(variants, p)
+		}
+	}
+	return variants
+}
+
+func (r *InMemoryProductRepository) GetByCode(code string) (models.Product, bool) {
+	for _, p := range r.products {
+		if code != "" && (p.SKU == code || p.Barcode == code) {
+			return p, true
+		}
+	}
+	return models.Product{}, false
+}
+
+func (r *InMemoryProductRepository) Create(product models.Product, outletID, userID int) error {
 	r.products = append(r.products, product)
+	return nil
 }
 
-func (r *InMemoryProductRepository) Update(id int, product models.Product) bool {
+func (r *InMemoryProductRepository) Update(id int, product models.Product, stock *int, outletID, userID int, approval *models.Approval) error {
 	for i, p := range r.products {
 		if p.ID == id {
+			product.Stock = p.Stock
+			if stock != nil {
+				product.Stock = *stock
+			}
 			r.products[i] = product
-			return true
+			return nil
 		}
 	}
-	return false
+	return utils.ErrProductNotFound
 }
 
-func (r *InMemoryProductRepository) Delete(id int) bool {
+func (r *InMemoryProductRepository) Delete(id int) error {
 	for i, p := range r.products {
 		if p.ID == id {
 			r.products = append(r.products[:i], r.products[i+1:]...)
-			return true
+			return nil
 		}
 	}
-	return false
+	return utils.ErrProductNotFound
 }
@@ -3,6 +3,7 @@ package repository
 import (
 	"database/sql"
 	"kasir-api-go/internal/models"
+	"kasir-api-go/internal/utils"
 )
 
 type PostgresProductRepository struct {
@@ -13,112 +14,230 @@ func NewPostgresProductRepository(db *sql.DB) *PostgresProductRepository {
 	return &PostgresProductRepository{db: db}
 }
 
-func (r *PostgresProductRepository) GetAll(search string) []models.Product {
-	query := `
-		SELECT p.id, p.name, p.price, p.stock, c.id, c.name, c.description
-		FROM products p
-		LEFT JOIN categories c ON p.category_id = c.id
-	`
-	var args []interface{}
-	if search != "" {
-		query += " WHERE p.name ILIKE $1"
-		args = append(args, "%"+search+"%")
+// productSelect selects the columns scanProduct reads
+const productSelect = `
+	SELECT p.id, p.parent_id, p.name, p.variant_name, COALESCE(p.sku, ''), COALESCE(p.barcode, ''), p.price, p.cost, p.stock,
+		p.min_stock, p.reorder_quantity, p.tax_rate_id, c.id, c.name, c.description
+	FROM products p
+	LEFT JOIN categories c ON p.category_id = c.id`
+
+func scanProduct(row rowScanner) (models.Product, error) {
+	var p models.Product
+	var parentID, taxRateID, categoryID sql.NullInt64
+	var categoryName, categoryDesc sql.NullString
+
+	if err := row.Scan(&p.ID, &parentID, &p.Name, &p.VariantName, &p.SKU, &p.Barcode, &p.Price, &p.Cost, &p.Stock,
+		&p.MinStock, &p.ReorderQuantity, &taxRateID, &categoryID, &categoryName, &categoryDesc); err != nil {
+		return models.Product{}, err
 	}
-	query += " ORDER BY p.id"
+	p.ParentID = nullableInt(parentID)
+	p.TaxRateID = nullableInt(taxRateID)
+
+	if categoryID.Valid {
+		p.Category = &models.Category{
+			ID:          int(categoryID.Int64),
+			Name:        categoryName.String,
+			Description: categoryDesc.String,
+		}
+	}
+	return p, nil
+}
 
+func (r *PostgresProductRepository) queryProducts(query string, args ...interface{}) ([]models.Product, error) {
 	rows, err := r.db.Query(query, args...)
 	if err != nil {
-		return []models.Product{}
+		return nil, err
 	}
 	defer rows.Close()
 
 	var products []models.Product
 	for rows.Next() {
-		var p models.Product
-		var categoryID sql.NullInt64
-		var categoryName, categoryDesc sql.NullString
-
-		if err := rows.Scan(&p.ID, &p.Name, &p.Price, &p.Stock, &categoryID, &categoryName, &categoryDesc); err != nil {
-			continue
-		}
-
-		if categoryID.Valid {
-			p.Category = &models.Category{
-				ID:          int(categoryID.Int64),
-				Name:        categoryName.String,
-				Description: categoryDesc.String,
-			}
+		p, err := scanProduct(rows)
+		if err != nil {
+			return nil, err
 		}
-
 		products = append(products, p)
 	}
+	return products, rows.Err()
+}
+
+func (r *PostgresProductRepository) GetAll(search string) []models.Product {
+	query := productSelect
+	var args []interface{}
+	if search != "" {
+		query += " WHERE p.name ILIKE $1 OR p.sku = $2 OR p.barcode = $2"
+		args = append(args, "%"+search+"%", search)
+	}
+	query += " ORDER BY p.id"
 
+	products, err := r.queryProducts(query, args...)
+	if err != nil {
+		return []models.Product{}
+	}
 	return products
 }
 
 func (r *PostgresProductRepository) GetByID(id int) (models.Product, bool) {
-	query := `
-		SELECT p.id, p.name, p.price, p.stock, c.id, c.name, c.description
-		FROM products p
-		LEFT JOIN categories c ON p.category_id = c.id
-		WHERE p.id = $1
-	`
+	p, err := scanProduct(r.db.QueryRow(productSelect+" WHERE p.id = $1", id))
+	if err != nil {
+		return models.Product{}, false
+	}
 
-	var p models.Product
-	var categoryID sql.NullInt64
-	var categoryName, categoryDesc sql.NullString
+	if p.Variants, err = r.queryProducts(productSelect+" WHERE p.parent_id = $1 ORDER BY p.id", id); err != nil {
+		return models.Product{}, false
+	}
 
-	err := r.db.QueryRow(query, id).Scan(&p.ID, &p.Name, &p.Price, &p.Stock, &categoryID, &categoryName, &categoryDesc)
+	rows, err := r.db.Query(`
+		SELECT o.id, o.code, COALESCE(os.stock, 0), COALESCE(t.quantity, 0)
+		FROM outlets o
+		LEFT JOIN outlet_stocks os ON os.outlet_id = o.id AND os.product_id = $1
+		LEFT JOIN (`+inTransitSelect+`) t ON t.outlet_id = o.id AND t.product_id = $1
+		WHERE os.product_id IS NOT NULL OR t.product_id IS NOT NULL
+		ORDER BY o.code`, id)
 	if err != nil {
 		return models.Product{}, false
 	}
-
-	if categoryID.Valid {
-		p.Category = &models.Category{
-			ID:          int(categoryID.Int64),
-			Name:        categoryName.String,
-			Description: categoryDesc.String,
+	defer rows.Close()
+	for rows.Next() {
+		s := models.OutletStock{ProductID: id}
+		if err := rows.Scan(&s.OutletID, &s.OutletCode, &s.Stock, &s.InTransit); err != nil {
+			return models.Product{}, false
 		}
+		p.Stocks = append(p.Stocks, s)
+	}
+
+	return p, rows.Err() == nil
+}
+
+func (r *PostgresProductRepository) GetVariants(parentID int) []models.Product {
+	variants, err := r.queryProducts(productSelect+" WHERE p.parent_id = $1 ORDER BY p.id", parentID)
+	if err != nil {
+		return []models.Product{}
 	}
+	return variants
+}
 
+func (r *PostgresProductRepository) GetByCode(code string) (models.Product, bool) {
+	p, err := scanProduct(r.db.QueryRow(productSelect+" WHERE p.sku = $1 OR p.barcode = $1 ORDER BY p.id LIMIT 1", code))
+	if err != nil {
+		return models.Product{}, false
+	}
 	return p, true
 }
 
-func (r *PostgresProductRepository) Create(product models.Product) {
+func (r *PostgresProductRepository) Create(product models.Product, outletID, userID int) error {
 	var categoryID *int
 	if product.Category != nil {
 		categoryID = &product.Category.ID
 	}
 
-	query := `INSERT INTO products (id, name, price, stock, category_id) VALUES ($1, $2, $3, $4, $5)`
-	r.db.Exec(query, product.ID, product.Name, product.Price, product.Stock, categoryID)
+	tx, err := r.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	// The opening stock goes in through the ledger, valued at the cost given
+	query := `INSERT INTO products (id, parent_id, name, variant_name, sku, barcode, price, cost, stock, min_stock, reorder_quantity,
+		category_id, tax_rate_id)
+		VALUES ($1, $2, $3, $4, NULLIF($5, ''), NULLIF($6, ''), $7, $8, 0, $9, $10, $11, $12)`
+	if _, err := tx.Exec(query, product.ID, product.ParentID, product.Name, product.VariantName, product.SKU, product.Barcode,
+		product.Price, product.Cost, product.MinStock, product.ReorderQuantity, categoryID, product.TaxRateID); err != nil {
+		return err
+	}
+	if product.Stock != 0 {
+		movement := models.StockMovement{
+			ProductID:     product.ID,
+			OutletID:      outletID,
+			Delta:         product.Stock,
+			Reason:        models.StockMovementAdjustment,
+			ReferenceType: models.StockReferenceProduct,
+			ReferenceID:   &product.ID,
+			UserID:        optionalID(userID),
+			Note:          "Opening stock",
+		}
+		if err := moveStock(tx, &movement); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
 }
 
-func (r *PostgresProductRepository) Update(id int, product models.Product) bool {
+func (r *PostgresProductRepository) Update(id int, product models.Product, stock *int, outletID, userID int, approval *models.Approval) error {
 	var categoryID *int
 	if product.Category != nil {
 		categoryID = &product.Category.ID
 	}
 
-	query := `UPDATE products SET name = $1, price = $2, stock = $3, category_id = $4, updated_at = CURRENT_TIMESTAMP WHERE id = $5`
-
-	result, err := r.db.Exec(query, product.Name, product.Price, product.Stock, categoryID, id)
+	tx, err := r.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	// Lock the row so the ledger records the change against the outlet's
+	// stock it replaces
+	var outletStock int
+	err = tx.QueryRow(`SELECT COALESCE(s.stock, 0) FROM products p
+		LEFT JOIN outlet_stocks s ON s.product_id = p.id AND s.outlet_id = $2
+		WHERE p.id = $1 FOR UPDATE OF p`, id, outletID).Scan(&outletStock)
+	if err == sql.ErrNoRows {
+		return utils.ErrProductNotFound
+	}
 	if err != nil {
-		return false
+		return err
 	}
 
-	rowsAffected, _ := result.RowsAffected()
-	return rowsAffected > 0
+	query := `UPDATE products SET name = $1, variant_name = $2, sku = NULLIF($3, ''), barcode = NULLIF($4, ''), price = $5,
+		min_stock = $6, reorder_quantity = $7, category_id = $8, tax_rate_id = $9, updated_at = CURRENT_TIMESTAMP WHERE id = $10`
+	if _, err := tx.Exec(query, product.Name, product.VariantName, product.SKU, product.Barcode, product.Price, product.MinStock,
+		product.ReorderQuantity, categoryID, product.TaxRateID, id); err != nil {
+		return err
+	}
+
+	// Variants are named after their parent and share its category and tax rate
+	if _, err := tx.Exec(`UPDATE products SET name = $1 || ' - ' || variant_name, category_id = $2, tax_rate_id = $3,
+		updated_at = CURRENT_TIMESTAMP WHERE parent_id = $4`, product.Name, categoryID, product.TaxRateID, id); err != nil {
+		return err
+	}
+
+	if stock != nil && *stock != outletStock {
+		delta := *stock - outletStock
+		movement := models.StockMovement{
+			ProductID:     id,
+			OutletID:      outletID,
+			Delta:         delta,
+			Reason:        models.StockMovementAdjustment,
+			ReferenceType: models.StockReferenceProduct,
+			ReferenceID:   &id,
+			UserID:        optionalID(userID),
+			Note:          "Product update",
+		}
+		if err := moveStock(tx, &movement); err != nil {
+			return err
+		}
+	}
+
+	if approval != nil {
+		if err := insertApprovals(tx, []models.Approval{*approval}); err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit()
 }
 
-func (r *PostgresProductRepository) Delete(id int) bool {
+func (r *PostgresProductRepository) Delete(id int) error {
 	query := `DELETE FROM products WHERE id = $1`
 
 	result, err := r.db.Exec(query, id)
 	if err != nil {
-		return false
+		return err
 	}
 
 	rowsAffected, _ := result.RowsAffected()
-	return rowsAffected > 0
+	if rowsAffected == 0 {
+		return utils.ErrProductNotFound
+	}
+	return nil
 }
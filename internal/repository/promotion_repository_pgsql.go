@@ -0,0 +1,145 @@
+package repository
+
+import (
+	"database/sql"
+	"kasir-api-go/internal/models"
+	"kasir-api-go/internal/utils"
+)
+
+type PromotionRepository interface {
+	GetAll() ([]models.Promotion, error)
+	GetByID(id int) (models.Promotion, error)
+	Create(promotion models.Promotion) (models.Promotion, error)
+	Update(id int, promotion models.Promotion) (models.Promotion, error)
+	Delete(id int) error
+}
+
+type postgresPromotionRepository struct {
+	db *sql.DB
+}
+
+func NewPostgresPromotionRepository(db *sql.DB) PromotionRepository {
+	return &postgresPromotionRepository{db: db}
+}
+
+const promotionColumns = `id, name, type, scope, value, buy_quantity, free_quantity, min_purchase,
+	product_id, category_id, start_date, end_date, COALESCE(start_time, ''), COALESCE(end_time, ''), active`
+
+type rowScanner interface {
+	Scan(dest ...interface{}) error
+}
+
+// queryer is satisfied by both *sql.DB and *sql.Tx.
+type queryer interface {
+	Query(query string, args ...interface{}) (*sql.Rows, error)
+	QueryRow(query string, args ...interface{}) *sql.Row
+}
+
+func scanPromotion(row rowScanner) (models.Promotion, error) {
+	var p models.Promotion
+	var productID, categoryID sql.NullInt64
+	var startDate, endDate sql.NullTime
+
+	err := row.Scan(&p.ID, &p.Name, &p.Type, &p.Scope, &p.Value, &p.BuyQuantity, &p.FreeQuantity, &p.MinPurchase,
+		&productID, &categoryID, &startDate, &endDate, &p.StartTime, &p.EndTime, &p.Active)
+	if err != nil {
+		return p, err
+	}
+
+	if productID.Valid {
+		id := int(productID.Int64)
+		p.ProductID = &id
+	}
+	if categoryID.Valid {
+		id := int(categoryID.Int64)
+		p.CategoryID = &id
+	}
+	if startDate.Valid {
+		p.StartDate = &startDate.Time
+	}
+	if endDate.Valid {
+		p.EndDate = &endDate.Time
+	}
+
+	return p, nil
+}
+
+func (r *postgresPromotionRepository) GetAll() ([]models.Promotion, error) {
+	rows, err := r.db.Query(`SELECT ` + promotionColumns + ` FROM promotions ORDER BY id`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	promotions := []models.Promotion{}
+	for rows.Next() {
+		p, err := scanPromotion(rows)
+		if err != nil {
+			return nil, err
+		}
+		promotions = append(promotions, p)
+	}
+
+	return promotions, rows.Err()
+}
+
+func (r *postgresPromotionRepository) GetByID(id int) (models.Promotion, error) {
+	p, err := scanPromotion(r.db.QueryRow(`SELECT `+promotionColumns+` FROM promotions WHERE id = $1`, id))
+	if err == sql.ErrNoRows {
+		return p, utils.ErrPromotionNotFound
+	}
+	return p, err
+}
+
+func (r *postgresPromotionRepository) Create(promotion models.Promotion) (models.Promotion, error) {
+	query := `
+		INSERT INTO promotions (name, type, scope, value, buy_quantity, free_quantity, min_purchase,
+			product_id, category_id, start_date, end_date, start_time, end_time, active)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, NULLIF($12, ''), NULLIF($13, ''), $14)
+		RETURNING id`
+
+	err := r.db.QueryRow(query, promotion.Name, promotion.Type, promotion.Scope, promotion.Value,
+		promotion.BuyQuantity, promotion.FreeQuantity, promotion.MinPurchase, promotion.ProductID, promotion.CategoryID,
+		promotion.StartDate, promotion.EndDate, promotion.StartTime, promotion.EndTime, promotion.Active).Scan(&promotion.ID)
+	if err != nil {
+		return models.Promotion{}, err
+	}
+
+	return promotion, nil
+}
+
+func (r *postgresPromotionRepository) Update(id int, promotion models.Promotion) (models.Promotion, error) {
+	query := `
+		UPDATE promotions SET name = $1, type = $2, scope = $3, value = $4, buy_quantity = $5, free_quantity = $6,
+			min_purchase = $7, product_id = $8, category_id = $9, start_date = $10, end_date = $11,
+			start_time = NULLIF($12, ''), end_time = NULLIF($13, ''), active = $14, updated_at = CURRENT_TIMESTAMP
+		WHERE id = $15`
+
+	result, err := r.db.Exec(query, promotion.Name, promotion.Type, promotion.Scope, promotion.Value,
+		promotion.BuyQuantity, promotion.FreeQuantity, promotion.MinPurchase, promotion.ProductID, promotion.CategoryID,
+		promotion.StartDate, promotion.EndDate, promotion.StartTime, promotion.EndTime, promotion.Active, id)
+	if err != nil {
+		return models.Promotion{}, err
+	}
+
+	rowsAffected, _ := result.RowsAffected()
+	if rowsAffected == 0 {
+		return models.Promotion{}, utils.ErrPromotionNotFound
+	}
+
+	promotion.ID = id
+	return promotion, nil
+}
+
+func (r *postgresPromotionRepository) Delete(id int) error {
+	result, err := r.db.Exec(`DELETE FROM promotions WHERE id = $1`, id)
+	if err != nil {
+		return err
+	}
+
+	rowsAffected, _ := result.RowsAffected()
+	if rowsAffected == 0 {
+		return utils.ErrPromotionNotFound
+	}
+	return nil
+}
@@ -0,0 +1,517 @@
+package repository
+
+import (
+	"database/sql"
+	"fmt"
+	"kasir-api-go/internal/models"
+	"kasir-api-go/internal/utils"
+	"sort"
+)
+
+type PurchaseOrderRepository interface {
+	Create(order models.PurchaseOrder, userID int) (models.PurchaseOrder, error)
+	GetAll(status models.PurchaseOrderStatus) ([]models.PurchaseOrder, error)
+	GetOutstanding() ([]models.PurchaseOrder, error)
+	GetByID(id int) (models.PurchaseOrder, error)
+	Receive(id int, req models.ReceiveGoodsRequest, userID int) (models.PurchaseOrder, error)
+	Return(id int, req models.PurchaseReturnRequest, userID int) (models.PurchaseOrder, error)
+	Cancel(id int) (models.PurchaseOrder, error)
+}
+
+type postgresPurchaseOrderRepository struct {
+	db *sql.DB
+}
+
+func NewPostgresPurchaseOrderRepository(db *sql.DB) PurchaseOrderRepository {
+	return &postgresPurchaseOrderRepository{db: db}
+}
+
+// Create places an order, snapshotting the name of each product.
+func (r *postgresPurchaseOrderRepository) Create(order models.PurchaseOrder, userID int) (models.PurchaseOrder, error) {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return models.PurchaseOrder{}, err
+	}
+	defer tx.Rollback()
+
+	var expectedDate *string
+	if order.ExpectedDate != "" {
+		expectedDate = &order.ExpectedDate
+	}
+	var id int
+	err = tx.QueryRow(`INSERT INTO purchase_orders (supplier_id, outlet_id, status, expected_date, note, created_by_id)
+		VALUES ($1, $2, 'open', $3, $4, $5) RETURNING id`,
+		order.SupplierID, order.OutletID, expectedDate, order.Note, optionalID(userID)).Scan(&id)
+	if err != nil {
+		return models.PurchaseOrder{}, err
+	}
+
+	for _, item := range order.Items {
+		result, err := tx.Exec(`
+			INSERT INTO purchase_order_items (purchase_order_id, product_id, product_name, quantity, unit_cost)
+			SELECT $1, id, name, $3, $4 FROM products WHERE id = $2`,
+			id, *item.ProductID, item.Quantity, item.UnitCost)
+		if err != nil {
+			return models.PurchaseOrder{}, err
+		}
+		if n, _ := result.RowsAffected(); n == 0 {
+			return models.PurchaseOrder{}, fmt.Errorf("%w: product %d not found", utils.ErrInvalidPurchaseOrder, *item.ProductID)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return models.PurchaseOrder{}, err
+	}
+	return r.GetByID(id)
+}
+
+const purchaseOrderSelect = `
+	SELECT po.id, po.supplier_id, s.name, po.outlet_id, o.code, po.status, COALESCE(to_char(po.expected_date, 'YYYY-MM-DD'), ''), po.note,
+		COALESCE(u.username, ''), po.created_at, po.updated_at, COALESCE(t.total_cost, 0),
+		CASE WHEN po.status IN ('open', 'partially_received') THEN COALESCE(t.outstanding_value, 0) ELSE 0 END
+	FROM purchase_orders po
+	JOIN suppliers s ON s.id = po.supplier_id
+	JOIN outlets o ON o.id = po.outlet_id
+	LEFT JOIN users u ON u.id = po.created_by_id
+	LEFT JOIN LATERAL (
+		SELECT SUM(i.quantity * i.unit_cost) AS total_cost,
+			SUM(GREATEST(i.quantity - i.received_quantity, 0) * i.unit_cost) FILTER (WHERE i.product_id IS NOT NULL) AS outstanding_value
+		FROM purchase_order_items i
+		WHERE i.purchase_order_id = po.id
+	) t ON TRUE`
+
+func scanPurchaseOrder(row rowScanner) (models.PurchaseOrder, error) {
+	var o models.PurchaseOrder
+	err := row.Scan(&o.ID, &o.SupplierID, &o.SupplierName, &o.OutletID, &o.OutletCode, &o.Status, &o.ExpectedDate, &o.Note,
+		&o.CreatedBy, &o.CreatedAt, &o.UpdatedAt, &o.TotalCost, &o.OutstandingValue)
+	return o, err
+}
+
+func (r *postgresPurchaseOrderRepository) list(where string, args ...interface{}) ([]models.PurchaseOrder, error) {
+	rows, err := r.db.Query(purchaseOrderSelect+where+` ORDER BY po.created_at DESC, po.id DESC`, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	orders := []models.PurchaseOrder{}
+	for rows.Next() {
+		o, err := scanPurchaseOrder(rows)
+		if err != nil {
+			return nil, err
+		}
+		orders = append(orders, o)
+	}
+
+	return orders, rows.Err()
+}
+
+func (r *postgresPurchaseOrderRepository) GetAll(status models.PurchaseOrderStatus) ([]models.PurchaseOrder, error) {
+	if status == "" {
+		return r.list("")
+	}
+	return r.list(` WHERE po.status = $1`, status)
+}
+
+// GetOutstanding returns the orders still waiting for goods, with their
+// lines.
+func (r *postgresPurchaseOrderRepository) GetOutstanding() ([]models.PurchaseOrder, error) {
+	orders, err := r.list(` WHERE po.status IN ('open', 'partially_received')`)
+	if err != nil {
+		return nil, err
+	}
+
+	for i := range orders {
+		orders[i].Items, err = purchaseOrderItems(r.db, orders[i].ID, orders[i].Status)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return orders, nil
+}
+
+// GetByID returns an order with its lines, goods receipts and returns.
+func (r *postgresPurchaseOrderRepository) GetByID(id int) (models.PurchaseOrder, error) {
+	o, err := scanPurchaseOrder(r.db.QueryRow(purchaseOrderSelect+` WHERE po.id = $1`, id))
+	if err == sql.ErrNoRows {
+		return o, utils.ErrPurchaseOrderNotFound
+	}
+	if err != nil {
+		return o, err
+	}
+
+	if o.Items, err = purchaseOrderItems(r.db, id, o.Status); err != nil {
+		return o, err
+	}
+
+	receiptLines, err := purchaseLines(r.db, `
+		SELECT gri.goods_receipt_id, gri.product_id, gri.product_name, gri.quantity, gri.unit_cost,
+			gri.batch_number, COALESCE(to_char(gri.expiry_date, 'YYYY-MM-DD'), '')
+		FROM goods_receipt_items gri
+		JOIN goods_receipts gr ON gr.id = gri.goods_receipt_id
+		WHERE gr.purchase_order_id = $1
+		ORDER BY gri.id`, id)
+	if err != nil {
+		return o, err
+	}
+	rows, err := r.db.Query(`
+		SELECT gr.id, gr.note, COALESCE(u.username, ''), gr.created_at
+		FROM goods_receipts gr
+		LEFT JOIN users u ON u.id = gr.received_by_id
+		WHERE gr.purchase_order_id = $1
+		ORDER BY gr.created_at, gr.id`, id)
+	if err != nil {
+		return o, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		receipt := models.GoodsReceipt{PurchaseOrderID: id}
+		if err := rows.Scan(&receipt.ID, &receipt.Note, &receipt.ReceivedBy, &receipt.CreatedAt); err != nil {
+			return o, err
+		}
+		receipt.Items = receiptLines[receipt.ID]
+		o.Receipts = append(o.Receipts, receipt)
+	}
+	if err := rows.Err(); err != nil {
+		return o, err
+	}
+
+	returnLines, err := purchaseLines(r.db, `
+		SELECT pri.purchase_return_id, pri.product_id, pri.product_name, pri.quantity, pri.unit_cost, '', ''
+		FROM purchase_return_items pri
+		JOIN purchase_returns pr ON pr.id = pri.purchase_return_id
+		WHERE pr.purchase_order_id = $1
+		ORDER BY pri.id`, id)
+	if err != nil {
+		return o, err
+	}
+	returnRows, err := r.db.Query(`
+		SELECT pr.id, pr.reason, COALESCE(u.username, ''), pr.created_at
+		FROM purchase_returns pr
+		LEFT JOIN users u ON u.id = pr.returned_by_id
+		WHERE pr.purchase_order_id = $1
+		ORDER BY pr.created_at, pr.id`, id)
+	if err != nil {
+		return o, err
+	}
+	defer returnRows.Close()
+	for returnRows.Next() {
+		ret := models.PurchaseReturn{PurchaseOrderID: id}
+		if err := returnRows.Scan(&ret.ID, &ret.Reason, &ret.ReturnedBy, &ret.CreatedAt); err != nil {
+			return o, err
+		}
+		ret.Items = returnLines[ret.ID]
+		o.Returns = append(o.Returns, ret)
+	}
+
+	return o, returnRows.Err()
+}
+
+// Receive records a delivery and adds the goods to the stock of the order's
+// outlet through the stock ledger. The order is marked received once every
+// line has arrived.
+func (r *postgresPurchaseOrderRepository) Receive(id int, req models.ReceiveGoodsRequest, userID int) (models.PurchaseOrder, error) {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return models.PurchaseOrder{}, err
+	}
+	defer tx.Rollback()
+
+	status, outletID, err := lockPurchaseOrder(tx, id)
+	if err != nil {
+		return models.PurchaseOrder{}, err
+	}
+	if !status.IsOutstanding() {
+		return models.PurchaseOrder{}, utils.ErrPurchaseOrderClosed
+	}
+
+	items, err := purchaseOrderItems(tx, id, status)
+	if err != nil {
+		return models.PurchaseOrder{}, err
+	}
+
+	var lines []models.PurchaseLine
+	if len(req.Items) == 0 {
+		for _, item := range items {
+			if item.ProductID != nil && item.OutstandingQuantity > 0 {
+				lines = append(lines, models.PurchaseLine{ProductID: item.ProductID, ProductName: item.ProductName,
+					Quantity: item.OutstandingQuantity, UnitCost: item.UnitCost})
+			}
+		}
+		if len(lines) == 0 {
+			return models.PurchaseOrder{}, fmt.Errorf("%w: nothing is outstanding", utils.ErrInvalidGoodsReceipt)
+		}
+	} else {
+		lines, err = matchPurchaseLines(items, req.Items, utils.ErrInvalidGoodsReceipt, func(item models.PurchaseOrderItem) int {
+			return item.OutstandingQuantity
+		}, "outstanding")
+		if err != nil {
+			return models.PurchaseOrder{}, err
+		}
+	}
+
+	var receiptID int
+	err = tx.QueryRow(`INSERT INTO goods_receipts (purchase_order_id, note, received_by_id) VALUES ($1, $2, $3) RETURNING id`,
+		id, req.Note, optionalID(userID)).Scan(&receiptID)
+	if err != nil {
+		return models.PurchaseOrder{}, err
+	}
+
+	for _, line := range lines {
+		var expiryDate *string
+		if line.ExpiryDate != "" {
+			expiryDate = &line.ExpiryDate
+		}
+		_, err := tx.Exec(`INSERT INTO goods_receipt_items (goods_receipt_id, product_id, product_name, quantity, unit_cost, batch_number, expiry_date)
+			VALUES ($1, $2, $3, $4, $5, $6, $7)`, receiptID, *line.ProductID, line.ProductName, line.Quantity, line.UnitCost,
+			line.BatchNumber, expiryDate)
+		if err != nil {
+			return models.PurchaseOrder{}, err
+		}
+		_, err = tx.Exec(`UPDATE purchase_order_items SET received_quantity = received_quantity + $1
+			WHERE purchase_order_id = $2 AND product_id = $3`, line.Quantity, id, *line.ProductID)
+		if err != nil {
+			return models.PurchaseOrder{}, err
+		}
+
+		movement := models.StockMovement{
+			ProductID:     *line.ProductID,
+			OutletID:      outletID,
+			Delta:         line.Quantity,
+			Reason:        models.StockMovementReceipt,
+			ReferenceType: models.StockReferenceGoodsReceipt,
+			ReferenceID:   &receiptID,
+			UserID:        optionalID(userID),
+			Note:          fmt.Sprintf("Purchase order %d", id),
+			UnitCost:      &line.UnitCost,
+			BatchNumber:   line.BatchNumber,
+			ExpiryDate:    line.ExpiryDate,
+		}
+		if err := moveStock(tx, &movement); err != nil {
+			return models.PurchaseOrder{}, err
+		}
+	}
+
+	// Lines of deleted products can no longer arrive and do not keep the
+	// order open
+	_, err = tx.Exec(`
+		UPDATE purchase_orders SET updated_at = CURRENT_TIMESTAMP,
+			status = CASE WHEN EXISTS (
+				SELECT 1 FROM purchase_order_items
+				WHERE purchase_order_id = $1 AND product_id IS NOT NULL AND received_quantity < quantity
+			) THEN 'partially_received' ELSE 'received' END
+		WHERE id = $1`, id)
+	if err != nil {
+		return models.PurchaseOrder{}, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return models.PurchaseOrder{}, err
+	}
+	return r.GetByID(id)
+}
+
+// Return sends received goods back to the supplier and takes them out of
+// the stock of the order's outlet through the stock ledger.
+func (r *postgresPurchaseOrderRepository) Return(id int, req models.PurchaseReturnRequest, userID int) (models.PurchaseOrder, error) {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return models.PurchaseOrder{}, err
+	}
+	defer tx.Rollback()
+
+	status, outletID, err := lockPurchaseOrder(tx, id)
+	if err != nil {
+		return models.PurchaseOrder{}, err
+	}
+
+	items, err := purchaseOrderItems(tx, id, status)
+	if err != nil {
+		return models.PurchaseOrder{}, err
+	}
+	lines, err := matchPurchaseLines(items, req.Items, utils.ErrInvalidPurchaseReturn, func(item models.PurchaseOrderItem) int {
+		return item.ReceivedQuantity - item.ReturnedQuantity
+	}, "returnable")
+	if err != nil {
+		return models.PurchaseOrder{}, err
+	}
+
+	var returnID int
+	err = tx.QueryRow(`INSERT INTO purchase_returns (purchase_order_id, reason, returned_by_id) VALUES ($1, $2, $3) RETURNING id`,
+		id, req.Reason, optionalID(userID)).Scan(&returnID)
+	if err != nil {
+		return models.PurchaseOrder{}, err
+	}
+
+	for _, line := range lines {
+		_, err := tx.Exec(`INSERT INTO purchase_return_items (purchase_return_id, product_id, product_name, quantity, unit_cost)
+			VALUES ($1, $2, $3, $4, $5)`, returnID, *line.ProductID, line.ProductName, line.Quantity, line.UnitCost)
+		if err != nil {
+			return models.PurchaseOrder{}, err
+		}
+		_, err = tx.Exec(`UPDATE purchase_order_items SET returned_quantity = returned_quantity + $1
+			WHERE purchase_order_id = $2 AND product_id = $3`, line.Quantity, id, *line.ProductID)
+		if err != nil {
+			return models.PurchaseOrder{}, err
+		}
+
+		movement := models.StockMovement{
+			ProductID:     *line.ProductID,
+			OutletID:      outletID,
+			Delta:         -line.Quantity,
+			Reason:        models.StockMovementPurchaseReturn,
+			ReferenceType: models.StockReferencePurchaseReturn,
+			ReferenceID:   &returnID,
+			UserID:        optionalID(userID),
+			Note:          fmt.Sprintf("Purchase order %d", id),
+		}
+		if err := moveStock(tx, &movement); err != nil {
+			return models.PurchaseOrder{}, err
+		}
+		if movement.BalanceAfter < 0 {
+			return models.PurchaseOrder{}, fmt.Errorf("%w: only %d of %s in stock",
+				utils.ErrInvalidPurchaseReturn, movement.BalanceAfter+line.Quantity, line.ProductName)
+		}
+	}
+
+	_, err = tx.Exec(`UPDATE purchase_orders SET updated_at = CURRENT_TIMESTAMP WHERE id = $1`, id)
+	if err != nil {
+		return models.PurchaseOrder{}, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return models.PurchaseOrder{}, err
+	}
+	return r.GetByID(id)
+}
+
+// Cancel stops waiting for the rest of an order. Goods already received stay
+// in stock.
+func (r *postgresPurchaseOrderRepository) Cancel(id int) (models.PurchaseOrder, error) {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return models.PurchaseOrder{}, err
+	}
+	defer tx.Rollback()
+
+	status, _, err := lockPurchaseOrder(tx, id)
+	if err != nil {
+		return models.PurchaseOrder{}, err
+	}
+	if !status.IsOutstanding() {
+		return models.PurchaseOrder{}, utils.ErrPurchaseOrderClosed
+	}
+
+	_, err = tx.Exec(`UPDATE purchase_orders SET status = 'cancelled', updated_at = CURRENT_TIMESTAMP WHERE id = $1`, id)
+	if err != nil {
+		return models.PurchaseOrder{}, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return models.PurchaseOrder{}, err
+	}
+	return r.GetByID(id)
+}
+
+// lockPurchaseOrder locks an order for a receipt, a return or a
+// cancellation and returns its status and outlet.
+func lockPurchaseOrder(tx *sql.Tx, id int) (models.PurchaseOrderStatus, int, error) {
+	var status models.PurchaseOrderStatus
+	var outletID int
+	err := tx.QueryRow(`SELECT status, outlet_id FROM purchase_orders WHERE id = $1 FOR UPDATE`, id).Scan(&status, &outletID)
+	if err == sql.ErrNoRows {
+		return status, outletID, utils.ErrPurchaseOrderNotFound
+	}
+	return status, outletID, err
+}
+
+// purchaseOrderItems returns the lines of an order sorted by product id, the
+// order checkout locks products in.
+func purchaseOrderItems(q queryer, id int, status models.PurchaseOrderStatus) ([]models.PurchaseOrderItem, error) {
+	rows, err := q.Query(`
+		SELECT id, product_id, product_name, quantity, unit_cost, received_quantity, returned_quantity
+		FROM purchase_order_items
+		WHERE purchase_order_id = $1
+		ORDER BY product_id NULLS LAST, id`, id)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	items := []models.PurchaseOrderItem{}
+	for rows.Next() {
+		var item models.PurchaseOrderItem
+		var productID sql.NullInt64
+		err := rows.Scan(&item.ID, &productID, &item.ProductName, &item.Quantity, &item.UnitCost,
+			&item.ReceivedQuantity, &item.ReturnedQuantity)
+		if err != nil {
+			return nil, err
+		}
+		item.ProductID = nullableInt(productID)
+		if status.IsOutstanding() && item.ProductID != nil && item.ReceivedQuantity < item.Quantity {
+			item.OutstandingQuantity = item.Quantity - item.ReceivedQuantity
+		}
+		items = append(items, item)
+	}
+
+	return items, rows.Err()
+}
+
+// matchPurchaseLines turns the requested lines of a receipt or a return into
+// lines of the order, checking each against the quantity available for it.
+// The result is sorted by product id.
+func matchPurchaseLines(items []models.PurchaseOrderItem, requested []models.PurchaseLineRequest, invalid error,
+	available func(models.PurchaseOrderItem) int, availableLabel string) ([]models.PurchaseLine, error) {
+	byProduct := make(map[int]models.PurchaseOrderItem, len(items))
+	for _, item := range items {
+		if item.ProductID != nil {
+			byProduct[*item.ProductID] = item
+		}
+	}
+
+	lines := make([]models.PurchaseLine, 0, len(requested))
+	for _, req := range requested {
+		item, ok := byProduct[req.ProductID]
+		if !ok {
+			return nil, fmt.Errorf("%w: product %d is not on this purchase order", invalid, req.ProductID)
+		}
+		if limit := available(item); req.Quantity > limit {
+			return nil, fmt.Errorf("%w: only %d of %s are %s", invalid, limit, item.ProductName, availableLabel)
+		}
+
+		unitCost := item.UnitCost
+		if req.UnitCost != nil {
+			unitCost = *req.UnitCost
+		}
+		lines = append(lines, models.PurchaseLine{ProductID: item.ProductID, ProductName: item.ProductName,
+			Quantity: req.Quantity, UnitCost: unitCost, BatchNumber: req.BatchNumber, ExpiryDate: req.ExpiryDate})
+	}
+
+	sort.Slice(lines, func(i, j int) bool { return *lines[i].ProductID < *lines[j].ProductID })
+	return lines, nil
+}
+
+// purchaseLines returns the lines of goods receipts or purchase returns keyed
+// by document id. The query selects the document id followed by the line.
+func purchaseLines(q queryer, query string, args ...interface{}) (map[int][]models.PurchaseLine, error) {
+	rows, err := q.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	lines := map[int][]models.PurchaseLine{}
+	for rows.Next() {
+		var documentID int
+		var line models.PurchaseLine
+		var productID sql.NullInt64
+		err := rows.Scan(&documentID, &productID, &line.ProductName, &line.Quantity, &line.UnitCost, &line.BatchNumber, &line.ExpiryDate)
+		if err != nil {
+			return nil, err
+		}
+		line.ProductID = nullableInt(productID)
+		lines[documentID] = append(lines[documentID], line)
+	}
+
+	return lines, rows.Err()
+}
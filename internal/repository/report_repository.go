@@ -6,8 +6,13 @@ import (
 	"time"
 )
 
+// ReportRepository builds the reports. An empty outletCode covers every
+// outlet.
 type ReportRepository interface {
-	GetSalesReport(startDate, endDate time.Time) (models.SalesReport, error)
+	GetSalesReport(startDate, endDate time.Time, outletCode string) (models.SalesReport, error)
+	GetShiftSalesReport(shiftID int) (models.SalesReport, error)
+	GetInventoryValuation(method models.CostingMethod, outletCode string) (models.InventoryValuation, error)
+	GetProfitLines(startDate, endDate time.Time, groupBy models.ProfitGrouping, outletCode string) ([]models.ProfitLine, error)
 }
 
 type postgresReportRepository struct {
@@ -18,35 +23,341 @@ func NewPostgresReportRepository(db *sql.DB) ReportRepository {
 	return &postgresReportRepository{db: db}
 }
 
-func (r *postgresReportRepository) GetSalesReport(startDate, endDate time.Time) (models.SalesReport, error) {
+func (r *postgresReportRepository) GetSalesReport(startDate, endDate time.Time, outletCode string) (models.SalesReport, error) {
+	return salesReport(r.db, "t.created_at >= $1 AND t.created_at < $2 AND ($3 = '' OR t.outlet_code = $3)",
+		"r.created_at >= $1 AND r.created_at < $2 AND ($3 = '' OR t.outlet_code = $3)", startDate, endDate, outletCode)
+}
+
+func (r *postgresReportRepository) GetShiftSalesReport(shiftID int) (models.SalesReport, error) {
+	return salesReport(r.db, "t.shift_id = $1", "r.shift_id = $1", shiftID)
+}
+
+// GetInventoryValuation values the stock on hand of every product. Under
+// FIFO the stock is valued at the cost layers it is made of. The layers are
+// kept across outlets, so the stock of one outlet is valued at its share of
+// the value of the product. Variants are listed under their parent product.
+func (r *postgresReportRepository) GetInventoryValuation(method models.CostingMethod, outletCode string) (models.InventoryValuation, error) {
+	rows, err := r.db.Query(`
+		SELECT p.id, p.parent_id, p.name, p.stock, CASE WHEN $1 = '' THEN p.stock ELSE COALESCE(os.stock, 0) END,
+			p.cost, COALESCE(l.quantity, 0), COALESCE(l.value, 0)
+		FROM products p
+		LEFT JOIN (
+			SELECT os.product_id, os.stock
+			FROM outlet_stocks os
+			JOIN outlets o ON o.id = os.outlet_id
+			WHERE o.code = $1
+		) os ON os.product_id = p.id
+		LEFT JOIN (
+			SELECT product_id, SUM(remaining_quantity) AS quantity, SUM(remaining_quantity * unit_cost) AS value
+			FROM stock_cost_layers
+			WHERE remaining_quantity > 0
+			GROUP BY product_id
+		) l ON l.product_id = p.id
+		ORDER BY p.name, p.id`, outletCode)
+	if err != nil {
+		return models.InventoryValuation{}, err
+	}
+	defer rows.Close()
+
+	valuation := models.InventoryValuation{Method: method, Outlet: outletCode, Products: []models.ProductValuation{}}
+	parents := make(map[int]int) // product id to the index of its valuation
+	variants := make(map[int][]models.ProductValuation)
+	for rows.Next() {
+		var p models.ProductValuation
+		var parentID sql.NullInt64
+		var totalStock, layerQuantity, layerValue int
+		err := rows.Scan(&p.ProductID, &parentID, &p.ProductName, &totalStock, &p.Stock, &p.AverageCost, &layerQuantity, &layerValue)
+		if err != nil {
+			return valuation, err
+		}
+
+		onHand := totalStock
+		if onHand < 0 {
+			onHand = 0
+		}
+		p.Value = onHand * p.AverageCost
+		if method == models.CostingFIFO {
+			p.Value = layerValue
+			if onHand > layerQuantity {
+				p.Value += (onHand - layerQuantity) * p.AverageCost
+			}
+		}
+
+		if outletCode != "" {
+			switch {
+			case p.Stock <= 0:
+				p.Value = 0
+			case p.Stock < onHand:
+				p.Value = p.Value * p.Stock / onHand
+			case p.Stock > onHand:
+				p.Value = p.Stock * p.AverageCost
+			}
+		}
+
+		valuation.TotalValue += p.Value
+		if parentID.Valid {
+			variants[int(parentID.Int64)] = append(variants[int(parentID.Int64)], p)
+			continue
+		}
+		parents[p.ProductID] = len(valuation.Products)
+		valuation.Products = append(valuation.Products, p)
+	}
+	if err := rows.Err(); err != nil {
+		return valuation, err
+	}
+
+	// The stock of a product with variants is the stock of its variants
+	for parentID, vs := range variants {
+		p := &valuation.Products[parents[parentID]]
+		for _, v := range vs {
+			p.Stock += v.Stock
+			p.Value += v.Value
+		}
+		if p.Stock > 0 {
+			p.AverageCost = p.Value / p.Stock
+		}
+		p.Variants = vs
+	}
+	return valuation, nil
+}
+
+// profitGroupings holds the id, name and grouping columns of each breakdown
+// of the profit report. Products and categories are named as they are now;
+// lines of deleted products keep the name they were sold under. Variants
+// count towards their parent product unless grouped by variant.
+var profitGroupings = map[models.ProfitGrouping]struct{ id, name, groupBy string }{
+	"": {"NULL::int", "''", ""},
+	models.ProfitByProduct: {"COALESCE(td.parent_product_id, td.product_id)",
+		"COALESCE(pp.name, NULLIF(td.parent_product_name, ''), p.name, td.product_name)", "GROUP BY 1, 2 ORDER BY 2, 1"},
+	models.ProfitByVariant:  {"td.product_id", "COALESCE(p.name, td.product_name)", "GROUP BY 1, 2 ORDER BY 2, 1"},
+	models.ProfitByCategory: {"c.id", "COALESCE(c.name, 'Uncategorized')", "GROUP BY 1, 2 ORDER BY 2, 1"},
+	models.ProfitByDay:      {"NULL::int", "to_char(l.booked_at, 'YYYY-MM-DD')", "GROUP BY 1, 2 ORDER BY 2"},
+}
+
+// GetProfitLines returns the revenue and cost of goods sold of the sales
+// made between startDate and endDate less the refunds made in that period,
+// one line per group or a single line without a grouping. Refunds count on
+// the day they are made, like in the sales report, so the figures of a
+// closed day do not change.
+func (r *postgresReportRepository) GetProfitLines(startDate, endDate time.Time, groupBy models.ProfitGrouping,
+	outletCode string) ([]models.ProfitLine, error) {
+	g := profitGroupings[groupBy]
+	rows, err := r.db.Query(`
+		SELECT `+g.id+`, `+g.name+`, COALESCE(SUM(l.revenue), 0), COALESCE(SUM(l.cogs), 0)
+		FROM (
+			SELECT td.id AS detail_id, t.created_at AS booked_at, td.subtotal AS revenue, td.cost_amount AS cogs
+			FROM transaction_details td
+			JOIN transactions t ON t.id = td.transaction_id
+			WHERE t.created_at >= $1 AND t.created_at < $2 AND ($3 = '' OR t.outlet_code = $3) AND t.status <> 'voided'
+			UNION ALL
+			SELECT td.id, r.created_at, -(td.subtotal * ri.quantity / td.quantity), -ri.cost_amount
+			FROM transaction_refund_items ri
+			JOIN transaction_refunds r ON r.id = ri.refund_id
+			JOIN transaction_details td ON td.id = ri.transaction_detail_id
+			JOIN transactions t ON t.id = r.transaction_id
+			WHERE r.created_at >= $1 AND r.created_at < $2 AND ($3 = '' OR t.outlet_code = $3)
+				AND r.type = 'refund' AND t.status <> 'voided' AND td.quantity > 0
+		) l
+		JOIN transaction_details td ON td.id = l.detail_id
+		LEFT JOIN products p ON p.id = td.product_id
+		LEFT JOIN products pp ON pp.id = td.parent_product_id
+		LEFT JOIN categories c ON c.id = p.category_id
+		`+g.groupBy, startDate, endDate, outletCode)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	lines := []models.ProfitLine{}
+	for rows.Next() {
+		var line models.ProfitLine
+		var id sql.NullInt64
+		if err := rows.Scan(&id, &line.Name, &line.Revenue, &line.COGS); err != nil {
+			return nil, err
+		}
+		line.ID = nullableInt(id)
+		line.GrossProfit = line.Revenue - line.COGS
+		line.Margin = models.MarginPercent(line.GrossProfit, line.Revenue)
+		lines = append(lines, line)
+	}
+
+	return lines, rows.Err()
+}
+
+// salesReport aggregates the transactions matching salesFilter, a condition
+// on the transactions table aliased as t, and the refunds matching
+// refundFilter, a condition on the transaction_refunds table aliased as r and
+// the transactions they refund. Refunds count on the day they are made, not
+// on the day of the sale; voided transactions are left out altogether, and a
+// sale can only be voided on the day it was made.
+func salesReport(q queryer, salesFilter, refundFilter string, args ...interface{}) (models.SalesReport, error) {
 	var report models.SalesReport
 
-	// 1. Get total revenue and transactions
+	// 1. Get gross revenue and transactions, excluding voids, and the refunds
+	// made in the period
 	query := `
-		SELECT COALESCE(SUM(total_amount), 0), COUNT(id)
-		FROM transactions
-		WHERE created_at >= $1 AND created_at < $2`
+		SELECT COALESCE(SUM(t.gross_amount), 0), COALESCE(SUM(t.discount_amount), 0), COALESCE(SUM(t.total_amount), 0), COUNT(t.id),
+			(SELECT COALESCE(SUM(r.amount), 0)
+				FROM transaction_refunds r
+				JOIN transactions t ON r.transaction_id = t.id
+				WHERE ` + refundFilter + ` AND r.type = 'refund' AND t.status <> 'voided')
+		FROM transactions t
+		WHERE ` + salesFilter + ` AND t.status <> 'voided'`
 
-	err := r.db.QueryRow(query, startDate, endDate).Scan(&report.TotalRevenue, &report.TotalTransactions)
+	var salesAmount int
+	err := q.QueryRow(query, args...).Scan(&report.GrossRevenue, &report.TotalDiscount, &salesAmount,
+		&report.TotalTransactions, &report.TotalRefund)
 	if err != nil {
 		return report, err
 	}
+	report.TotalRevenue = salesAmount - report.TotalRefund
 
 	// 2. Get best selling product
 	bestSellingQuery := `
-		SELECT p.name, COALESCE(SUM(td.quantity), 0) as total_qty
-		FROM transaction_details td
-		JOIN products p ON td.product_id = p.id
-		JOIN transactions t ON td.transaction_id = t.id
-		WHERE t.created_at >= $1 AND t.created_at < $2
-		GROUP BY p.name
+		SELECT name, COALESCE(SUM(quantity), 0) as total_qty
+		FROM (` + lineMovements(salesFilter, refundFilter) + `) l
+		GROUP BY name
 		ORDER BY total_qty DESC
 		LIMIT 1`
 
-	err = r.db.QueryRow(bestSellingQuery, startDate, endDate).Scan(&report.BestSellingProduct.Name, &report.BestSellingProduct.QtySold)
+	err = q.QueryRow(bestSellingQuery, args...).Scan(&report.BestSellingProduct.Name, &report.BestSellingProduct.QtySold)
 	if err != nil && err != sql.ErrNoRows {
 		return report, err
 	}
 
+	// 3. Get tax and service charge net of the refunds
+	taxQuery := `
+		SELECT tax_name, tax_rate, COALESCE(SUM(subtotal), 0), COALESCE(SUM(tax_amount), 0), COALESCE(SUM(service_charge), 0)
+		FROM (` + lineMovements(salesFilter, refundFilter) + `) l
+		GROUP BY tax_name, tax_rate
+		ORDER BY tax_rate DESC, tax_name`
+
+	rows, err := q.Query(taxQuery, args...)
+	if err != nil {
+		return report, err
+	}
+	defer rows.Close()
+
+	report.TaxSummary = []models.TaxSummary{}
+	for rows.Next() {
+		var summary models.TaxSummary
+		var serviceCharge int
+		if err := rows.Scan(&summary.TaxName, &summary.Rate, &summary.TaxableAmount, &summary.TaxAmount, &serviceCharge); err != nil {
+			return report, err
+		}
+		report.TotalTax += summary.TaxAmount
+		report.TotalServiceCharge += serviceCharge
+		if summary.TaxName != "" {
+			report.TaxSummary = append(report.TaxSummary, summary)
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return report, err
+	}
+
 	return report, nil
 }
+
+// lineMovements selects the lines sold by the transactions matching
+// salesFilter and, negated, the lines given back by the refunds matching
+// refundFilter, each with the product name rolled up to the parent of a
+// variant. Voided transactions and their refunds are left out.
+func lineMovements(salesFilter, refundFilter string) string {
+	return `
+		SELECT COALESCE(NULLIF(td.parent_product_name, ''), td.product_name) AS name, td.tax_name, td.tax_rate,
+			td.quantity, td.subtotal, td.tax_amount, td.service_charge
+		FROM transaction_details td
+		JOIN transactions t ON td.transaction_id = t.id
+		WHERE ` + salesFilter + ` AND t.status <> 'voided' AND td.quantity > 0
+		UNION ALL
+		SELECT COALESCE(NULLIF(td.parent_product_name, ''), td.product_name), td.tax_name, td.tax_rate,
+			-ri.quantity, -(td.subtotal * ri.quantity / td.quantity), -(td.tax_amount * ri.quantity / td.quantity),
+			-(td.service_charge * ri.quantity / td.quantity)
+		FROM transaction_refund_items ri
+		JOIN transaction_refunds r ON ri.refund_id = r.id
+		JOIN transaction_details td ON ri.transaction_detail_id = td.id
+		JOIN transactions t ON r.transaction_id = t.id
+		WHERE ` + refundFilter + ` AND r.type = 'refund' AND t.status <> 'voided' AND td.quantity > 0`
+}
+
+// zReport builds the end-of-day report of the transactions matching
+// salesFilter and the refunds matching refundFilter, as in salesReport.
+func zReport(q queryer, salesFilter, refundFilter string, args ...interface{}) (models.ZReport, error) {
+	report := models.ZReport{PaymentTotals: []models.PaymentTotal{}, TopProducts: []models.ProductSales{}}
+
+	sales, err := salesReport(q, salesFilter, refundFilter, args...)
+	if err != nil {
+		return report, err
+	}
+	report.Sales = sales
+
+	// 1. Payments taken per method on the sales that were not voided, less
+	// the refunds paid back. A refund is paid back in the same proportions
+	// as the sale was paid.
+	rows, err := q.Query(`
+		SELECT method, COALESCE(SUM(amount), 0)
+		FROM (
+			SELECT p.method, p.amount
+			FROM transaction_payments p
+			JOIN transactions t ON p.transaction_id = t.id
+			WHERE `+salesFilter+` AND t.status <> 'voided'
+			UNION ALL
+			SELECT p.method, -(r.amount * p.amount / t.total_amount)
+			FROM transaction_refunds r
+			JOIN transactions t ON r.transaction_id = t.id
+			JOIN transaction_payments p ON p.transaction_id = t.id
+			WHERE `+refundFilter+` AND r.type = 'refund' AND t.status <> 'voided' AND t.total_amount > 0
+		) m
+		GROUP BY method
+		ORDER BY method`, args...)
+	if err != nil {
+		return report, err
+	}
+	for rows.Next() {
+		var total models.PaymentTotal
+		if err := rows.Scan(&total.Method, &total.Amount); err != nil {
+			rows.Close()
+			return report, err
+		}
+		report.PaymentTotals = append(report.PaymentTotals, total)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return report, err
+	}
+
+	// 2. Voids and refunds
+	err = q.QueryRow(`
+		SELECT COUNT(*) FILTER (WHERE t.status = 'voided'), COALESCE(SUM(t.total_amount) FILTER (WHERE t.status = 'voided'), 0),
+			(SELECT COUNT(*) FROM transaction_refunds r
+				JOIN transactions t ON r.transaction_id = t.id
+				WHERE `+refundFilter+` AND r.type = 'refund' AND t.status <> 'voided')
+		FROM transactions t
+		WHERE `+salesFilter, args...).Scan(&report.VoidCount, &report.VoidAmount, &report.RefundCount)
+	if err != nil {
+		return report, err
+	}
+
+	// 3. Top products on the quantities sold less those refunded
+	rows, err = q.Query(`
+		SELECT name, SUM(quantity) AS total_qty, COALESCE(SUM(subtotal + tax_amount + service_charge), 0)
+		FROM (`+lineMovements(salesFilter, refundFilter)+`) l
+		GROUP BY name
+		HAVING SUM(quantity) > 0
+		ORDER BY total_qty DESC, name
+		LIMIT 10`, args...)
+	if err != nil {
+		return report, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var product models.ProductSales
+		if err := rows.Scan(&product.Name, &product.QtySold, &product.Revenue); err != nil {
+			return report, err
+		}
+		report.TopProducts = append(report.TopProducts, product)
+	}
+
+	return report, rows.Err()
+}
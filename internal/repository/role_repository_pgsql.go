@@ -0,0 +1,131 @@
+package repository
+
+import (
+	"database/sql"
+	"kasir-api-go/internal/models"
+	"kasir-api-go/internal/utils"
+	"strings"
+)
+
+type RoleRepository interface {
+	GetAll() ([]models.Role, error)
+	GetByName(name models.UserRole) (models.Role, error)
+	Create(role models.Role) (models.Role, error)
+	Update(role models.Role) (models.Role, error)
+	Delete(name models.UserRole) error
+}
+
+type postgresRoleRepository struct {
+	db *sql.DB
+}
+
+func NewPostgresRoleRepository(db *sql.DB) RoleRepository {
+	return &postgresRoleRepository{db: db}
+}
+
+func scanRole(row rowScanner) (models.Role, error) {
+	var role models.Role
+	var permissions string
+	if err := row.Scan(&role.Name, &role.Description, &permissions); err != nil {
+		return role, err
+	}
+	role.Permissions = models.GrantedPermissions(role.Name, splitPermissions(permissions))
+	return role, nil
+}
+
+// splitPermissions parses a permissions array read with array_to_string.
+func splitPermissions(s string) []models.Permission {
+	permissions := []models.Permission{}
+	for _, p := range strings.Split(s, ",") {
+		if p != "" {
+			permissions = append(permissions, models.Permission(p))
+		}
+	}
+	return permissions
+}
+
+func permissionStrings(permissions []models.Permission) []string {
+	s := make([]string, len(permissions))
+	for i, p := range permissions {
+		s[i] = string(p)
+	}
+	return s
+}
+
+func (r *postgresRoleRepository) GetAll() ([]models.Role, error) {
+	rows, err := r.db.Query(`SELECT name, description, array_to_string(permissions, ',') FROM roles ORDER BY created_at, name`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	roles := []models.Role{}
+	for rows.Next() {
+		role, err := scanRole(rows)
+		if err != nil {
+			return nil, err
+		}
+		roles = append(roles, role)
+	}
+
+	return roles, rows.Err()
+}
+
+func (r *postgresRoleRepository) GetByName(name models.UserRole) (models.Role, error) {
+	role, err := scanRole(r.db.QueryRow(`SELECT name, description, array_to_string(permissions, ',') FROM roles WHERE name = $1`, name))
+	if err == sql.ErrNoRows {
+		return role, utils.ErrRoleNotFound
+	}
+	return role, err
+}
+
+func (r *postgresRoleRepository) Create(role models.Role) (models.Role, error) {
+	query := `
+		INSERT INTO roles (name, description, permissions) VALUES ($1, $2, $3)
+		ON CONFLICT (name) DO NOTHING`
+
+	result, err := r.db.Exec(query, role.Name, role.Description, permissionStrings(role.Permissions))
+	if err != nil {
+		return models.Role{}, err
+	}
+
+	rowsAffected, _ := result.RowsAffected()
+	if rowsAffected == 0 {
+		return models.Role{}, utils.ErrRoleExists
+	}
+	return role, nil
+}
+
+func (r *postgresRoleRepository) Update(role models.Role) (models.Role, error) {
+	query := `UPDATE roles SET description = $1, permissions = $2, updated_at = CURRENT_TIMESTAMP WHERE name = $3`
+
+	result, err := r.db.Exec(query, role.Description, permissionStrings(role.Permissions), role.Name)
+	if err != nil {
+		return models.Role{}, err
+	}
+
+	rowsAffected, _ := result.RowsAffected()
+	if rowsAffected == 0 {
+		return models.Role{}, utils.ErrRoleNotFound
+	}
+	return role, nil
+}
+
+// Delete removes a role that no user holds.
+func (r *postgresRoleRepository) Delete(name models.UserRole) error {
+	result, err := r.db.Exec(`
+		DELETE FROM roles WHERE name = $1
+			AND NOT EXISTS (SELECT 1 FROM users WHERE role = $1)`, name)
+	if err != nil {
+		return err
+	}
+
+	rowsAffected, _ := result.RowsAffected()
+	if rowsAffected == 0 {
+		if _, err := r.GetByName(name); err != nil {
+			return err
+		}
+		return utils.ErrRoleInUse
+	}
+	return nil
+}
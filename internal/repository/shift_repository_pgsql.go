@@ -0,0 +1,286 @@
+package repository
+
+import (
+	"database/sql"
+	"kasir-api-go/internal/models"
+	"kasir-api-go/internal/utils"
+)
+
+type ShiftRepository interface {
+	Open(shift models.Shift) (models.Shift, error)
+	GetAll() ([]models.Shift, error)
+	GetByID(id int) (models.Shift, error)
+	GetOpen(outletCode string) (models.Shift, error)
+	AddCashMovement(shiftID, userID int, movement models.CashMovement) (models.CashMovement, error)
+	CashSummary(shiftID int) (models.ShiftSummary, error)
+	Close(id, countedCash int, note string) (models.Shift, error)
+}
+
+type postgresShiftRepository struct {
+	db *sql.DB
+}
+
+func NewPostgresShiftRepository(db *sql.DB) ShiftRepository {
+	return &postgresShiftRepository{db: db}
+}
+
+const shiftColumns = `id, outlet_code, cashier_id, cashier_name, status, opening_float, expected_cash, counted_cash, cash_difference,
+	note, opened_at, closed_at`
+
+func scanShift(row rowScanner) (models.Shift, error) {
+	var s models.Shift
+	var cashierID, expected, counted, difference sql.NullInt64
+	var closedAt sql.NullTime
+
+	err := row.Scan(&s.ID, &s.OutletCode, &cashierID, &s.CashierName, &s.Status, &s.OpeningFloat, &expected, &counted, &difference,
+		&s.Note, &s.OpenedAt, &closedAt)
+	if err != nil {
+		return s, err
+	}
+
+	s.CashierID = nullableInt(cashierID)
+	s.ExpectedCash = nullableInt(expected)
+	s.CountedCash = nullableInt(counted)
+	s.CashDifference = nullableInt(difference)
+	if closedAt.Valid {
+		s.ClosedAt = &closedAt.Time
+	}
+	return s, nil
+}
+
+func nullableInt(v sql.NullInt64) *int {
+	if !v.Valid {
+		return nil
+	}
+	i := int(v.Int64)
+	return &i
+}
+
+// Open starts a shift unless the outlet already has one open.
+func (r *postgresShiftRepository) Open(shift models.Shift) (models.Shift, error) {
+	query := `
+		INSERT INTO shifts (outlet_code, cashier_id, cashier_name, status, opening_float)
+		SELECT $1, $2, $3, 'open', $4
+		WHERE NOT EXISTS (SELECT 1 FROM shifts WHERE outlet_code = $1 AND status = 'open')
+		ON CONFLICT DO NOTHING
+		RETURNING ` + shiftColumns
+
+	opened, err := scanShift(r.db.QueryRow(query, shift.OutletCode, shift.CashierID, shift.CashierName, shift.OpeningFloat))
+	if err == sql.ErrNoRows {
+		return models.Shift{}, utils.ErrShiftAlreadyOpen
+	}
+	return opened, err
+}
+
+func (r *postgresShiftRepository) GetAll() ([]models.Shift, error) {
+	rows, err := r.db.Query(`SELECT ` + shiftColumns + ` FROM shifts ORDER BY opened_at DESC`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	shifts := []models.Shift{}
+	for rows.Next() {
+		s, err := scanShift(rows)
+		if err != nil {
+			return nil, err
+		}
+		shifts = append(shifts, s)
+	}
+
+	return shifts, rows.Err()
+}
+
+func (r *postgresShiftRepository) GetByID(id int) (models.Shift, error) {
+	s, err := scanShift(r.db.QueryRow(`SELECT `+shiftColumns+` FROM shifts WHERE id = $1`, id))
+	if err == sql.ErrNoRows {
+		return s, utils.ErrShiftNotFound
+	}
+	if err != nil {
+		return s, err
+	}
+
+	s.CashMovements, err = r.getCashMovements(s.ID)
+	return s, err
+}
+
+func (r *postgresShiftRepository) GetOpen(outletCode string) (models.Shift, error) {
+	var id int
+	err := r.db.QueryRow(`SELECT id FROM shifts WHERE outlet_code = $1 AND status = 'open'`, outletCode).Scan(&id)
+	if err == sql.ErrNoRows {
+		return models.Shift{}, utils.ErrNoOpenShift
+	}
+	if err != nil {
+		return models.Shift{}, err
+	}
+	return r.GetByID(id)
+}
+
+func (r *postgresShiftRepository) getCashMovements(shiftID int) ([]models.CashMovement, error) {
+	rows, err := r.db.Query(`SELECT m.id, m.shift_id, m.type, m.amount, m.reason, COALESCE(u.username, ''), m.created_at
+		FROM shift_cash_movements m
+		LEFT JOIN users u ON u.id = m.user_id
+		WHERE m.shift_id = $1 ORDER BY m.id`, shiftID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	movements := []models.CashMovement{}
+	for rows.Next() {
+		var m models.CashMovement
+		if err := rows.Scan(&m.ID, &m.ShiftID, &m.Type, &m.Amount, &m.Reason, &m.CreatedBy, &m.CreatedAt); err != nil {
+			return nil, err
+		}
+		movements = append(movements, m)
+	}
+
+	return movements, rows.Err()
+}
+
+// AddCashMovement records cash moved in or out of an open shift's drawer by
+// the user with the given id.
+func (r *postgresShiftRepository) AddCashMovement(shiftID, userID int, movement models.CashMovement) (models.CashMovement, error) {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return models.CashMovement{}, err
+	}
+	defer tx.Rollback()
+
+	if _, err := lockOpenShift(tx, shiftID); err != nil {
+		return models.CashMovement{}, err
+	}
+
+	movement.ShiftID = shiftID
+	err = tx.QueryRow(`
+		INSERT INTO shift_cash_movements (shift_id, type, amount, reason, user_id) VALUES ($1, $2, $3, $4, $5)
+		RETURNING id, COALESCE((SELECT username FROM users WHERE id = $5), ''), created_at`,
+		shiftID, movement.Type, movement.Amount, movement.Reason, optionalID(userID)).Scan(&movement.ID, &movement.CreatedBy, &movement.CreatedAt)
+	if err != nil {
+		return models.CashMovement{}, err
+	}
+
+	return movement, tx.Commit()
+}
+
+func (r *postgresShiftRepository) CashSummary(shiftID int) (models.ShiftSummary, error) {
+	var openingFloat int
+	err := r.db.QueryRow(`SELECT opening_float FROM shifts WHERE id = $1`, shiftID).Scan(&openingFloat)
+	if err == sql.ErrNoRows {
+		return models.ShiftSummary{}, utils.ErrShiftNotFound
+	}
+	if err != nil {
+		return models.ShiftSummary{}, err
+	}
+	return cashSummary(r.db, shiftID, openingFloat)
+}
+
+// Close counts out a shift. The shift row stays locked while the drawer is
+// reconciled, so checkouts still linking to it finish first and no new ones
+// can join.
+func (r *postgresShiftRepository) Close(id, countedCash int, note string) (models.Shift, error) {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return models.Shift{}, err
+	}
+	defer tx.Rollback()
+
+	openingFloat, err := lockOpenShift(tx, id)
+	if err != nil {
+		return models.Shift{}, err
+	}
+
+	summary, err := cashSummary(tx, id, openingFloat)
+	if err != nil {
+		return models.Shift{}, err
+	}
+	summary.Reconcile(&countedCash)
+
+	_, err = tx.Exec(`UPDATE shifts SET status = 'closed', expected_cash = $1, counted_cash = $2, cash_difference = $3,
+		note = $4, closed_at = CURRENT_TIMESTAMP WHERE id = $5`,
+		summary.ExpectedCash, countedCash, *summary.Difference, note, id)
+	if err != nil {
+		return models.Shift{}, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return models.Shift{}, err
+	}
+	return r.GetByID(id)
+}
+
+// lockOpenShift locks a shift for a change to its drawer and returns its
+// opening float.
+func lockOpenShift(tx *sql.Tx, id int) (int, error) {
+	var status models.ShiftStatus
+	var openingFloat int
+	err := tx.QueryRow(`SELECT status, opening_float FROM shifts WHERE id = $1 FOR UPDATE`, id).Scan(&status, &openingFloat)
+	if err == sql.ErrNoRows {
+		return 0, utils.ErrShiftNotFound
+	}
+	if err != nil {
+		return 0, err
+	}
+	if status != models.ShiftStatusOpen {
+		return 0, utils.ErrShiftClosed
+	}
+	return openingFloat, nil
+}
+
+// cashSummary adds up the money that went through the drawer of a shift.
+// Voided sales count as taken and their voids as given back, the same as
+// refunds. A refund gives back cash in proportion to the share of the sale
+// that was paid in cash.
+func cashSummary(q queryer, shiftID, openingFloat int) (models.ShiftSummary, error) {
+	summary := models.ShiftSummary{OpeningFloat: openingFloat, PaymentTotals: []models.PaymentTotal{}}
+
+	rows, err := q.Query(`
+		SELECT p.method, COALESCE(SUM(p.amount), 0)
+		FROM transaction_payments p
+		JOIN transactions t ON p.transaction_id = t.id
+		WHERE t.shift_id = $1
+		GROUP BY p.method
+		ORDER BY p.method`, shiftID)
+	if err != nil {
+		return summary, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var total models.PaymentTotal
+		if err := rows.Scan(&total.Method, &total.Amount); err != nil {
+			return summary, err
+		}
+		if total.Method == models.PaymentMethodCash {
+			summary.CashSales = total.Amount
+		}
+		summary.PaymentTotals = append(summary.PaymentTotals, total)
+	}
+	if err := rows.Err(); err != nil {
+		return summary, err
+	}
+
+	err = q.QueryRow(`
+		SELECT COALESCE(SUM(r.amount * cash.amount / t.total_amount), 0)
+		FROM transaction_refunds r
+		JOIN transactions t ON r.transaction_id = t.id
+		JOIN (
+			SELECT transaction_id, SUM(amount) AS amount FROM transaction_payments
+			WHERE method = 'cash' GROUP BY transaction_id
+		) cash ON cash.transaction_id = t.id
+		WHERE r.shift_id = $1 AND t.total_amount > 0`, shiftID).Scan(&summary.CashRefunds)
+	if err != nil {
+		return summary, err
+	}
+
+	err = q.QueryRow(`
+		SELECT COALESCE(SUM(amount) FILTER (WHERE type = 'cash_in'), 0),
+			COALESCE(SUM(amount) FILTER (WHERE type = 'cash_out'), 0)
+		FROM shift_cash_movements WHERE shift_id = $1`, shiftID).Scan(&summary.CashIn, &summary.CashOut)
+	if err != nil {
+		return summary, err
+	}
+
+	summary.Reconcile(nil)
+	return summary, nil
+}
@@ -0,0 +1,138 @@
+package repository
+
+import (
+	"database/sql"
+	"kasir-api-go/internal/models"
+	"time"
+)
+
+type StockBatchRepository interface {
+	GetByProduct(productID int) ([]models.StockBatch, error)
+	// GetExpiring returns the batches in stock expiring on or before the
+	// given date, soonest first. An empty outletCode covers every outlet.
+	GetExpiring(before time.Time, outletCode string) ([]models.StockBatch, error)
+}
+
+type postgresStockBatchRepository struct {
+	db *sql.DB
+}
+
+func NewPostgresStockBatchRepository(db *sql.DB) StockBatchRepository {
+	return &postgresStockBatchRepository{db: db}
+}
+
+const stockBatchSelect = `
+	SELECT b.id, b.product_id, p.name, b.outlet_id, o.code, b.batch_number, COALESCE(to_char(b.expiry_date, 'YYYY-MM-DD'), ''),
+		b.expiry_date - CURRENT_DATE, b.quantity, b.remaining_quantity, b.created_at
+	FROM stock_batches b
+	JOIN products p ON p.id = b.product_id
+	JOIN outlets o ON o.id = b.outlet_id`
+
+// GetByProduct returns the batches of a product still in stock by outlet, in
+// the order they go out.
+func (r *postgresStockBatchRepository) GetByProduct(productID int) ([]models.StockBatch, error) {
+	return r.list(stockBatchSelect+`
+		WHERE b.product_id = $1 AND b.remaining_quantity > 0
+		ORDER BY o.code, b.expiry_date NULLS LAST, b.id`, productID)
+}
+
+func (r *postgresStockBatchRepository) GetExpiring(before time.Time, outletCode string) ([]models.StockBatch, error) {
+	return r.list(stockBatchSelect+`
+		WHERE b.remaining_quantity > 0 AND b.expiry_date <= $1 AND ($2 = '' OR o.code = $2)
+		ORDER BY b.expiry_date, p.name, o.code, b.id`, before.Format("2006-01-02"), outletCode)
+}
+
+func (r *postgresStockBatchRepository) list(query string, args ...interface{}) ([]models.StockBatch, error) {
+	rows, err := r.db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	batches := []models.StockBatch{}
+	for rows.Next() {
+		var b models.StockBatch
+		var daysToExpiry sql.NullInt64
+		err := rows.Scan(&b.ID, &b.ProductID, &b.ProductName, &b.OutletID, &b.OutletCode, &b.BatchNumber, &b.ExpiryDate,
+			&daysToExpiry, &b.Quantity, &b.RemainingQuantity, &b.ReceivedAt)
+		if err != nil {
+			return nil, err
+		}
+		b.DaysToExpiry = nullableInt(daysToExpiry)
+		batches = append(batches, b)
+	}
+
+	return batches, rows.Err()
+}
+
+// addStockBatch puts goods coming in into a batch of their own at the
+// outlet. Stock that had gone below zero there was sold without a batch, so
+// the goods cover it first.
+func addStockBatch(tx *sql.Tx, m *models.StockMovement) error {
+	remaining := m.Delta
+	if m.BalanceAfter < remaining {
+		remaining = m.BalanceAfter
+	}
+	if remaining <= 0 {
+		return nil
+	}
+
+	var expiryDate *string
+	if m.ExpiryDate != "" {
+		expiryDate = &m.ExpiryDate
+	}
+	_, err := tx.Exec(`INSERT INTO stock_batches (product_id, outlet_id, stock_movement_id, batch_number, expiry_date, quantity, remaining_quantity)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)`, m.ProductID, m.OutletID, m.ID, m.BatchNumber, expiryDate, m.Delta, remaining)
+	return err
+}
+
+// consumeStockBatches takes goods going out of the outlet's batches that
+// expire first and sets m.TakenLots. Batches without an expiry date go after
+// every dated batch, and anything beyond the batches was not in any of them.
+// Like the cost layers, the batches are guarded by the product row moveStock
+// locked.
+func consumeStockBatches(tx *sql.Tx, m *models.StockMovement) error {
+	rows, err := tx.Query(`SELECT id, batch_number, COALESCE(to_char(expiry_date, 'YYYY-MM-DD'), ''), remaining_quantity
+		FROM stock_batches
+		WHERE product_id = $1 AND outlet_id = $2 AND remaining_quantity > 0
+		ORDER BY expiry_date NULLS LAST, id`, m.ProductID, m.OutletID)
+	if err != nil {
+		return err
+	}
+	type batch struct {
+		id                      int
+		batchNumber, expiryDate string
+		remaining               int
+	}
+	var batches []batch
+	for rows.Next() {
+		var b batch
+		if err := rows.Scan(&b.id, &b.batchNumber, &b.expiryDate, &b.remaining); err != nil {
+			rows.Close()
+			return err
+		}
+		batches = append(batches, b)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	remaining := make([]int, len(batches))
+	for i, b := range batches {
+		remaining[i] = b.remaining
+	}
+	taken, short := drawDown(remaining, -m.Delta)
+
+	for i, take := range taken {
+		b := batches[i]
+		if _, err := tx.Exec("UPDATE stock_batches SET remaining_quantity = remaining_quantity - $1 WHERE id = $2", take, b.id); err != nil {
+			return err
+		}
+		m.TakenLots = append(m.TakenLots, models.StockLot{BatchNumber: b.batchNumber, ExpiryDate: b.expiryDate, Quantity: take})
+	}
+	if short > 0 {
+		m.TakenLots = append(m.TakenLots, models.StockLot{Quantity: short})
+	}
+	return nil
+}
@@ -0,0 +1,203 @@
+package repository
+
+import (
+	"database/sql"
+	"kasir-api-go/internal/models"
+)
+
+type StockMovementRepository interface {
+	GetByProduct(productID int) ([]models.StockMovement, error)
+}
+
+type postgresStockMovementRepository struct {
+	db *sql.DB
+}
+
+func NewPostgresStockMovementRepository(db *sql.DB) StockMovementRepository {
+	return &postgresStockMovementRepository{db: db}
+}
+
+// GetByProduct returns the ledger of a product, newest first.
+func (r *postgresStockMovementRepository) GetByProduct(productID int) ([]models.StockMovement, error) {
+	rows, err := r.db.Query(`
+		SELECT m.id, m.product_id, m.product_name, m.outlet_id, o.code, m.delta, m.balance_after, m.reason, m.reference_type, m.reference_id,
+			m.user_id, COALESCE(u.username, ''), m.note, m.created_at
+		FROM stock_movements m
+		JOIN outlets o ON o.id = m.outlet_id
+		LEFT JOIN users u ON u.id = m.user_id
+		WHERE m.product_id = $1
+		ORDER BY m.created_at DESC, m.id DESC`, productID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	movements := []models.StockMovement{}
+	for rows.Next() {
+		var m models.StockMovement
+		var referenceID, userID sql.NullInt64
+		err := rows.Scan(&m.ID, &m.ProductID, &m.ProductName, &m.OutletID, &m.OutletCode, &m.Delta, &m.BalanceAfter, &m.Reason, &m.ReferenceType, &referenceID,
+			&userID, &m.Username, &m.Note, &m.CreatedAt)
+		if err != nil {
+			return nil, err
+		}
+		m.ReferenceID = nullableInt(referenceID)
+		m.UserID = nullableInt(userID)
+		movements = append(movements, m)
+	}
+
+	return movements, rows.Err()
+}
+
+// moveStock changes the stock of a product at outlet m.OutletID by m.Delta
+// and records the movement in the ledger, both inside tx; products.stock
+// keeps the total of all outlets. Goods coming in add a cost layer and a
+// stock batch and update the average cost; goods going out use up the oldest
+// layers and the first-expiring batches of the outlet. Cost is kept across
+// outlets. The product row should already be locked when the caller checked
+// the stock before moving it.
+func moveStock(tx *sql.Tx, m *models.StockMovement) error {
+	var totalAfter, averageCost int
+	err := tx.QueryRow("UPDATE products SET stock = stock + $1 WHERE id = $2 RETURNING stock, cost", m.Delta, m.ProductID).
+		Scan(&totalAfter, &averageCost)
+	if err != nil {
+		return err
+	}
+	err = tx.QueryRow(`
+		INSERT INTO outlet_stocks (outlet_id, product_id, stock) VALUES ($1, $2, $3)
+		ON CONFLICT (outlet_id, product_id) DO UPDATE SET stock = outlet_stocks.stock + EXCLUDED.stock
+		RETURNING stock`, m.OutletID, m.ProductID, m.Delta).Scan(&m.BalanceAfter)
+	if err != nil {
+		return err
+	}
+	if err := insertStockMovement(tx, m); err != nil {
+		return err
+	}
+
+	switch {
+	case m.Delta > 0:
+		if err := addCostLayer(tx, m, averageCost, totalAfter); err != nil {
+			return err
+		}
+		return addStockBatch(tx, m)
+	case m.Delta < 0:
+		if err := consumeCostLayers(tx, m, averageCost); err != nil {
+			return err
+		}
+		return consumeStockBatches(tx, m)
+	}
+	return nil
+}
+
+// addCostLayer records goods coming in at m.UnitCost and folds them into the
+// average cost of the product, given its total stock after the movement.
+// Stock that had gone below zero was sold without a layer, so the receipt
+// covers it first.
+func addCostLayer(tx *sql.Tx, m *models.StockMovement, averageCost, totalAfter int) error {
+	unitCost := averageCost
+	if m.UnitCost != nil {
+		unitCost = *m.UnitCost
+	}
+
+	before := totalAfter - m.Delta
+	if before < 0 {
+		before = 0
+	}
+	if total := before + m.Delta; total > 0 {
+		newCost := (before*averageCost + m.Delta*unitCost + total/2) / total
+		if _, err := tx.Exec("UPDATE products SET cost = $1 WHERE id = $2", newCost, m.ProductID); err != nil {
+			return err
+		}
+	}
+
+	remaining := m.Delta
+	if totalAfter < remaining {
+		remaining = totalAfter
+	}
+	if remaining <= 0 {
+		return nil
+	}
+	_, err := tx.Exec(`INSERT INTO stock_cost_layers (product_id, stock_movement_id, unit_cost, quantity, remaining_quantity)
+		VALUES ($1, $2, $3, $4, $5)`, m.ProductID, m.ID, unitCost, m.Delta, remaining)
+	return err
+}
+
+// consumeCostLayers takes goods going out of the oldest cost layers and sets
+// m.CostAmount. Anything beyond the layers is valued at the average cost.
+// The product row locked by moveStock keeps other movements of the product
+// off the layers meanwhile.
+func consumeCostLayers(tx *sql.Tx, m *models.StockMovement, averageCost int) error {
+	rows, err := tx.Query(`SELECT id, unit_cost, remaining_quantity FROM stock_cost_layers
+		WHERE product_id = $1 AND remaining_quantity > 0 ORDER BY id`, m.ProductID)
+	if err != nil {
+		return err
+	}
+	type layer struct{ id, unitCost, remaining int }
+	var layers []layer
+	for rows.Next() {
+		var l layer
+		if err := rows.Scan(&l.id, &l.unitCost, &l.remaining); err != nil {
+			rows.Close()
+			return err
+		}
+		layers = append(layers, l)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	remaining := make([]int, len(layers))
+	for i, l := range layers {
+		remaining[i] = l.remaining
+	}
+	taken, short := drawDown(remaining, -m.Delta)
+
+	fifoCost := short * averageCost
+	for i, take := range taken {
+		if _, err := tx.Exec("UPDATE stock_cost_layers SET remaining_quantity = remaining_quantity - $1 WHERE id = $2", take, layers[i].id); err != nil {
+			return err
+		}
+		fifoCost += take * layers[i].unitCost
+	}
+
+	m.CostAmount = -m.Delta * averageCost
+	if m.Costing == models.CostingFIFO {
+		m.CostAmount = fifoCost
+	}
+	return nil
+}
+
+// drawDown takes qty out of the remaining quantities in order, first to last,
+// and returns what it took from each of them up to the last one it touched,
+// and the part of qty they could not cover.
+func drawDown(remaining []int, qty int) (taken []int, short int) {
+	for _, available := range remaining {
+		if qty == 0 {
+			break
+		}
+		take := min(available, qty)
+		taken = append(taken, take)
+		qty -= take
+	}
+	return taken, qty
+}
+
+// insertStockMovement writes a movement whose stock change has already been
+// applied, with the product name as it is now.
+func insertStockMovement(tx *sql.Tx, m *models.StockMovement) error {
+	return tx.QueryRow(`
+		INSERT INTO stock_movements (product_id, product_name, outlet_id, delta, balance_after, reason, reference_type, reference_id, user_id, note)
+		SELECT $1, p.name, $2, $3, $4, $5, $6, $7, $8, $9 FROM products p WHERE p.id = $1
+		RETURNING id, product_name, created_at`,
+		m.ProductID, m.OutletID, m.Delta, m.BalanceAfter, m.Reason, m.ReferenceType, m.ReferenceID, m.UserID, m.Note).
+		Scan(&m.ID, &m.ProductName, &m.CreatedAt)
+}
+
+// optionalID turns the zero id of an unknown user or document into NULL.
+func optionalID(id int) *int {
+	if id == 0 {
+		return nil
+	}
+	return &id
+}
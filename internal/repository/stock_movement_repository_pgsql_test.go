@@ -0,0 +1,35 @@
+package repository
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestDrawDown(t *testing.T) {
+	remaining := []int{5, 3, 10}
+
+	tests := []struct {
+		name      string
+		remaining []int
+		qty       int
+		wantTaken []int
+		wantShort int
+	}{
+		{"part of the first", remaining, 4, []int{4}, 0},
+		{"all of the first", remaining, 5, []int{5}, 0},
+		{"first two", remaining, 8, []int{5, 3}, 0},
+		{"into the third", remaining, 12, []int{5, 3, 4}, 0},
+		{"more than there is", remaining, 20, []int{5, 3, 10}, 2},
+		{"nothing to take", remaining, 0, nil, 0},
+		{"nothing there", nil, 3, nil, 3},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			taken, short := drawDown(tt.remaining, tt.qty)
+			if !reflect.DeepEqual(taken, tt.wantTaken) || short != tt.wantShort {
+				t.Errorf("drawDown(%v, %d) = (%v, %d), want (%v, %d)", tt.remaining, tt.qty, taken, short, tt.wantTaken, tt.wantShort)
+			}
+		})
+	}
+}
@@ -0,0 +1,303 @@
+package repository
+
+import (
+	"database/sql"
+	"fmt"
+	"kasir-api-go/internal/models"
+	"kasir-api-go/internal/utils"
+	"sort"
+)
+
+type StockTakeRepository interface {
+	// Open starts a count at the outlet with outletID; an outlet has at most
+	// one open count.
+	Open(note string, outletID, userID int) (models.StockTake, error)
+	GetAll() ([]models.StockTake, error)
+	GetByID(id int) (models.StockTake, error)
+	SubmitCounts(id int, counts []models.StockCount, userID int) error
+	Approve(id, userID int) (models.StockTake, error)
+	Cancel(id, userID int) (models.StockTake, error)
+}
+
+type postgresStockTakeRepository struct {
+	db *sql.DB
+}
+
+func NewPostgresStockTakeRepository(db *sql.DB) StockTakeRepository {
+	return &postgresStockTakeRepository{db: db}
+}
+
+// Open starts a count and snapshots the outlet's stock and the price of
+// every product.
+func (r *postgresStockTakeRepository) Open(note string, outletID, userID int) (models.StockTake, error) {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return models.StockTake{}, err
+	}
+	defer tx.Rollback()
+
+	var id int
+	err = tx.QueryRow(`
+		INSERT INTO stock_takes (status, note, outlet_id, opened_by_id)
+		SELECT 'open', $1, $2, $3
+		WHERE NOT EXISTS (SELECT 1 FROM stock_takes WHERE outlet_id = $2 AND status = 'open')
+		ON CONFLICT DO NOTHING
+		RETURNING id`, note, outletID, optionalID(userID)).Scan(&id)
+	if err == sql.ErrNoRows {
+		return models.StockTake{}, utils.ErrStockTakeAlreadyOpen
+	}
+	if err != nil {
+		return models.StockTake{}, err
+	}
+
+	_, err = tx.Exec(`
+		INSERT INTO stock_take_items (stock_take_id, product_id, product_name, system_quantity, unit_value)
+		SELECT $1, p.id, p.name, COALESCE(os.stock, 0), p.cost
+		FROM products p
+		LEFT JOIN outlet_stocks os ON os.product_id = p.id AND os.outlet_id = $2`, id, outletID)
+	if err != nil {
+		return models.StockTake{}, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return models.StockTake{}, err
+	}
+	return r.GetByID(id)
+}
+
+const stockTakeSelect = `
+	SELECT s.id, s.outlet_id, ol.code, s.status, s.note, COALESCE(o.username, ''), s.opened_at, COALESCE(c.username, ''), s.closed_at
+	FROM stock_takes s
+	JOIN outlets ol ON ol.id = s.outlet_id
+	LEFT JOIN users o ON o.id = s.opened_by_id
+	LEFT JOIN users c ON c.id = s.closed_by_id`
+
+func scanStockTake(row rowScanner) (models.StockTake, error) {
+	var t models.StockTake
+	var closedAt sql.NullTime
+	if err := row.Scan(&t.ID, &t.OutletID, &t.OutletCode, &t.Status, &t.Note, &t.OpenedBy, &t.OpenedAt, &t.ClosedBy, &closedAt); err != nil {
+		return t, err
+	}
+	if closedAt.Valid {
+		t.ClosedAt = &closedAt.Time
+	}
+	return t, nil
+}
+
+func (r *postgresStockTakeRepository) GetAll() ([]models.StockTake, error) {
+	rows, err := r.db.Query(stockTakeSelect + ` ORDER BY s.opened_at DESC, s.id DESC`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	takes := []models.StockTake{}
+	for rows.Next() {
+		t, err := scanStockTake(rows)
+		if err != nil {
+			return nil, err
+		}
+		takes = append(takes, t)
+	}
+
+	return takes, rows.Err()
+}
+
+// GetByID returns a count with its items.
+func (r *postgresStockTakeRepository) GetByID(id int) (models.StockTake, error) {
+	t, err := scanStockTake(r.db.QueryRow(stockTakeSelect+` WHERE s.id = $1`, id))
+	if err == sql.ErrNoRows {
+		return t, utils.ErrStockTakeNotFound
+	}
+	if err != nil {
+		return t, err
+	}
+
+	rows, err := r.db.Query(`
+		SELECT i.product_id, i.product_name, i.system_quantity, i.unit_value, i.counted_quantity,
+			COALESCE(u.username, ''), i.counted_at
+		FROM stock_take_items i
+		LEFT JOIN users u ON u.id = i.counted_by_id
+		WHERE i.stock_take_id = $1
+		ORDER BY i.product_name, i.product_id`, id)
+	if err != nil {
+		return t, err
+	}
+	defer rows.Close()
+
+	t.Items = []models.StockTakeItem{}
+	for rows.Next() {
+		var item models.StockTakeItem
+		var counted sql.NullInt64
+		var countedAt sql.NullTime
+		err := rows.Scan(&item.ProductID, &item.ProductName, &item.SystemQuantity, &item.UnitValue, &counted,
+			&item.CountedBy, &countedAt)
+		if err != nil {
+			return t, err
+		}
+		item.CountedQuantity = nullableInt(counted)
+		if countedAt.Valid {
+			item.CountedAt = &countedAt.Time
+		}
+		t.Items = append(t.Items, item)
+	}
+
+	return t, rows.Err()
+}
+
+// SubmitCounts records a batch of counts. Batches of different counters can
+// be submitted at the same time; approving or cancelling waits for them.
+// Each count takes the outlet's stock of the product again as its system
+// quantity, so sales and other movements made before the goods were counted
+// are not counted twice.
+func (r *postgresStockTakeRepository) SubmitCounts(id int, counts []models.StockCount, userID int) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if err := lockOpenStockTake(tx, id, false); err != nil {
+		return err
+	}
+
+	// The product rows are share-locked in id order, like checkout locks
+	// them, so the stock read waits for sales still being written
+	counts = append([]models.StockCount(nil), counts...)
+	sort.Slice(counts, func(i, j int) bool { return counts[i].ProductID < counts[j].ProductID })
+	for _, count := range counts {
+		if _, err := tx.Exec(`SELECT 1 FROM products WHERE id = $1 FOR SHARE`, count.ProductID); err != nil {
+			return err
+		}
+		result, err := tx.Exec(`
+			UPDATE stock_take_items i SET counted_quantity = $1, counted_by_id = $2, counted_at = CURRENT_TIMESTAMP,
+				system_quantity = COALESCE((SELECT os.stock FROM outlet_stocks os
+					WHERE os.outlet_id = t.outlet_id AND os.product_id = i.product_id), 0)
+			FROM stock_takes t
+			WHERE t.id = i.stock_take_id AND i.stock_take_id = $3 AND i.product_id = $4`,
+			count.CountedQuantity, optionalID(userID), id, count.ProductID)
+		if err != nil {
+			return err
+		}
+		if n, _ := result.RowsAffected(); n == 0 {
+			return fmt.Errorf("%w: product %d is not part of this stock take", utils.ErrInvalidStockCount, count.ProductID)
+		}
+	}
+
+	return tx.Commit()
+}
+
+// Approve adjusts the stock of every counted product by its variance through
+// the stock ledger and closes the count, so the stock becomes the counted
+// quantity plus whatever moved since it was counted. Products that were not
+// counted keep their stock.
+func (r *postgresStockTakeRepository) Approve(id, userID int) (models.StockTake, error) {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return models.StockTake{}, err
+	}
+	defer tx.Rollback()
+
+	if err := lockOpenStockTake(tx, id, true); err != nil {
+		return models.StockTake{}, err
+	}
+	var outletID int
+	if err := tx.QueryRow(`SELECT outlet_id FROM stock_takes WHERE id = $1`, id).Scan(&outletID); err != nil {
+		return models.StockTake{}, err
+	}
+
+	// Product ids come out sorted so the product rows are locked in the same
+	// order as at checkout
+	rows, err := tx.Query(`
+		SELECT product_id, counted_quantity - system_quantity
+		FROM stock_take_items
+		WHERE stock_take_id = $1 AND counted_quantity IS NOT NULL AND counted_quantity <> system_quantity
+		ORDER BY product_id`, id)
+	if err != nil {
+		return models.StockTake{}, err
+	}
+	var movements []models.StockMovement
+	for rows.Next() {
+		m := models.StockMovement{
+			OutletID:      outletID,
+			Reason:        models.StockMovementAdjustment,
+			ReferenceType: models.StockReferenceStockTake,
+			ReferenceID:   &id,
+			UserID:        optionalID(userID),
+			Note:          "Stock take",
+		}
+		if err := rows.Scan(&m.ProductID, &m.Delta); err != nil {
+			rows.Close()
+			return models.StockTake{}, err
+		}
+		movements = append(movements, m)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return models.StockTake{}, err
+	}
+
+	for i := range movements {
+		if err := moveStock(tx, &movements[i]); err != nil {
+			return models.StockTake{}, err
+		}
+	}
+
+	_, err = tx.Exec(`UPDATE stock_takes SET status = 'approved', closed_by_id = $1, closed_at = CURRENT_TIMESTAMP WHERE id = $2`,
+		optionalID(userID), id)
+	if err != nil {
+		return models.StockTake{}, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return models.StockTake{}, err
+	}
+	return r.GetByID(id)
+}
+
+// Cancel closes a count without touching the stock.
+func (r *postgresStockTakeRepository) Cancel(id, userID int) (models.StockTake, error) {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return models.StockTake{}, err
+	}
+	defer tx.Rollback()
+
+	if err := lockOpenStockTake(tx, id, true); err != nil {
+		return models.StockTake{}, err
+	}
+
+	_, err = tx.Exec(`UPDATE stock_takes SET status = 'cancelled', closed_by_id = $1, closed_at = CURRENT_TIMESTAMP WHERE id = $2`,
+		optionalID(userID), id)
+	if err != nil {
+		return models.StockTake{}, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return models.StockTake{}, err
+	}
+	return r.GetByID(id)
+}
+
+// lockOpenStockTake locks a count until the end of tx and fails when it is
+// no longer open. Submitting counts shares the lock; approving and
+// cancelling take it exclusively.
+func lockOpenStockTake(tx *sql.Tx, id int, exclusive bool) error {
+	lock := "FOR SHARE"
+	if exclusive {
+		lock = "FOR UPDATE"
+	}
+
+	var status models.StockTakeStatus
+	err := tx.QueryRow(`SELECT status FROM stock_takes WHERE id = $1 `+lock, id).Scan(&status)
+	if err == sql.ErrNoRows {
+		return utils.ErrStockTakeNotFound
+	}
+	if err != nil {
+		return err
+	}
+	if status != models.StockTakeStatusOpen {
+		return utils.ErrStockTakeNotOpen
+	}
+	return nil
+}
@@ -0,0 +1,542 @@
+package repository
+
+import (
+	"database/sql"
+	"fmt"
+	"kasir-api-go/internal/models"
+	"kasir-api-go/internal/utils"
+	"time"
+)
+
+type StockTransferRepository interface {
+	Create(transfer models.StockTransfer, userID int) (models.StockTransfer, error)
+	// GetAll lists the transfers with status, or all of them when it is
+	// empty, from or to the outlet with outletCode, or any outlet when it is
+	// empty.
+	GetAll(status models.StockTransferStatus, outletCode string) ([]models.StockTransfer, error)
+	GetByID(id int) (models.StockTransfer, error)
+	// Update replaces the outlets, note and lines of a draft.
+	Update(id int, transfer models.StockTransfer) (models.StockTransfer, error)
+	// Send takes the goods out of the origin's stock, valued under costing.
+	Send(id int, costing models.CostingMethod, userID int) (models.StockTransfer, error)
+	// Receive adds the goods received to the destination's stock.
+	Receive(id int, req models.ReceiveStockTransferRequest, userID int) (models.StockTransfer, error)
+	Cancel(id int) (models.StockTransfer, error)
+	// GetDiscrepancies returns the lines received in a different quantity
+	// than was sent between startDate (inclusive) and endDate (exclusive).
+	GetDiscrepancies(startDate, endDate time.Time, outletCode string) ([]models.TransferDiscrepancy, error)
+}
+
+type postgresStockTransferRepository struct {
+	db *sql.DB
+}
+
+func NewPostgresStockTransferRepository(db *sql.DB) StockTransferRepository {
+	return &postgresStockTransferRepository{db: db}
+}
+
+// inTransitSelect sums up the goods sent to each outlet that it has not
+// received yet, by product.
+const inTransitSelect = `
+	SELECT t.to_outlet_id AS outlet_id, i.product_id, SUM(i.quantity) AS quantity
+	FROM stock_transfer_items i
+	JOIN stock_transfers t ON t.id = i.stock_transfer_id
+	WHERE t.status = 'sent'
+	GROUP BY t.to_outlet_id, i.product_id`
+
+// Create drafts a transfer, snapshotting the name of each product.
+func (r *postgresStockTransferRepository) Create(transfer models.StockTransfer, userID int) (models.StockTransfer, error) {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return models.StockTransfer{}, err
+	}
+	defer tx.Rollback()
+
+	var id int
+	err = tx.QueryRow(`INSERT INTO stock_transfers (from_outlet_id, to_outlet_id, status, note, created_by_id)
+		VALUES ($1, $2, 'draft', $3, $4) RETURNING id`,
+		transfer.FromOutletID, transfer.ToOutletID, transfer.Note, optionalID(userID)).Scan(&id)
+	if err != nil {
+		return models.StockTransfer{}, err
+	}
+	if err := insertStockTransferItems(tx, id, transfer.Items); err != nil {
+		return models.StockTransfer{}, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return models.StockTransfer{}, err
+	}
+	return r.GetByID(id)
+}
+
+func insertStockTransferItems(tx *sql.Tx, id int, items []models.StockTransferItem) error {
+	for _, item := range items {
+		result, err := tx.Exec(`
+			INSERT INTO stock_transfer_items (stock_transfer_id, product_id, product_name, quantity)
+			SELECT $1, id, name, $3 FROM products WHERE id = $2`,
+			id, *item.ProductID, item.Quantity)
+		if err != nil {
+			return err
+		}
+		if n, _ := result.RowsAffected(); n == 0 {
+			return fmt.Errorf("%w: product %d not found", utils.ErrInvalidStockTransfer, *item.ProductID)
+		}
+	}
+	return nil
+}
+
+const stockTransferSelect = `
+	SELECT t.id, t.from_outlet_id, fo.code, t.to_outlet_id, tto.code, t.status, t.note, COALESCE(cu.username, ''),
+		t.created_at, t.updated_at, COALESCE(su.username, ''), t.sent_at, COALESCE(ru.username, ''), t.received_at,
+		t.receipt_note, COALESCE(i.item_count, 0), COALESCE(i.quantity, 0), COALESCE(i.discrepancy_count, 0)
+	FROM stock_transfers t
+	JOIN outlets fo ON fo.id = t.from_outlet_id
+	JOIN outlets tto ON tto.id = t.to_outlet_id
+	LEFT JOIN users cu ON cu.id = t.created_by_id
+	LEFT JOIN users su ON su.id = t.sent_by_id
+	LEFT JOIN users ru ON ru.id = t.received_by_id
+	LEFT JOIN LATERAL (
+		SELECT COUNT(*) AS item_count, SUM(quantity) AS quantity,
+			COUNT(*) FILTER (WHERE received_quantity <> quantity) AS discrepancy_count
+		FROM stock_transfer_items
+		WHERE stock_transfer_id = t.id
+	) i ON TRUE`
+
+func scanStockTransfer(row rowScanner) (models.StockTransfer, error) {
+	var t models.StockTransfer
+	var sentAt, receivedAt sql.NullTime
+	err := row.Scan(&t.ID, &t.FromOutletID, &t.FromOutletCode, &t.ToOutletID, &t.ToOutletCode, &t.Status, &t.Note, &t.CreatedBy,
+		&t.CreatedAt, &t.UpdatedAt, &t.SentBy, &sentAt, &t.ReceivedBy, &receivedAt,
+		&t.ReceiptNote, &t.ItemCount, &t.TotalQuantity, &t.DiscrepancyCount)
+	if err != nil {
+		return t, err
+	}
+	if sentAt.Valid {
+		t.SentAt = &sentAt.Time
+	}
+	if receivedAt.Valid {
+		t.ReceivedAt = &receivedAt.Time
+	}
+	return t, nil
+}
+
+func (r *postgresStockTransferRepository) GetAll(status models.StockTransferStatus, outletCode string) ([]models.StockTransfer, error) {
+	rows, err := r.db.Query(stockTransferSelect+`
+		WHERE ($1 = '' OR t.status = $1) AND ($2 = '' OR fo.code = $2 OR tto.code = $2)
+		ORDER BY t.created_at DESC, t.id DESC`, string(status), outletCode)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	transfers := []models.StockTransfer{}
+	for rows.Next() {
+		t, err := scanStockTransfer(rows)
+		if err != nil {
+			return nil, err
+		}
+		transfers = append(transfers, t)
+	}
+
+	return transfers, rows.Err()
+}
+
+// GetByID returns a transfer with its lines and the lots they were sent in.
+func (r *postgresStockTransferRepository) GetByID(id int) (models.StockTransfer, error) {
+	t, err := scanStockTransfer(r.db.QueryRow(stockTransferSelect+` WHERE t.id = $1`, id))
+	if err == sql.ErrNoRows {
+		return t, utils.ErrStockTransferNotFound
+	}
+	if err != nil {
+		return t, err
+	}
+
+	lines, err := stockTransferLines(r.db, id)
+	if err != nil {
+		return t, err
+	}
+	lots, err := stockTransferLots(r.db, id)
+	if err != nil {
+		return t, err
+	}
+
+	t.Items = []models.StockTransferItem{}
+	for _, line := range lines {
+		item := models.StockTransferItem{
+			ProductID:        nullableInt(line.productID),
+			ProductName:      line.productName,
+			Quantity:         line.quantity,
+			UnitCost:         transferUnitCost(line.costAmount, line.quantity),
+			ReceivedQuantity: nullableInt(line.receivedQuantity),
+			Lots:             lots[line.id],
+		}
+		if item.ReceivedQuantity != nil {
+			discrepancy := *item.ReceivedQuantity - item.Quantity
+			value := discrepancy * item.UnitCost
+			item.Discrepancy, item.DiscrepancyValue = &discrepancy, &value
+		}
+		t.Items = append(t.Items, item)
+	}
+	return t, nil
+}
+
+// stockTransferLine is a row of stock_transfer_items.
+type stockTransferLine struct {
+	id               int
+	productID        sql.NullInt64
+	productName      string
+	quantity         int
+	costAmount       int
+	receivedQuantity sql.NullInt64
+}
+
+// stockTransferLines returns the lines of a transfer sorted by product id, so
+// the product rows are locked in the same order as at checkout, with the
+// lines of deleted products last.
+func stockTransferLines(q queryer, id int) ([]stockTransferLine, error) {
+	rows, err := q.Query(`
+		SELECT id, product_id, product_name, quantity, cost_amount, received_quantity
+		FROM stock_transfer_items
+		WHERE stock_transfer_id = $1
+		ORDER BY product_id NULLS LAST, id`, id)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var lines []stockTransferLine
+	for rows.Next() {
+		var l stockTransferLine
+		if err := rows.Scan(&l.id, &l.productID, &l.productName, &l.quantity, &l.costAmount, &l.receivedQuantity); err != nil {
+			return nil, err
+		}
+		lines = append(lines, l)
+	}
+
+	return lines, rows.Err()
+}
+
+// stockTransferLots returns the lots each line of a transfer was sent in, in
+// the order they were taken, by line id.
+func stockTransferLots(q queryer, id int) (map[int][]models.StockLot, error) {
+	rows, err := q.Query(`
+		SELECT l.stock_transfer_item_id, l.batch_number, COALESCE(to_char(l.expiry_date, 'YYYY-MM-DD'), ''), l.quantity
+		FROM stock_transfer_lots l
+		JOIN stock_transfer_items i ON i.id = l.stock_transfer_item_id
+		WHERE i.stock_transfer_id = $1
+		ORDER BY l.id`, id)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	lots := make(map[int][]models.StockLot)
+	for rows.Next() {
+		var itemID int
+		var lot models.StockLot
+		if err := rows.Scan(&itemID, &lot.BatchNumber, &lot.ExpiryDate, &lot.Quantity); err != nil {
+			return nil, err
+		}
+		lots[itemID] = append(lots[itemID], lot)
+	}
+
+	return lots, rows.Err()
+}
+
+// transferUnitCost is what a unit of a line cost when it was sent.
+func transferUnitCost(costAmount, quantity int) int {
+	return (costAmount + quantity/2) / quantity
+}
+
+func (r *postgresStockTransferRepository) Update(id int, transfer models.StockTransfer) (models.StockTransfer, error) {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return models.StockTransfer{}, err
+	}
+	defer tx.Rollback()
+
+	status, _, _, err := lockStockTransfer(tx, id)
+	if err != nil {
+		return models.StockTransfer{}, err
+	}
+	if status != models.StockTransferStatusDraft {
+		return models.StockTransfer{}, utils.ErrStockTransferNotDraft
+	}
+
+	_, err = tx.Exec(`UPDATE stock_transfers SET from_outlet_id = $1, to_outlet_id = $2, note = $3, updated_at = CURRENT_TIMESTAMP
+		WHERE id = $4`, transfer.FromOutletID, transfer.ToOutletID, transfer.Note, id)
+	if err != nil {
+		return models.StockTransfer{}, err
+	}
+	if _, err := tx.Exec(`DELETE FROM stock_transfer_items WHERE stock_transfer_id = $1`, id); err != nil {
+		return models.StockTransfer{}, err
+	}
+	if err := insertStockTransferItems(tx, id, transfer.Items); err != nil {
+		return models.StockTransfer{}, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return models.StockTransfer{}, err
+	}
+	return r.GetByID(id)
+}
+
+// Send takes every line out of the origin's stock through the stock ledger
+// and records the cost and the lots of the goods, which then are in transit.
+// The origin must have enough of each product in stock.
+func (r *postgresStockTransferRepository) Send(id int, costing models.CostingMethod, userID int) (models.StockTransfer, error) {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return models.StockTransfer{}, err
+	}
+	defer tx.Rollback()
+
+	status, fromOutletID, _, err := lockStockTransfer(tx, id)
+	if err != nil {
+		return models.StockTransfer{}, err
+	}
+	if status != models.StockTransferStatusDraft {
+		return models.StockTransfer{}, utils.ErrStockTransferNotDraft
+	}
+
+	lines, err := stockTransferLines(tx, id)
+	if err != nil {
+		return models.StockTransfer{}, err
+	}
+	for _, line := range lines {
+		if !line.productID.Valid {
+			return models.StockTransfer{}, fmt.Errorf("%w: %s has been deleted", utils.ErrInvalidStockTransfer, line.productName)
+		}
+
+		movement := models.StockMovement{
+			ProductID:     int(line.productID.Int64),
+			OutletID:      fromOutletID,
+			Delta:         -line.quantity,
+			Reason:        models.StockMovementTransfer,
+			ReferenceType: models.StockReferenceStockTransfer,
+			ReferenceID:   &id,
+			UserID:        optionalID(userID),
+			Note:          fmt.Sprintf("Stock transfer %d", id),
+			Costing:       costing,
+		}
+		if err := moveStock(tx, &movement); err != nil {
+			return models.StockTransfer{}, err
+		}
+		if movement.BalanceAfter < 0 {
+			return models.StockTransfer{}, fmt.Errorf("%w: only %d of %s in stock",
+				utils.ErrInvalidStockTransfer, movement.BalanceAfter+line.quantity, line.productName)
+		}
+
+		if _, err := tx.Exec(`UPDATE stock_transfer_items SET cost_amount = $1 WHERE id = $2`, movement.CostAmount, line.id); err != nil {
+			return models.StockTransfer{}, err
+		}
+		for _, lot := range movement.TakenLots {
+			var expiryDate *string
+			if lot.ExpiryDate != "" {
+				expiryDate = &lot.ExpiryDate
+			}
+			_, err := tx.Exec(`INSERT INTO stock_transfer_lots (stock_transfer_item_id, batch_number, expiry_date, quantity)
+				VALUES ($1, $2, $3, $4)`, line.id, lot.BatchNumber, expiryDate, lot.Quantity)
+			if err != nil {
+				return models.StockTransfer{}, err
+			}
+		}
+	}
+
+	_, err = tx.Exec(`UPDATE stock_transfers SET status = 'sent', sent_by_id = $1, sent_at = CURRENT_TIMESTAMP, updated_at = CURRENT_TIMESTAMP
+		WHERE id = $2`, optionalID(userID), id)
+	if err != nil {
+		return models.StockTransfer{}, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return models.StockTransfer{}, err
+	}
+	return r.GetByID(id)
+}
+
+// Receive records the quantity received of every line and adds it to the
+// destination's stock through the stock ledger, in the lots it was sent in
+// and at the cost it was sent at. Goods of deleted products are left out of
+// the stock.
+func (r *postgresStockTransferRepository) Receive(id int, req models.ReceiveStockTransferRequest, userID int) (models.StockTransfer, error) {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return models.StockTransfer{}, err
+	}
+	defer tx.Rollback()
+
+	status, _, toOutletID, err := lockStockTransfer(tx, id)
+	if err != nil {
+		return models.StockTransfer{}, err
+	}
+	if status != models.StockTransferStatusSent {
+		return models.StockTransfer{}, utils.ErrStockTransferNotSent
+	}
+
+	lines, err := stockTransferLines(tx, id)
+	if err != nil {
+		return models.StockTransfer{}, err
+	}
+	lots, err := stockTransferLots(tx, id)
+	if err != nil {
+		return models.StockTransfer{}, err
+	}
+
+	onTransfer := make(map[int]bool, len(lines))
+	for _, line := range lines {
+		if line.productID.Valid {
+			onTransfer[int(line.productID.Int64)] = true
+		}
+	}
+	received := make(map[int]int, len(req.Items))
+	for _, item := range req.Items {
+		if !onTransfer[item.ProductID] {
+			return models.StockTransfer{}, fmt.Errorf("%w: product %d is not on this transfer", utils.ErrInvalidTransferReceipt, item.ProductID)
+		}
+		received[item.ProductID] = item.ReceivedQuantity
+	}
+
+	for _, line := range lines {
+		quantity, ok := received[int(line.productID.Int64)]
+		if !ok || !line.productID.Valid {
+			quantity = line.quantity
+		}
+		if _, err := tx.Exec(`UPDATE stock_transfer_items SET received_quantity = $1 WHERE id = $2`, quantity, line.id); err != nil {
+			return models.StockTransfer{}, err
+		}
+		if !line.productID.Valid {
+			continue
+		}
+
+		unitCost := transferUnitCost(line.costAmount, line.quantity)
+		for _, lot := range receivedLots(lots[line.id], quantity) {
+			movement := models.StockMovement{
+				ProductID:     int(line.productID.Int64),
+				OutletID:      toOutletID,
+				Delta:         lot.Quantity,
+				Reason:        models.StockMovementTransfer,
+				ReferenceType: models.StockReferenceStockTransfer,
+				ReferenceID:   &id,
+				UserID:        optionalID(userID),
+				Note:          fmt.Sprintf("Stock transfer %d", id),
+				UnitCost:      &unitCost,
+				BatchNumber:   lot.BatchNumber,
+				ExpiryDate:    lot.ExpiryDate,
+			}
+			if err := moveStock(tx, &movement); err != nil {
+				return models.StockTransfer{}, err
+			}
+		}
+	}
+
+	_, err = tx.Exec(`UPDATE stock_transfers SET status = 'received', received_by_id = $1, received_at = CURRENT_TIMESTAMP,
+		receipt_note = $2, updated_at = CURRENT_TIMESTAMP WHERE id = $3`, optionalID(userID), req.Note, id)
+	if err != nil {
+		return models.StockTransfer{}, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return models.StockTransfer{}, err
+	}
+	return r.GetByID(id)
+}
+
+// receivedLots splits the quantity received of a line over the lots it was
+// sent in, in the order they were taken. A shortage comes off the last lots,
+// and a surplus arrives without a batch.
+func receivedLots(sent []models.StockLot, quantity int) []models.StockLot {
+	var lots []models.StockLot
+	for _, lot := range sent {
+		if quantity == 0 {
+			break
+		}
+		if lot.Quantity > quantity {
+			lot.Quantity = quantity
+		}
+		lots = append(lots, lot)
+		quantity -= lot.Quantity
+	}
+	if quantity > 0 {
+		lots = append(lots, models.StockLot{Quantity: quantity})
+	}
+	return lots
+}
+
+// Cancel drops a draft without touching the stock.
+func (r *postgresStockTransferRepository) Cancel(id int) (models.StockTransfer, error) {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return models.StockTransfer{}, err
+	}
+	defer tx.Rollback()
+
+	status, _, _, err := lockStockTransfer(tx, id)
+	if err != nil {
+		return models.StockTransfer{}, err
+	}
+	if status != models.StockTransferStatusDraft {
+		return models.StockTransfer{}, utils.ErrStockTransferNotDraft
+	}
+
+	_, err = tx.Exec(`UPDATE stock_transfers SET status = 'cancelled', updated_at = CURRENT_TIMESTAMP WHERE id = $1`, id)
+	if err != nil {
+		return models.StockTransfer{}, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return models.StockTransfer{}, err
+	}
+	return r.GetByID(id)
+}
+
+func (r *postgresStockTransferRepository) GetDiscrepancies(startDate, endDate time.Time, outletCode string) ([]models.TransferDiscrepancy, error) {
+	rows, err := r.db.Query(`
+		SELECT t.id, fo.code, tto.code, COALESCE(u.username, ''), t.received_at,
+			i.product_id, i.product_name, i.quantity, i.received_quantity, i.cost_amount
+		FROM stock_transfer_items i
+		JOIN stock_transfers t ON t.id = i.stock_transfer_id
+		JOIN outlets fo ON fo.id = t.from_outlet_id
+		JOIN outlets tto ON tto.id = t.to_outlet_id
+		LEFT JOIN users u ON u.id = t.received_by_id
+		WHERE t.status = 'received' AND i.received_quantity <> i.quantity
+			AND t.received_at >= $1 AND t.received_at < $2
+			AND ($3 = '' OR fo.code = $3 OR tto.code = $3)
+		ORDER BY t.received_at, t.id, i.product_name`, startDate, endDate, outletCode)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	lines := []models.TransferDiscrepancy{}
+	for rows.Next() {
+		var d models.TransferDiscrepancy
+		var productID sql.NullInt64
+		var costAmount int
+		err := rows.Scan(&d.TransferID, &d.FromOutletCode, &d.ToOutletCode, &d.ReceivedBy, &d.ReceivedAt,
+			&productID, &d.ProductName, &d.SentQuantity, &d.ReceivedQuantity, &costAmount)
+		if err != nil {
+			return nil, err
+		}
+		d.ProductID = nullableInt(productID)
+		d.Discrepancy = d.ReceivedQuantity - d.SentQuantity
+		d.UnitCost = transferUnitCost(costAmount, d.SentQuantity)
+		d.DiscrepancyValue = d.Discrepancy * d.UnitCost
+		lines = append(lines, d)
+	}
+
+	return lines, rows.Err()
+}
+
+// lockStockTransfer locks a transfer until the end of tx and returns its
+// status, origin and destination.
+func lockStockTransfer(tx *sql.Tx, id int) (models.StockTransferStatus, int, int, error) {
+	var status models.StockTransferStatus
+	var fromOutletID, toOutletID int
+	err := tx.QueryRow(`SELECT status, from_outlet_id, to_outlet_id FROM stock_transfers WHERE id = $1 FOR UPDATE`, id).
+		Scan(&status, &fromOutletID, &toOutletID)
+	if err == sql.ErrNoRows {
+		return status, fromOutletID, toOutletID, utils.ErrStockTransferNotFound
+	}
+	return status, fromOutletID, toOutletID, err
+}
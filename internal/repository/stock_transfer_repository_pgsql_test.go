@@ -0,0 +1,78 @@
+package repository
+
+import (
+	"kasir-api-go/internal/models"
+	"reflect"
+	"testing"
+)
+
+func TestTransferUnitCost(t *testing.T) {
+	tests := []struct {
+		name       string
+		costAmount int
+		quantity   int
+		want       int
+	}{
+		{"even", 10000, 4, 2500},
+		{"rounds down", 10000, 3, 3333},
+		{"rounds half up", 10001, 2, 5001},
+		{"rounds up", 5, 3, 2},
+		{"free", 0, 5, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := transferUnitCost(tt.costAmount, tt.quantity); got != tt.want {
+				t.Errorf("transferUnitCost(%d, %d) = %d, want %d", tt.costAmount, tt.quantity, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestReceivedLots(t *testing.T) {
+	sent := []models.StockLot{
+		{BatchNumber: "B1", ExpiryDate: "2026-12-01", Quantity: 5},
+		{BatchNumber: "B2", Quantity: 3},
+	}
+
+	tests := []struct {
+		name     string
+		sent     []models.StockLot
+		quantity int
+		want     []models.StockLot
+	}{
+		{"all received", sent, 8, sent},
+		{
+			name:     "shortage comes off the last lot",
+			sent:     sent,
+			quantity: 6,
+			want:     []models.StockLot{{BatchNumber: "B1", ExpiryDate: "2026-12-01", Quantity: 5}, {BatchNumber: "B2", Quantity: 1}},
+		},
+		{
+			name:     "shortage drops the last lot",
+			sent:     sent,
+			quantity: 4,
+			want:     []models.StockLot{{BatchNumber: "B1", ExpiryDate: "2026-12-01", Quantity: 4}},
+		},
+		{
+			name:     "surplus arrives without a batch",
+			sent:     sent,
+			quantity: 10,
+			want: []models.StockLot{
+				{BatchNumber: "B1", ExpiryDate: "2026-12-01", Quantity: 5},
+				{BatchNumber: "B2", Quantity: 3},
+				{Quantity: 2},
+			},
+		},
+		{"nothing received", sent, 0, nil},
+		{"sent without lots", nil, 3, []models.StockLot{{Quantity: 3}}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := receivedLots(tt.sent, tt.quantity); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("receivedLots(%d) = %+v, want %+v", tt.quantity, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,105 @@
+package repository
+
+import (
+	"database/sql"
+	"kasir-api-go/internal/models"
+	"kasir-api-go/internal/utils"
+)
+
+type SupplierRepository interface {
+	GetAll() ([]models.Supplier, error)
+	GetByID(id int) (models.Supplier, error)
+	Create(supplier models.Supplier) (models.Supplier, error)
+	Update(id int, supplier models.Supplier) (models.Supplier, error)
+	Delete(id int) error
+}
+
+type postgresSupplierRepository struct {
+	db *sql.DB
+}
+
+func NewPostgresSupplierRepository(db *sql.DB) SupplierRepository {
+	return &postgresSupplierRepository{db: db}
+}
+
+const supplierColumns = `id, name, contact_name, phone, email, address`
+
+func scanSupplier(row rowScanner) (models.Supplier, error) {
+	var s models.Supplier
+	err := row.Scan(&s.ID, &s.Name, &s.ContactName, &s.Phone, &s.Email, &s.Address)
+	return s, err
+}
+
+func (r *postgresSupplierRepository) GetAll() ([]models.Supplier, error) {
+	rows, err := r.db.Query(`SELECT ` + supplierColumns + ` FROM suppliers ORDER BY name, id`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	suppliers := []models.Supplier{}
+	for rows.Next() {
+		s, err := scanSupplier(rows)
+		if err != nil {
+			return nil, err
+		}
+		suppliers = append(suppliers, s)
+	}
+
+	return suppliers, rows.Err()
+}
+
+func (r *postgresSupplierRepository) GetByID(id int) (models.Supplier, error) {
+	s, err := scanSupplier(r.db.QueryRow(`SELECT `+supplierColumns+` FROM suppliers WHERE id = $1`, id))
+	if err == sql.ErrNoRows {
+		return s, utils.ErrSupplierNotFound
+	}
+	return s, err
+}
+
+func (r *postgresSupplierRepository) Create(supplier models.Supplier) (models.Supplier, error) {
+	query := `INSERT INTO suppliers (name, contact_name, phone, email, address) VALUES ($1, $2, $3, $4, $5) RETURNING id`
+	err := r.db.QueryRow(query, supplier.Name, supplier.ContactName, supplier.Phone, supplier.Email, supplier.Address).
+		Scan(&supplier.ID)
+	if err != nil {
+		return models.Supplier{}, err
+	}
+	return supplier, nil
+}
+
+func (r *postgresSupplierRepository) Update(id int, supplier models.Supplier) (models.Supplier, error) {
+	query := `UPDATE suppliers SET name = $1, contact_name = $2, phone = $3, email = $4, address = $5,
+		updated_at = CURRENT_TIMESTAMP WHERE id = $6`
+
+	result, err := r.db.Exec(query, supplier.Name, supplier.ContactName, supplier.Phone, supplier.Email, supplier.Address, id)
+	if err != nil {
+		return models.Supplier{}, err
+	}
+
+	rowsAffected, _ := result.RowsAffected()
+	if rowsAffected == 0 {
+		return models.Supplier{}, utils.ErrSupplierNotFound
+	}
+
+	supplier.ID = id
+	return supplier, nil
+}
+
+// Delete removes a supplier no purchase order was placed with.
+func (r *postgresSupplierRepository) Delete(id int) error {
+	result, err := r.db.Exec(`
+		DELETE FROM suppliers WHERE id = $1
+			AND NOT EXISTS (SELECT 1 FROM purchase_orders WHERE supplier_id = $1)`, id)
+	if err != nil {
+		return err
+	}
+
+	rowsAffected, _ := result.RowsAffected()
+	if rowsAffected == 0 {
+		if _, err := r.GetByID(id); err != nil {
+			return err
+		}
+		return utils.ErrSupplierInUse
+	}
+	return nil
+}
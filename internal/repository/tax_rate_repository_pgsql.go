@@ -0,0 +1,91 @@
+package repository
+
+import (
+	"database/sql"
+	"kasir-api-go/internal/models"
+	"kasir-api-go/internal/utils"
+)
+
+type TaxRateRepository interface {
+	GetAll() ([]models.TaxRate, error)
+	GetByID(id int) (models.TaxRate, error)
+	Create(taxRate models.TaxRate) (models.TaxRate, error)
+	Update(id int, taxRate models.TaxRate) (models.TaxRate, error)
+	Delete(id int) error
+}
+
+type postgresTaxRateRepository struct {
+	db *sql.DB
+}
+
+func NewPostgresTaxRateRepository(db *sql.DB) TaxRateRepository {
+	return &postgresTaxRateRepository{db: db}
+}
+
+func (r *postgresTaxRateRepository) GetAll() ([]models.TaxRate, error) {
+	rows, err := r.db.Query(`SELECT id, name, rate, inclusive FROM tax_rates ORDER BY id`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	taxRates := []models.TaxRate{}
+	for rows.Next() {
+		var t models.TaxRate
+		if err := rows.Scan(&t.ID, &t.Name, &t.Rate, &t.Inclusive); err != nil {
+			return nil, err
+		}
+		taxRates = append(taxRates, t)
+	}
+
+	return taxRates, rows.Err()
+}
+
+func (r *postgresTaxRateRepository) GetByID(id int) (models.TaxRate, error) {
+	var t models.TaxRate
+	err := r.db.QueryRow(`SELECT id, name, rate, inclusive FROM tax_rates WHERE id = $1`, id).
+		Scan(&t.ID, &t.Name, &t.Rate, &t.Inclusive)
+	if err == sql.ErrNoRows {
+		return t, utils.ErrTaxRateNotFound
+	}
+	return t, err
+}
+
+func (r *postgresTaxRateRepository) Create(taxRate models.TaxRate) (models.TaxRate, error) {
+	query := `INSERT INTO tax_rates (name, rate, inclusive) VALUES ($1, $2, $3) RETURNING id`
+	err := r.db.QueryRow(query, taxRate.Name, taxRate.Rate, taxRate.Inclusive).Scan(&taxRate.ID)
+	if err != nil {
+		return models.TaxRate{}, err
+	}
+	return taxRate, nil
+}
+
+func (r *postgresTaxRateRepository) Update(id int, taxRate models.TaxRate) (models.TaxRate, error) {
+	query := `UPDATE tax_rates SET name = $1, rate = $2, inclusive = $3, updated_at = CURRENT_TIMESTAMP WHERE id = $4`
+
+	result, err := r.db.Exec(query, taxRate.Name, taxRate.Rate, taxRate.Inclusive, id)
+	if err != nil {
+		return models.TaxRate{}, err
+	}
+
+	rowsAffected, _ := result.RowsAffected()
+	if rowsAffected == 0 {
+		return models.TaxRate{}, utils.ErrTaxRateNotFound
+	}
+
+	taxRate.ID = id
+	return taxRate, nil
+}
+
+func (r *postgresTaxRateRepository) Delete(id int) error {
+	result, err := r.db.Exec(`DELETE FROM tax_rates WHERE id = $1`, id)
+	if err != nil {
+		return err
+	}
+
+	rowsAffected, _ := result.RowsAffected()
+	if rowsAffected == 0 {
+		return utils.ErrTaxRateNotFound
+	}
+	return nil
+}
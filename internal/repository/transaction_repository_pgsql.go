@@ -2,16 +2,22 @@ package repository
 
 import (
 	"database/sql"
+	"encoding/json"
 	"fmt"
 	"kasir-api-go/internal/models"
+	"kasir-api-go/internal/utils"
 	"sort"
 	"strings"
+	"time"
 )
 
 type TransactionRepository interface {
-	CreateTransaction(items []models.CheckoutItem) (*models.Transaction, error)
+	CreateTransaction(req models.CheckoutRequest) (*models.Transaction, error)
 	GetAll() ([]models.Transaction, error)
 	GetByID(id int) (models.Transaction, error)
+	GetByInvoiceNumber(outletCode, invoiceNumber string) (models.Transaction, error)
+	VoidTransaction(id int, req models.VoidRequest) (*models.Refund, error)
+	RefundTransaction(id int, req models.RefundRequest) (*models.Refund, error)
 }
 
 type postgresTransactionRepository struct {
@@ -22,15 +28,20 @@ func NewPostgresTransactionRepository(db *sql.DB) TransactionRepository {
 	return &postgresTransactionRepository{db: db}
 }
 
-func (r *postgresTransactionRepository) CreateTransaction(items []models.CheckoutItem) (*models.Transaction, error) {
+func (r *postgresTransactionRepository) CreateTransaction(req models.CheckoutRequest) (*models.Transaction, error) {
+	items := req.Items
 	if len(items) == 0 {
 		return nil, fmt.Errorf("transaction items cannot be empty")
 	}
 
 	// 1. Consolidate duplicate products
 	consolidated := make(map[int]int)
+	priceOverrides := make(map[int]int)
 	for _, item := range items {
 		consolidated[item.ProductID] += item.Quantity
+		if item.UnitPrice != nil {
+			priceOverrides[item.ProductID] = *item.UnitPrice
+		}
 	}
 
 	// 2. Sort Product IDs to prevent deadlocks
@@ -46,18 +57,75 @@ func (r *postgresTransactionRepository) CreateTransaction(items []models.Checkou
 	}
 	defer tx.Rollback()
 
-	totalAmount := 0
+	// Serialize retries sharing an idempotency key and replay the first result
+	if req.IdempotencyKey != "" {
+		replay, err := lockIdempotencyKey(tx, req.Outlet.ID, req.Actor.ID, req.IdempotencyKey, req.RequestHash)
+		if err != nil {
+			return nil, err
+		}
+		if replay != nil {
+			return replay, nil
+		}
+	}
+
+	// No sale can be added to a day that has been closed. The day is taken
+	// from the database clock that dates the transaction.
+	now := time.Now()
+	today, err := businessDay(tx)
+	if err != nil {
+		return nil, err
+	}
+	if err := lockBusinessDay(tx, req.Outlet.Code, today, false); err != nil {
+		return nil, err
+	}
+
+	// Checking out a parked cart locks it so it cannot be edited or checked
+	// out twice
+	if req.CartID != 0 {
+		if err := lockCart(tx, req.CartID, consolidated); err != nil {
+			return nil, err
+		}
+	}
+
+	// 3. Load the promotions running right now
+	promotions, err := activePromotions(tx, now)
+	if err != nil {
+		return nil, err
+	}
+
+	grossAmount := 0
 	details := make([]models.TransactionDetail, 0)
+	discounts := make([]models.AppliedDiscount, 0)
+	discountDetails := make([]int, 0) // index into details, -1 for basket discounts
+	lineTaxes := make([]models.TaxRate, 0)
+	minStocks := make([]int, 0)
 
-	// 3. Process products in sorted order with locking
+	// 4. Process products in sorted order with locking. The stock is taken
+	// out once the transaction exists for the ledger to point at.
 	for _, id := range productIDs {
 		qty := consolidated[id]
-		var productPrice, stock int
-		var productName string
+		var productPrice, stock, minStock, categoryID int
+		var productName, sku, parentName string
+		var parentID sql.NullInt64
+		var hasVariants bool
+		var taxRate models.TaxRate
 
-		// Use FOR UPDATE to lock the row and prevent race conditions
-		err := tx.QueryRow("SELECT name, price, stock FROM products WHERE id = $1 FOR UPDATE", id).
-			Scan(&productName, &productPrice, &stock)
+		// Use FOR UPDATE to lock the row and prevent race conditions. The
+		// stock checked is the one at the outlet selling. The product tax
+		// rate takes precedence over the category one.
+		err := tx.QueryRow(`
+			SELECT p.name, COALESCE(p.sku, ''), p.price, COALESCE(os.stock, 0), p.min_stock, COALESCE(p.category_id, 0),
+				COALESCE(tr.name, ''), COALESCE(tr.rate, 0), COALESCE(tr.inclusive, FALSE),
+				p.parent_id, COALESCE(pp.name, ''), EXISTS (SELECT 1 FROM products v WHERE v.parent_id = p.id)
+			FROM products p
+			LEFT JOIN products pp ON pp.id = p.parent_id
+			LEFT JOIN outlet_stocks os ON os.product_id = p.id AND os.outlet_id = $2
+			LEFT JOIN categories c ON p.category_id = c.id
+			LEFT JOIN tax_rates tr ON tr.id = COALESCE(p.tax_rate_id, c.tax_rate_id)
+			WHERE p.id = $1
+			FOR UPDATE OF p`, id, req.Outlet.ID).
+			Scan(&productName, &sku, &productPrice, &stock, &minStock, &categoryID, &taxRate.Name, &taxRate.Rate, &taxRate.Inclusive,
+				&parentID, &parentName, &hasVariants)
 		if err == sql.ErrNoRows {
 			return nil, fmt.Errorf("product id %d not found", id)
 		}
@@ -65,46 +133,289 @@ func (r *postgresTransactionRepository) CreateTransaction(items []models.Checkou
 			return nil, err
 		}
 
+		// A product with variants is sold as one of them
+		if hasVariants {
+			return nil, fmt.Errorf("%w: %s is sold by its variants", utils.ErrInvalidVariant, productName)
+		}
 		if stock < qty {
 			return nil, fmt.Errorf("insufficient stock for product: %s", productName)
 		}
+		if price, ok := priceOverrides[id]; ok {
+			productPrice = price
+		}
 
-		subtotal := productPrice * qty
-		totalAmount += subtotal
-
-		_, err = tx.Exec("UPDATE products SET stock = stock - $1 WHERE id = $2", qty, id)
-		if err != nil {
-			return nil, err
+		gross := productPrice * qty
+		grossAmount += gross
+		lineDiscount, promotion := models.BestLineDiscount(promotions, id, categoryID, productPrice, qty)
+		if parentID.Valid {
+			// A promotion on the parent product covers its variants
+			parentDiscount, parentPromotion := models.BestLineDiscount(promotions, int(parentID.Int64), categoryID, productPrice, qty)
+			if parentDiscount > lineDiscount {
+				lineDiscount, promotion = parentDiscount, parentPromotion
+			}
+		}
+		if promotion != nil {
+			discounts = append(discounts, appliedDiscount(promotion, lineDiscount))
+			discountDetails = append(discountDetails, len(details))
 		}
 
 		details = append(details, models.TransactionDetail{
-			ProductID:   id,
-			ProductName: productName,
-			Quantity:    qty,
-			Subtotal:    subtotal,
+			ProductID:         id,
+			ProductName:       productName,
+			SKU:               sku,
+			ParentProductID:   nullableInt(parentID),
+			ParentProductName: parentName,
+			UnitPrice:         productPrice,
+			Quantity:          qty,
+			DiscountAmount:    lineDiscount,
+			Subtotal:          gross - lineDiscount,
+			TaxName:           taxRate.Name,
+			TaxRate:           taxRate.Rate,
 		})
+		lineTaxes = append(lineTaxes, taxRate)
+		minStocks = append(minStocks, minStock)
+	}
+
+	// 5. Apply basket promotions on top of the line discounts
+	basketAmount := 0
+	for _, d := range details {
+		basketAmount += d.Subtotal
+	}
+	basketDiscount, promotion := models.BestBasketDiscount(promotions, basketAmount)
+	if promotion != nil {
+		models.AllocateBasketDiscount(details, basketDiscount)
+		discounts = append(discounts, appliedDiscount(promotion, basketDiscount))
+		discountDetails = append(discountDetails, -1)
+	}
+
+	// The manual discount comes off whatever the promotions left
+	if req.ManualDiscount > 0 {
+		if req.ManualDiscount > basketAmount-basketDiscount {
+			return nil, fmt.Errorf("%w: manual discount exceeds the basket amount", utils.ErrInvalidOverride)
+		}
+		models.AllocateBasketDiscount(details, req.ManualDiscount)
+		discounts = append(discounts, models.AppliedDiscount{PromotionName: "Manual discount", Amount: req.ManualDiscount})
+		discountDetails = append(discountDetails, -1)
+	}
+
+	// 6. Split out the tax and add the service charge per line
+	subtotalAmount, taxAmount, serviceCharge, discountAmount := 0, 0, 0, 0
+	for i := range details {
+		d := &details[i]
+		d.Subtotal, d.TaxAmount = lineTaxes[i].Split(d.Subtotal)
+		d.ServiceCharge = models.PercentOf(d.Subtotal, req.ServiceChargeRate)
+
+		subtotalAmount += d.Subtotal
+		taxAmount += d.TaxAmount
+		serviceCharge += d.ServiceCharge
+		discountAmount += d.DiscountAmount
+	}
+	totalAmount := subtotalAmount + taxAmount + serviceCharge
+
+	// 7. Settle the payments against the final total
+	payments, paidAmount, changeAmount, err := settlePayments(req.Payments, totalAmount)
+	if err != nil {
+		return nil, err
+	}
+
+	// 8. Link the sale to the shift on the till. Sharing the lock lets
+	// checkouts run side by side while keeping the shift from closing
+	// under them.
+	shiftID, err := openShiftID(tx, req.Outlet.Code)
+	if err != nil {
+		return nil, err
 	}
 
-	// 4. Insert transaction header
+	// 9. Take the next invoice number of the day. The counter row stays
+	// locked until commit, and a rollback gives the number back, so the
+	// sequence has no gaps.
+	var sequence int
+	err = tx.QueryRow(`
+		INSERT INTO invoice_sequences (outlet_code, business_date, last_number) VALUES ($1, $2, 1)
+		ON CONFLICT (outlet_code, business_date) DO UPDATE SET last_number = invoice_sequences.last_number + 1
+		RETURNING last_number`, req.Outlet.Code, today.Format("2006-01-02")).Scan(&sequence)
+	if err != nil {
+		return nil, err
+	}
+	invoiceNumber := req.InvoiceFormat.Number(req.Outlet.Code, today, sequence)
+
+	// 10. Insert transaction header
 	var transactionID int
-	err = tx.QueryRow("INSERT INTO transactions (total_amount) VALUES ($1) RETURNING id", totalAmount).Scan(&transactionID)
+	var createdAt time.Time
+	err = tx.QueryRow(`INSERT INTO transactions (invoice_number, outlet_code, shift_id, gross_amount, discount_amount, subtotal_amount,
+			tax_amount, service_charge, total_amount, paid_amount, change_amount)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11) RETURNING id, created_at`,
+		invoiceNumber, req.Outlet.Code, shiftID, grossAmount, discountAmount, subtotalAmount, taxAmount, serviceCharge, totalAmount,
+		paidAmount, changeAmount).Scan(&transactionID, &createdAt)
 	if err != nil {
 		return nil, err
 	}
 
-	// 5. Bulk insert transaction details
+	// Take the sold quantities out of stock through the ledger, which also
+	// works out the cost of goods sold of each line and takes the goods from
+	// the first-expiring batches while the products are still locked. A line
+	// taking its product below the minimum stock at the outlet raises a
+	// low-stock event.
+	var lowStock []models.LowStockEvent
+	lots := make([][]models.StockLot, len(details))
+	for i := range details {
+		movement := models.StockMovement{
+			ProductID:     details[i].ProductID,
+			OutletID:      req.Outlet.ID,
+			Delta:         -details[i].Quantity,
+			Reason:        models.StockMovementSale,
+			ReferenceType: models.StockReferenceTransaction,
+			ReferenceID:   &transactionID,
+			UserID:        optionalID(req.Actor.ID),
+			Costing:       req.CostingMethod,
+		}
+		if err := moveStock(tx, &movement); err != nil {
+			return nil, err
+		}
+		details[i].CostAmount = movement.CostAmount
+		lots[i] = movement.TakenLots
+
+		if minStock := minStocks[i]; movement.BalanceAfter < minStock && movement.BalanceAfter+details[i].Quantity >= minStock {
+			event := models.LowStockEvent{
+				ProductID:     details[i].ProductID,
+				ProductName:   details[i].ProductName,
+				OutletID:      req.Outlet.ID,
+				OutletCode:    req.Outlet.Code,
+				Stock:         movement.BalanceAfter,
+				MinStock:      minStock,
+				TransactionID: &transactionID,
+			}
+			if err := insertLowStockEvent(tx, &event); err != nil {
+				return nil, err
+			}
+			lowStock = append(lowStock, event)
+		}
+	}
+
+	// 11. Bulk insert transaction details
 	if len(details) > 0 {
 		valueStrings := make([]string, 0, len(details))
-		valueArgs := make([]interface{}, 0, len(details)*4)
+		const columns = 15
+		valueArgs := make([]interface{}, 0, len(details)*columns)
 		for i, d := range details {
-			pos := i * 4
-			valueStrings = append(valueStrings, fmt.Sprintf("($%d, $%d, $%d, $%d)", pos+1, pos+2, pos+3, pos+4))
-			valueArgs = append(valueArgs, transactionID, d.ProductID, d.Quantity, d.Subtotal)
+			placeholders := make([]string, columns)
+			for j := range placeholders {
+				placeholders[j] = fmt.Sprintf("$%d", i*columns+j+1)
+			}
+			valueStrings = append(valueStrings, "("+strings.Join(placeholders, ", ")+")")
+			valueArgs = append(valueArgs, transactionID, d.ProductID, d.ProductName, d.UnitPrice, d.Quantity, d.DiscountAmount, d.Subtotal,
+				d.TaxName, d.TaxRate, d.TaxAmount, d.ServiceCharge, d.CostAmount, d.ParentProductID, d.ParentProductName, d.SKU)
 		}
-		bulkInsertQuery := fmt.Sprintf("INSERT INTO transaction_details (transaction_id, product_id, quantity, subtotal) VALUES %s",
-			strings.Join(valueStrings, ","))
+		bulkInsertQuery := fmt.Sprintf(`INSERT INTO transaction_details (transaction_id, product_id, product_name, unit_price, quantity, discount_amount, subtotal,
+				tax_name, tax_rate, tax_amount, service_charge, cost_amount, parent_product_id, parent_product_name, sku)
+			VALUES %s RETURNING id`, strings.Join(valueStrings, ","))
 
-		_, err = tx.Exec(bulkInsertQuery, valueArgs...)
+		rows, err := tx.Query(bulkInsertQuery, valueArgs...)
+		if err != nil {
+			return nil, err
+		}
+		for i := 0; rows.Next(); i++ {
+			if err := rows.Scan(&details[i].ID); err != nil {
+				rows.Close()
+				return nil, err
+			}
+			details[i].TransactionID = transactionID
+		}
+		rows.Close()
+		if err := rows.Err(); err != nil {
+			return nil, err
+		}
+	}
+
+	// Record the batches each line was taken from, for refunds to return the
+	// goods to
+	for i := range details {
+		for _, lot := range lots[i] {
+			var expiryDate *string
+			if lot.ExpiryDate != "" {
+				expiryDate = &lot.ExpiryDate
+			}
+			_, err := tx.Exec(`INSERT INTO transaction_detail_lots (transaction_detail_id, batch_number, expiry_date, quantity)
+				VALUES ($1, $2, $3, $4)`, details[i].ID, lot.BatchNumber, expiryDate, lot.Quantity)
+			if err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	// 12. Record the applied discounts
+	for i := range discounts {
+		discounts[i].TransactionID = transactionID
+		if idx := discountDetails[i]; idx >= 0 {
+			discounts[i].TransactionDetailID = &details[idx].ID
+		}
+		d := discounts[i]
+		err = tx.QueryRow(`INSERT INTO transaction_discounts (transaction_id, transaction_detail_id, promotion_id, promotion_name, amount)
+			VALUES ($1, $2, $3, $4, $5) RETURNING id`,
+			d.TransactionID, d.TransactionDetailID, d.PromotionID, d.PromotionName, d.Amount).Scan(&discounts[i].ID)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	// Record who approved the price overrides and the manual discount
+	for i := range req.Approvals {
+		req.Approvals[i].TransactionID = &transactionID
+	}
+	if err := insertApprovals(tx, req.Approvals); err != nil {
+		return nil, err
+	}
+
+	// 13. Insert payment lines
+	for i := range payments {
+		payments[i].TransactionID = transactionID
+		p := payments[i]
+		err = tx.QueryRow("INSERT INTO transaction_payments (transaction_id, method, amount, tendered_amount, reference) VALUES ($1, $2, $3, $4, $5) RETURNING id",
+			p.TransactionID, p.Method, p.Amount, p.TenderedAmount, p.Reference).Scan(&payments[i].ID)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	transaction := &models.Transaction{
+		ID:             transactionID,
+		InvoiceNumber:  invoiceNumber,
+		OutletCode:     req.Outlet.Code,
+		ShiftID:        shiftID,
+		Status:         models.TransactionStatusCompleted,
+		GrossAmount:    grossAmount,
+		DiscountAmount: discountAmount,
+		SubtotalAmount: subtotalAmount,
+		TaxAmount:      taxAmount,
+		ServiceCharge:  serviceCharge,
+		TotalAmount:    totalAmount,
+		PaidAmount:     paidAmount,
+		ChangeAmount:   changeAmount,
+		Details:        details,
+		Payments:       payments,
+		Discounts:      discounts,
+		LowStock:       lowStock,
+		CreatedAt:      createdAt,
+	}
+
+	// 14. Close the cart the items came from
+	if req.CartID != 0 {
+		_, err = tx.Exec("UPDATE carts SET status = 'checked_out', transaction_id = $1, updated_at = CURRENT_TIMESTAMP WHERE id = $2",
+			transactionID, req.CartID)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	// 15. Remember the response for retries with the same idempotency key
+	if req.IdempotencyKey != "" {
+		response, err := json.Marshal(transaction)
+		if err != nil {
+			return nil, err
+		}
+		_, err = tx.Exec(`INSERT INTO idempotency_keys (outlet_id, user_id, key, request_hash, transaction_id, response)
+			VALUES ($1, $2, $3, $4, $5, $6)`,
+			optionalID(req.Outlet.ID), optionalID(req.Actor.ID), req.IdempotencyKey, req.RequestHash, transactionID, string(response))
 		if err != nil {
 			return nil, err
 		}
@@ -114,15 +425,249 @@ func (r *postgresTransactionRepository) CreateTransaction(items []models.Checkou
 		return nil, err
 	}
 
-	return &models.Transaction{
-		ID:          transactionID,
-		TotalAmount: totalAmount,
-		Details:     details,
-	}, nil
+	return transaction, nil
+}
+
+// openShiftID returns the shift open on the outlet's till, if any, and
+// holds a share lock on it until the end of tx.
+func openShiftID(tx *sql.Tx, outletCode string) (*int, error) {
+	var id int
+	err := tx.QueryRow("SELECT id FROM shifts WHERE outlet_code = $1 AND status = 'open' FOR SHARE", outletCode).Scan(&id)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &id, nil
+}
+
+// lockCart locks a cart that is being checked out and makes sure it is still
+// open or held and still holds exactly the items being sold.
+func lockCart(tx *sql.Tx, cartID int, items map[int]int) error {
+	var status models.CartStatus
+	err := tx.QueryRow("SELECT status FROM carts WHERE id = $1 FOR UPDATE", cartID).Scan(&status)
+	if err == sql.ErrNoRows {
+		return utils.ErrCartNotFound
+	}
+	if err != nil {
+		return err
+	}
+	if status != models.CartStatusOpen && status != models.CartStatusHeld {
+		return utils.ErrCartNotOpen
+	}
+
+	rows, err := tx.Query("SELECT product_id, quantity FROM cart_items WHERE cart_id = $1", cartID)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	count := 0
+	for rows.Next() {
+		var productID, quantity int
+		if err := rows.Scan(&productID, &quantity); err != nil {
+			return err
+		}
+		if items[productID] != quantity {
+			return utils.ErrCartChanged
+		}
+		count++
+	}
+	if err := rows.Err(); err != nil {
+		return err
+	}
+	if count != len(items) {
+		return utils.ErrCartChanged
+	}
+	return nil
+}
+
+// lockIdempotencyKey holds a transaction-scoped advisory lock on the key, so a
+// concurrent retry waits for the first checkout to finish. It returns the
+// stored response when the key was already used for the same request. Keys
+// are scoped to the outlet with outletID and the user with userID.
+func lockIdempotencyKey(tx *sql.Tx, outletID, userID int, key, requestHash string) (*models.Transaction, error) {
+	scoped := fmt.Sprintf("%d:%d:%s", outletID, userID, key)
+	if _, err := tx.Exec("SELECT pg_advisory_xact_lock(hashtext($1))", scoped); err != nil {
+		return nil, err
+	}
+
+	var storedHash string
+	var response []byte
+	err := tx.QueryRow(`
+		SELECT request_hash, response FROM idempotency_keys
+		WHERE COALESCE(outlet_id, 0) = $1 AND COALESCE(user_id, 0) = $2 AND key = $3`, outletID, userID, key).
+		Scan(&storedHash, &response)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if storedHash != requestHash {
+		return nil, utils.ErrIdempotencyKeyConflict
+	}
+
+	var transaction models.Transaction
+	if err := json.Unmarshal(response, &transaction); err != nil {
+		return nil, err
+	}
+	return &transaction, nil
+}
+
+// activePromotions loads the promotions that can be applied at t.
+func activePromotions(tx *sql.Tx, t time.Time) ([]models.Promotion, error) {
+	rows, err := tx.Query(`SELECT ` + promotionColumns + ` FROM promotions WHERE active = TRUE ORDER BY id`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var promotions []models.Promotion
+	for rows.Next() {
+		p, err := scanPromotion(rows)
+		if err != nil {
+			return nil, err
+		}
+		if p.IsActiveAt(t) {
+			promotions = append(promotions, p)
+		}
+	}
+
+	return promotions, rows.Err()
+}
+
+func appliedDiscount(promotion *models.Promotion, amount int) models.AppliedDiscount {
+	promotionID := promotion.ID
+	return models.AppliedDiscount{
+		PromotionID:   &promotionID,
+		PromotionName: promotion.Name,
+		Amount:        amount,
+	}
+}
+
+// settlePayments validates the tender lines against the total and works out the
+// change. Non-cash lines are applied at face value and may not exceed the total,
+// so any change is always taken back from the cash lines.
+func settlePayments(lines []models.CheckoutPayment, totalAmount int) ([]models.Payment, int, int, error) {
+	paidAmount, nonCashAmount := 0, 0
+	for _, line := range lines {
+		paidAmount += line.Amount
+		if line.Method != models.PaymentMethodCash {
+			nonCashAmount += line.Amount
+		}
+	}
+
+	if paidAmount < totalAmount {
+		return nil, 0, 0, utils.ErrInsufficientPayment
+	}
+	if nonCashAmount > totalAmount {
+		return nil, 0, 0, utils.ErrNonCashOverpayment
+	}
+
+	changeAmount := paidAmount - totalAmount
+	remainingChange := changeAmount
+	payments := make([]models.Payment, len(lines))
+
+	// Walk backwards so the change comes out of the last cash tendered
+	for i := len(lines) - 1; i >= 0; i-- {
+		line := lines[i]
+		applied := line.Amount
+		if line.Method == models.PaymentMethodCash && remainingChange > 0 {
+			taken := min(applied, remainingChange)
+			applied -= taken
+			remainingChange -= taken
+		}
+		payments[i] = models.Payment{
+			Method:         line.Method,
+			Amount:         applied,
+			TenderedAmount: line.Amount,
+			Reference:      line.Reference,
+		}
+	}
+
+	return payments, paidAmount, changeAmount, nil
+}
+
+func (r *postgresTransactionRepository) getPayments(ids []interface{}) (map[int][]models.Payment, error) {
+	query := `
+		SELECT id, transaction_id, method, amount, tendered_amount, COALESCE(reference, '')
+		FROM transaction_payments
+		WHERE transaction_id IN (`
+
+	for i := range ids {
+		query += fmt.Sprintf("$%d", i+1)
+		if i < len(ids)-1 {
+			query += ","
+		}
+	}
+	query += ") ORDER BY id"
+
+	rows, err := r.db.Query(query, ids...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	payments := make(map[int][]models.Payment)
+	for rows.Next() {
+		var p models.Payment
+		if err := rows.Scan(&p.ID, &p.TransactionID, &p.Method, &p.Amount, &p.TenderedAmount, &p.Reference); err != nil {
+			return nil, err
+		}
+		payments[p.TransactionID] = append(payments[p.TransactionID], p)
+	}
+
+	return payments, rows.Err()
+}
+
+func (r *postgresTransactionRepository) getDiscounts(ids []interface{}) (map[int][]models.AppliedDiscount, error) {
+	query := `
+		SELECT id, transaction_id, transaction_detail_id, promotion_id, promotion_name, amount
+		FROM transaction_discounts
+		WHERE transaction_id IN (`
+
+	for i := range ids {
+		query += fmt.Sprintf("$%d", i+1)
+		if i < len(ids)-1 {
+			query += ","
+		}
+	}
+	query += ") ORDER BY id"
+
+	rows, err := r.db.Query(query, ids...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	discounts := make(map[int][]models.AppliedDiscount)
+	for rows.Next() {
+		var d models.AppliedDiscount
+		var detailID, promotionID sql.NullInt64
+		if err := rows.Scan(&d.ID, &d.TransactionID, &detailID, &promotionID, &d.PromotionName, &d.Amount); err != nil {
+			return nil, err
+		}
+		if detailID.Valid {
+			id := int(detailID.Int64)
+			d.TransactionDetailID = &id
+		}
+		if promotionID.Valid {
+			id := int(promotionID.Int64)
+			d.PromotionID = &id
+		}
+		discounts[d.TransactionID] = append(discounts[d.TransactionID], d)
+	}
+
+	return discounts, rows.Err()
 }
 
 func (r *postgresTransactionRepository) GetAll() ([]models.Transaction, error) {
-	query := `SELECT id, total_amount, created_at FROM transactions ORDER BY created_at DESC`
+	query := `SELECT id, invoice_number, outlet_code, shift_id, status, gross_amount, discount_amount, subtotal_amount, tax_amount, service_charge,
+		total_amount, refunded_amount, paid_amount, change_amount, created_at
+		FROM transactions ORDER BY created_at DESC`
 	rows, err := r.db.Query(query)
 	if err != nil {
 		return nil, err
@@ -135,12 +680,19 @@ func (r *postgresTransactionRepository) GetAll() ([]models.Transaction, error) {
 
 	for rows.Next() {
 		var t models.Transaction
-		if err := rows.Scan(&t.ID, &t.TotalAmount, &t.CreatedAt); err != nil {
+		var shiftID sql.NullInt64
+		if err := rows.Scan(&t.ID, &t.InvoiceNumber, &t.OutletCode, &shiftID, &t.Status, &t.GrossAmount, &t.DiscountAmount, &t.SubtotalAmount, &t.TaxAmount, &t.ServiceCharge,
+			&t.TotalAmount, &t.RefundedAmount, &t.PaidAmount, &t.ChangeAmount, &t.CreatedAt); err != nil {
 			return nil, err
 		}
+		t.ShiftID = nullableInt(shiftID)
 		transactions = append(transactions, t)
 		ids = append(ids, t.ID)
-		transMap[t.ID] = &transactions[len(transactions)-1]
+	}
+
+	// Index after the slice stops growing so the pointers stay valid
+	for i := range transactions {
+		transMap[transactions[i].ID] = &transactions[i]
 	}
 
 	if len(ids) == 0 {
@@ -149,9 +701,9 @@ func (r *postgresTransactionRepository) GetAll() ([]models.Transaction, error) {
 
 	// Fetch all details for these transactions in one go
 	detailQuery := `
-		SELECT td.id, td.transaction_id, td.product_id, td.quantity, td.subtotal, p.name, p.price
+		SELECT td.id, td.transaction_id, td.product_id, td.product_name, td.unit_price, td.quantity, td.refunded_quantity, td.discount_amount, td.subtotal,
+			td.tax_name, td.tax_rate, td.tax_amount, td.service_charge, td.cost_amount, td.parent_product_id, td.parent_product_name, td.sku
 		FROM transaction_details td
-		JOIN products p ON td.product_id = p.id
 		WHERE td.transaction_id IN (`
 
 	for i := range ids {
@@ -169,35 +721,89 @@ func (r *postgresTransactionRepository) GetAll() ([]models.Transaction, error) {
 	defer detailRows.Close()
 
 	for detailRows.Next() {
-		var d models.TransactionDetail
-		var p models.Product
-		if err := detailRows.Scan(&d.ID, &d.TransactionID, &d.ProductID, &d.Quantity, &d.Subtotal, &p.Name, &p.Price); err != nil {
+		d, err := scanTransactionDetail(detailRows)
+		if err != nil {
 			return nil, err
 		}
-		p.ID = d.ProductID
-		d.Product = &p
-		d.ProductName = p.Name
 
 		if t, ok := transMap[d.TransactionID]; ok {
 			t.Details = append(t.Details, d)
 		}
 	}
 
+	payments, err := r.getPayments(ids)
+	if err != nil {
+		return nil, err
+	}
+	discounts, err := r.getDiscounts(ids)
+	if err != nil {
+		return nil, err
+	}
+	for id, t := range transMap {
+		t.Payments = payments[id]
+		t.Discounts = discounts[id]
+	}
+
 	return transactions, nil
 }
 
+// scanTransactionDetail reads a line from its checkout snapshot. The product
+// reference is only set while the product still exists.
+func scanTransactionDetail(rows *sql.Rows) (models.TransactionDetail, error) {
+	var d models.TransactionDetail
+	var productID sql.NullInt64
+	var parentProductID sql.NullInt64
+	err := rows.Scan(&d.ID, &d.TransactionID, &productID, &d.ProductName, &d.UnitPrice, &d.Quantity, &d.RefundedQuantity, &d.DiscountAmount, &d.Subtotal,
+		&d.TaxName, &d.TaxRate, &d.TaxAmount, &d.ServiceCharge, &d.CostAmount, &parentProductID, &d.ParentProductName, &d.SKU)
+	if err != nil {
+		return d, err
+	}
+	d.ParentProductID = nullableInt(parentProductID)
+
+	if productID.Valid {
+		d.ProductID = int(productID.Int64)
+		d.Product = &models.Product{
+			ID:    d.ProductID,
+			Name:  d.ProductName,
+			Price: d.UnitPrice,
+		}
+	}
+
+	return d, nil
+}
+
+func (r *postgresTransactionRepository) GetByInvoiceNumber(outletCode, invoiceNumber string) (models.Transaction, error) {
+	var id int
+	err := r.db.QueryRow("SELECT id FROM transactions WHERE outlet_code = $1 AND invoice_number = $2", outletCode, invoiceNumber).Scan(&id)
+	if err == sql.ErrNoRows {
+		return models.Transaction{}, utils.ErrTransactionNotFound
+	}
+	if err != nil {
+		return models.Transaction{}, err
+	}
+	return r.GetByID(id)
+}
+
 func (r *postgresTransactionRepository) GetByID(id int) (models.Transaction, error) {
 	var t models.Transaction
-	query := `SELECT id, total_amount, created_at FROM transactions WHERE id = $1`
-	err := r.db.QueryRow(query, id).Scan(&t.ID, &t.TotalAmount, &t.CreatedAt)
+	var shiftID sql.NullInt64
+	query := `SELECT id, invoice_number, outlet_code, shift_id, status, gross_amount, discount_amount, subtotal_amount, tax_amount, service_charge,
+		total_amount, refunded_amount, paid_amount, change_amount, created_at
+		FROM transactions WHERE id = $1`
+	err := r.db.QueryRow(query, id).Scan(&t.ID, &t.InvoiceNumber, &t.OutletCode, &shiftID, &t.Status, &t.GrossAmount, &t.DiscountAmount, &t.SubtotalAmount, &t.TaxAmount, &t.ServiceCharge,
+		&t.TotalAmount, &t.RefundedAmount, &t.PaidAmount, &t.ChangeAmount, &t.CreatedAt)
+	if err == sql.ErrNoRows {
+		return t, utils.ErrTransactionNotFound
+	}
 	if err != nil {
 		return t, err
 	}
+	t.ShiftID = nullableInt(shiftID)
 
 	detailQuery := `
-		SELECT td.id, td.transaction_id, td.product_id, td.quantity, td.subtotal, p.name, p.price
+		SELECT td.id, td.transaction_id, td.product_id, td.product_name, td.unit_price, td.quantity, td.refunded_quantity, td.discount_amount, td.subtotal,
+			td.tax_name, td.tax_rate, td.tax_amount, td.service_charge, td.cost_amount, td.parent_product_id, td.parent_product_name, td.sku
 		FROM transaction_details td
-		JOIN products p ON td.product_id = p.id
 		WHERE td.transaction_id = $1`
 
 	rows, err := r.db.Query(detailQuery, t.ID)
@@ -207,16 +813,317 @@ func (r *postgresTransactionRepository) GetByID(id int) (models.Transaction, err
 	defer rows.Close()
 
 	for rows.Next() {
-		var d models.TransactionDetail
-		var p models.Product
-		if err := rows.Scan(&d.ID, &d.TransactionID, &d.ProductID, &d.Quantity, &d.Subtotal, &p.Name, &p.Price); err != nil {
+		d, err := scanTransactionDetail(rows)
+		if err != nil {
 			return t, err
 		}
-		p.ID = d.ProductID
-		d.Product = &p
-		d.ProductName = p.Name
 		t.Details = append(t.Details, d)
 	}
 
+	payments, err := r.getPayments([]interface{}{t.ID})
+	if err != nil {
+		return t, err
+	}
+	t.Payments = payments[t.ID]
+
+	discounts, err := r.getDiscounts([]interface{}{t.ID})
+	if err != nil {
+		return t, err
+	}
+	t.Discounts = discounts[t.ID]
+
 	return t, nil
 }
+
+func (r *postgresTransactionRepository) VoidTransaction(id int, req models.VoidRequest) (*models.Refund, error) {
+	return r.reverse(id, models.RefundTypeVoid, req.Reason, req.PerformedBy, req.Actor.ID, req.Outlet.Code, nil, req.Approvals)
+}
+
+func (r *postgresTransactionRepository) RefundTransaction(id int, req models.RefundRequest) (*models.Refund, error) {
+	if len(req.Items) == 0 {
+		return nil, utils.ErrInvalidRefundQuantity
+	}
+
+	quantities := make(map[int]int)
+	for _, item := range req.Items {
+		if item.Quantity <= 0 {
+			return nil, utils.ErrInvalidRefundQuantity
+		}
+		quantities[item.ProductID] += item.Quantity
+	}
+
+	return r.reverse(id, models.RefundTypeRefund, req.Reason, req.PerformedBy, req.Actor.ID, req.Outlet.Code, quantities, req.Approvals)
+}
+
+// reverse returns goods to stock and records the refund document along with
+// the approvals behind it. A nil quantities map reverses everything that has
+// not been refunded yet (a void). Unless saleOutlet is empty, only sales made
+// at the outlet with that code can be reversed.
+func (r *postgresTransactionRepository) reverse(id int, refundType models.RefundType, reason, performedBy string, userID int,
+	saleOutlet string, quantities map[int]int, approvals []models.Approval) (*models.Refund, error) {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	// 1. Lock the transaction header
+	var status models.TransactionStatus
+	var totalAmount, refundedAmount int
+	var outletID int
+	var outletCode string
+	var createdAt time.Time
+	err = tx.QueryRow(`SELECT t.status, t.total_amount, t.refunded_amount, o.id, t.outlet_code, t.created_at
+		FROM transactions t JOIN outlets o ON o.code = t.outlet_code
+		WHERE t.id = $1 FOR UPDATE OF t`, id).
+		Scan(&status, &totalAmount, &refundedAmount, &outletID, &outletCode, &createdAt)
+	if err == sql.ErrNoRows {
+		return nil, utils.ErrTransactionNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	if saleOutlet != "" && saleOutlet != outletCode {
+		return nil, utils.ErrOutletForbidden
+	}
+	if status == models.TransactionStatusVoided || status == models.TransactionStatusRefunded {
+		return nil, utils.ErrTransactionNotRefundable
+	}
+
+	// The reversal is booked on the day it is made, which cannot be closed
+	// yet. A void takes the sale out of the figures of its day, so only a
+	// sale of that day can be voided; older sales are refunded.
+	today, err := businessDay(tx)
+	if err != nil {
+		return nil, err
+	}
+	if err := lockBusinessDay(tx, outletCode, today, false); err != nil {
+		return nil, err
+	}
+	if refundType == models.RefundTypeVoid && createdAt.Format("2006-01-02") != today.Format("2006-01-02") {
+		return nil, utils.ErrVoidNotToday
+	}
+
+	// 2. Load the lines that can still be refunded
+	rows, err := tx.Query(`SELECT id, COALESCE(product_id, 0), quantity, refunded_quantity, subtotal, tax_amount, service_charge, cost_amount
+		FROM transaction_details WHERE transaction_id = $1 ORDER BY product_id`, id)
+	if err != nil {
+		return nil, err
+	}
+	var details []models.TransactionDetail
+	for rows.Next() {
+		var d models.TransactionDetail
+		if err := rows.Scan(&d.ID, &d.ProductID, &d.Quantity, &d.RefundedQuantity, &d.Subtotal, &d.TaxAmount, &d.ServiceCharge,
+			&d.CostAmount); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		details = append(details, d)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	// 3. Work out the quantity and amount to give back per line
+	items := make([]models.RefundItem, 0, len(details))
+	matched := 0
+	for _, d := range details {
+		remaining := d.Quantity - d.RefundedQuantity
+		qty := remaining
+		if quantities != nil {
+			requested, ok := quantities[d.ProductID]
+			if !ok {
+				continue
+			}
+			matched++
+			if requested > remaining {
+				return nil, utils.ErrInvalidRefundQuantity
+			}
+			qty = requested
+		}
+		if qty == 0 {
+			continue
+		}
+
+		items = append(items, models.RefundItem{
+			TransactionDetailID: d.ID,
+			ProductID:           d.ProductID,
+			Quantity:            qty,
+			Amount:              lineRefundAmount(d, qty),
+			CostAmount:          lineRefundCost(d, qty),
+		})
+	}
+	if quantities != nil && matched != len(quantities) {
+		return nil, utils.ErrInvalidRefundQuantity
+	}
+
+	refundAmount := 0
+	for _, item := range items {
+		refundAmount += item.Amount
+	}
+
+	// 4. Mark the lines as refunded. Stock is restored once the refund
+	// document exists for the ledger to point at.
+	for _, item := range items {
+		if _, err := tx.Exec("UPDATE transaction_details SET refunded_quantity = refunded_quantity + $1 WHERE id = $2", item.Quantity, item.TransactionDetailID); err != nil {
+			return nil, err
+		}
+	}
+
+	// 5. Update the header status
+	refundedAmount += refundAmount
+	newStatus := models.TransactionStatusPartiallyRefunded
+	if refundType == models.RefundTypeVoid {
+		newStatus = models.TransactionStatusVoided
+	} else if refundedAmount >= totalAmount {
+		newStatus = models.TransactionStatusRefunded
+	}
+	_, err = tx.Exec("UPDATE transactions SET status = $1, refunded_amount = $2 WHERE id = $3", newStatus, refundedAmount, id)
+	if err != nil {
+		return nil, err
+	}
+
+	// 6. Record the refund document against the shift paying it out
+	shiftID, err := openShiftID(tx, outletCode)
+	if err != nil {
+		return nil, err
+	}
+	refund := models.Refund{
+		TransactionID: id,
+		ShiftID:       shiftID,
+		Type:          refundType,
+		Amount:        refundAmount,
+		Reason:        reason,
+		PerformedBy:   performedBy,
+	}
+	err = tx.QueryRow(`INSERT INTO transaction_refunds (transaction_id, shift_id, type, amount, reason, performed_by)
+		VALUES ($1, $2, $3, $4, $5, $6) RETURNING id, created_at`,
+		refund.TransactionID, refund.ShiftID, refund.Type, refund.Amount, refund.Reason, refund.PerformedBy).Scan(&refund.ID, &refund.CreatedAt)
+	if err != nil {
+		return nil, err
+	}
+
+	for i := range items {
+		items[i].RefundID = refund.ID
+		item := items[i]
+		err = tx.QueryRow(`INSERT INTO transaction_refund_items (refund_id, transaction_detail_id, product_id, quantity, amount, cost_amount)
+			VALUES ($1, $2, NULLIF($3, 0), $4, $5, $6) RETURNING id`,
+			item.RefundID, item.TransactionDetailID, item.ProductID, item.Quantity, item.Amount, item.CostAmount).Scan(&items[i].ID)
+		if err != nil {
+			return nil, err
+		}
+	}
+	refund.Items = items
+
+	// 7. Put the returned goods back in stock at the outlet that sold them,
+	// in the batches they were sold from and at the cost they were sold at.
+	// The lines are ordered by product id, the same order checkout locks
+	// products in, and deleted products have nothing left to restock.
+	for _, item := range items {
+		if item.ProductID == 0 {
+			continue
+		}
+		returned, err := returnSaleLots(tx, item.TransactionDetailID, item.Quantity)
+		if err != nil {
+			return nil, err
+		}
+		unitCost := (item.CostAmount + item.Quantity/2) / item.Quantity
+		for _, lot := range returned {
+			movement := models.StockMovement{
+				ProductID:     item.ProductID,
+				OutletID:      outletID,
+				Delta:         lot.Quantity,
+				Reason:        models.StockMovementRefund,
+				ReferenceType: models.StockReferenceRefund,
+				ReferenceID:   &refund.ID,
+				UserID:        optionalID(userID),
+				Note:          string(refundType),
+				UnitCost:      &unitCost,
+				BatchNumber:   lot.BatchNumber,
+				ExpiryDate:    lot.ExpiryDate,
+			}
+			if err := moveStock(tx, &movement); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	for i := range approvals {
+		approvals[i].TransactionID = &id
+	}
+	if err := insertApprovals(tx, approvals); err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+
+	return &refund, nil
+}
+
+// returnSaleLots splits the quantity returned of a sale line over the lots it
+// was sold from that have not been returned yet, in the order they were
+// taken, and marks them returned. Goods sold before lots were recorded, or
+// from outside any batch, come back without a batch.
+func returnSaleLots(tx *sql.Tx, detailID, quantity int) ([]models.StockLot, error) {
+	rows, err := tx.Query(`SELECT id, batch_number, COALESCE(to_char(expiry_date, 'YYYY-MM-DD'), ''), quantity - returned_quantity
+		FROM transaction_detail_lots
+		WHERE transaction_detail_id = $1 AND returned_quantity < quantity
+		ORDER BY id`, detailID)
+	if err != nil {
+		return nil, err
+	}
+	type saleLot struct {
+		id  int
+		lot models.StockLot
+	}
+	var sold []saleLot
+	for rows.Next() {
+		var l saleLot
+		if err := rows.Scan(&l.id, &l.lot.BatchNumber, &l.lot.ExpiryDate, &l.lot.Quantity); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		sold = append(sold, l)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	var lots []models.StockLot
+	for _, l := range sold {
+		if quantity == 0 {
+			break
+		}
+		if l.lot.Quantity > quantity {
+			l.lot.Quantity = quantity
+		}
+		if _, err := tx.Exec(`UPDATE transaction_detail_lots SET returned_quantity = returned_quantity + $1 WHERE id = $2`,
+			l.lot.Quantity, l.id); err != nil {
+			return nil, err
+		}
+		lots = append(lots, l.lot)
+		quantity -= l.lot.Quantity
+	}
+	if quantity > 0 {
+		lots = append(lots, models.StockLot{Quantity: quantity})
+	}
+	return lots, nil
+}
+
+// lineRefundAmount prorates the line total over the quantity being returned.
+// It works on cumulative quantities so the refunds of a line never add up to
+// more or less than its total because of rounding.
+func lineRefundAmount(d models.TransactionDetail, qty int) int {
+	refunded, total := d.RefundedQuantity, d.LineTotal()
+	return total*(refunded+qty)/d.Quantity - total*refunded/d.Quantity
+}
+
+// lineRefundCost prorates the cost of goods sold of the line the same way.
+func lineRefundCost(d models.TransactionDetail, qty int) int {
+	refunded := d.RefundedQuantity
+	return d.CostAmount*(refunded+qty)/d.Quantity - d.CostAmount*refunded/d.Quantity
+}
@@ -0,0 +1,149 @@
+package repository
+
+import (
+	"errors"
+	"kasir-api-go/internal/models"
+	"kasir-api-go/internal/utils"
+	"reflect"
+	"testing"
+)
+
+func TestSettlePayments(t *testing.T) {
+	cash := models.PaymentMethodCash
+	card := models.PaymentMethodDebitCard
+	qris := models.PaymentMethodQRIS
+
+	tests := []struct {
+		name       string
+		lines      []models.CheckoutPayment
+		total      int
+		want       []models.Payment
+		wantPaid   int
+		wantChange int
+		wantErr    error
+	}{
+		{
+			name:     "exact cash",
+			lines:    []models.CheckoutPayment{{Method: cash, Amount: 10000}},
+			total:    10000,
+			want:     []models.Payment{{Method: cash, Amount: 10000, TenderedAmount: 10000}},
+			wantPaid: 10000,
+		},
+		{
+			name:       "cash with change",
+			lines:      []models.CheckoutPayment{{Method: cash, Amount: 50000}},
+			total:      42000,
+			want:       []models.Payment{{Method: cash, Amount: 42000, TenderedAmount: 50000}},
+			wantPaid:   50000,
+			wantChange: 8000,
+		},
+		{
+			name:  "change comes out of the cash line",
+			lines: []models.CheckoutPayment{{Method: card, Amount: 20000, Reference: "A1"}, {Method: cash, Amount: 30000}},
+			total: 42000,
+			want: []models.Payment{
+				{Method: card, Amount: 20000, TenderedAmount: 20000, Reference: "A1"},
+				{Method: cash, Amount: 22000, TenderedAmount: 30000},
+			},
+			wantPaid:   50000,
+			wantChange: 8000,
+		},
+		{
+			name: "change comes out of the last cash first",
+			lines: []models.CheckoutPayment{
+				{Method: cash, Amount: 10000}, {Method: qris, Amount: 5000}, {Method: cash, Amount: 5000},
+			},
+			total: 12000,
+			want: []models.Payment{
+				{Method: cash, Amount: 7000, TenderedAmount: 10000},
+				{Method: qris, Amount: 5000, TenderedAmount: 5000},
+				{Method: cash, Amount: 0, TenderedAmount: 5000},
+			},
+			wantPaid:   20000,
+			wantChange: 8000,
+		},
+		{
+			name:    "insufficient payment",
+			lines:   []models.CheckoutPayment{{Method: cash, Amount: 5000}, {Method: card, Amount: 4000}},
+			total:   10000,
+			wantErr: utils.ErrInsufficientPayment,
+		},
+		{
+			name:    "non-cash overpayment",
+			lines:   []models.CheckoutPayment{{Method: card, Amount: 15000}},
+			total:   10000,
+			wantErr: utils.ErrNonCashOverpayment,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			payments, paid, change, err := settlePayments(tt.lines, tt.total)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("error = %v, want %v", err, tt.wantErr)
+			}
+			if !reflect.DeepEqual(payments, tt.want) {
+				t.Errorf("payments = %+v, want %+v", payments, tt.want)
+			}
+			if paid != tt.wantPaid || change != tt.wantChange {
+				t.Errorf("paid %d and change %d, want %d and %d", paid, change, tt.wantPaid, tt.wantChange)
+			}
+		})
+	}
+}
+
+func TestLineRefundAmount(t *testing.T) {
+	line := models.TransactionDetail{Quantity: 3, Subtotal: 10000}
+	taxed := models.TransactionDetail{Quantity: 2, Subtotal: 9000, TaxAmount: 990, ServiceCharge: 500}
+
+	tests := []struct {
+		name     string
+		line     models.TransactionDetail
+		refunded int
+		qty      int
+		want     int
+	}{
+		{"first unit", line, 0, 1, 3333},
+		{"second unit", line, 1, 1, 3333},
+		{"last unit takes the remainder", line, 2, 1, 3334},
+		{"whole line", line, 0, 3, 10000},
+		{"rest of the line", line, 1, 2, 6667},
+		{"with tax and service charge", taxed, 0, 1, 5245},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.line.RefundedQuantity = tt.refunded
+			if got := lineRefundAmount(tt.line, tt.qty); got != tt.want {
+				t.Errorf("lineRefundAmount(%d after %d) = %d, want %d", tt.qty, tt.refunded, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLineRefundCost(t *testing.T) {
+	line := models.TransactionDetail{Quantity: 3, CostAmount: 1000}
+
+	tests := []struct {
+		name     string
+		line     models.TransactionDetail
+		refunded int
+		qty      int
+		want     int
+	}{
+		{"first unit", line, 0, 1, 333},
+		{"second unit", line, 1, 1, 333},
+		{"last unit takes the remainder", line, 2, 1, 334},
+		{"whole line", line, 0, 3, 1000},
+		{"no cost", models.TransactionDetail{Quantity: 3}, 0, 2, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.line.RefundedQuantity = tt.refunded
+			if got := lineRefundCost(tt.line, tt.qty); got != tt.want {
+				t.Errorf("lineRefundCost(%d after %d) = %d, want %d", tt.qty, tt.refunded, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,167 @@
+package repository
+
+import (
+	"database/sql"
+	"kasir-api-go/internal/models"
+	"kasir-api-go/internal/utils"
+	"time"
+)
+
+type UserRepository interface {
+	GetAll() ([]models.User, error)
+	GetByID(id int) (models.User, error)
+	GetByUsername(username string) (models.User, error)
+	Count() (int, error)
+	Create(user models.User) (models.User, error)
+	Update(id int, user models.User) (models.User, error)
+	Delete(id int) error
+	RecordLoginFailure(id, maxAttempts int, lockFor time.Duration) error
+	ResetLoginFailures(id int) error
+}
+
+type postgresUserRepository struct {
+	db *sql.DB
+}
+
+func NewPostgresUserRepository(db *sql.DB) UserRepository {
+	return &postgresUserRepository{db: db}
+}
+
+// userSelect reads users together with the permissions of their role.
+const userSelect = `
+	SELECT u.id, u.username, u.name, u.role, array_to_string(r.permissions, ','), u.outlet_id, COALESCE(o.code, ''),
+		u.active, u.password_hash, COALESCE(u.pin_hash, ''), u.token_version, u.failed_login_attempts, u.locked_until,
+		u.created_at, u.updated_at
+	FROM users u
+	JOIN roles r ON r.name = u.role
+	LEFT JOIN outlets o ON o.id = u.outlet_id`
+
+func scanUser(row rowScanner) (models.User, error) {
+	var u models.User
+	var permissions string
+	var outletID sql.NullInt64
+	var lockedUntil sql.NullTime
+
+	err := row.Scan(&u.ID, &u.Username, &u.Name, &u.Role, &permissions, &outletID, &u.OutletCode, &u.Active,
+		&u.PasswordHash, &u.PINHash, &u.TokenVersion, &u.FailedLoginAttempts, &lockedUntil, &u.CreatedAt, &u.UpdatedAt)
+	if err != nil {
+		return u, err
+	}
+	u.OutletID = nullableInt(outletID)
+
+	u.Permissions = models.GrantedPermissions(u.Role, splitPermissions(permissions))
+	u.HasPIN = u.PINHash != ""
+	if lockedUntil.Valid {
+		u.LockedUntil = &lockedUntil.Time
+	}
+	return u, nil
+}
+
+func (r *postgresUserRepository) GetAll() ([]models.User, error) {
+	rows, err := r.db.Query(userSelect + ` ORDER BY u.id`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	users := []models.User{}
+	for rows.Next() {
+		u, err := scanUser(rows)
+		if err != nil {
+			return nil, err
+		}
+		users = append(users, u)
+	}
+
+	return users, rows.Err()
+}
+
+func (r *postgresUserRepository) GetByID(id int) (models.User, error) {
+	u, err := scanUser(r.db.QueryRow(userSelect+` WHERE u.id = $1`, id))
+	if err == sql.ErrNoRows {
+		return u, utils.ErrUserNotFound
+	}
+	return u, err
+}
+
+func (r *postgresUserRepository) GetByUsername(username string) (models.User, error) {
+	u, err := scanUser(r.db.QueryRow(userSelect+` WHERE u.username = $1`, username))
+	if err == sql.ErrNoRows {
+		return u, utils.ErrUserNotFound
+	}
+	return u, err
+}
+
+func (r *postgresUserRepository) Count() (int, error) {
+	var count int
+	err := r.db.QueryRow(`SELECT COUNT(*) FROM users`).Scan(&count)
+	return count, err
+}
+
+func (r *postgresUserRepository) Create(user models.User) (models.User, error) {
+	query := `
+		INSERT INTO users (username, name, password_hash, pin_hash, role, outlet_id, active)
+		VALUES ($1, $2, $3, NULLIF($4, ''), $5, $6, $7)
+		ON CONFLICT (username) DO NOTHING
+		RETURNING id`
+
+	var id int
+	err := r.db.QueryRow(query, user.Username, user.Name, user.PasswordHash, user.PINHash, user.Role, user.OutletID, user.Active).Scan(&id)
+	if err == sql.ErrNoRows {
+		return models.User{}, utils.ErrUsernameTaken
+	}
+	if err != nil {
+		return models.User{}, err
+	}
+
+	return r.GetByID(id)
+}
+
+// Update saves the profile, role, outlet and credentials of a user. Any change bumps
+// the token version, so tokens issued before it stop working.
+func (r *postgresUserRepository) Update(id int, user models.User) (models.User, error) {
+	query := `
+		UPDATE users SET name = $1, password_hash = $2, pin_hash = NULLIF($3, ''), role = $4, outlet_id = $5, active = $6,
+			token_version = token_version + 1, updated_at = CURRENT_TIMESTAMP
+		WHERE id = $7`
+
+	result, err := r.db.Exec(query, user.Name, user.PasswordHash, user.PINHash, user.Role, user.OutletID, user.Active, id)
+	if err != nil {
+		return models.User{}, err
+	}
+
+	rowsAffected, _ := result.RowsAffected()
+	if rowsAffected == 0 {
+		return models.User{}, utils.ErrUserNotFound
+	}
+
+	return r.GetByID(id)
+}
+
+func (r *postgresUserRepository) Delete(id int) error {
+	result, err := r.db.Exec(`DELETE FROM users WHERE id = $1`, id)
+	if err != nil {
+		return err
+	}
+
+	rowsAffected, _ := result.RowsAffected()
+	if rowsAffected == 0 {
+		return utils.ErrUserNotFound
+	}
+	return nil
+}
+
+// RecordLoginFailure counts a failed sign-in and locks the account for lockFor
+// once maxAttempts failures have piled up.
+func (r *postgresUserRepository) RecordLoginFailure(id, maxAttempts int, lockFor time.Duration) error {
+	_, err := r.db.Exec(`
+		UPDATE users SET failed_login_attempts = failed_login_attempts + 1,
+			locked_until = CASE WHEN failed_login_attempts + 1 >= $2 THEN $3 ELSE locked_until END
+		WHERE id = $1`, id, maxAttempts, time.Now().Add(lockFor))
+	return err
+}
+
+func (r *postgresUserRepository) ResetLoginFailures(id int) error {
+	_, err := r.db.Exec(`UPDATE users SET failed_login_attempts = 0, locked_until = NULL WHERE id = $1`, id)
+	return err
+}
@@ -0,0 +1,22 @@
+package service
+
+import (
+	"kasir-api-go/internal/models"
+	"kasir-api-go/internal/repository"
+)
+
+type ApprovalService interface {
+	GetAll(action models.ApprovalAction) ([]models.Approval, error)
+}
+
+type approvalService struct {
+	repo repository.ApprovalRepository
+}
+
+func NewApprovalService(repo repository.ApprovalRepository) ApprovalService {
+	return &approvalService{repo: repo}
+}
+
+func (s *approvalService) GetAll(action models.ApprovalAction) ([]models.Approval, error) {
+	return s.repo.GetAll(action)
+}
@@ -0,0 +1,281 @@
+package service
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"kasir-api-go/internal/models"
+	"kasir-api-go/internal/repository"
+	"kasir-api-go/internal/utils"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"golang.org/x/crypto/bcrypt"
+)
+
+const (
+	tokenTypeAccess   = "access"
+	tokenTypeRefresh  = "refresh"
+	tokenTypeApproval = "approval"
+
+	// Failed password or PIN sign-ins allowed before the account is locked
+	maxLoginAttempts = 5
+	loginLockout     = 15 * time.Minute
+)
+
+// dummyHash is compared against when the username does not exist, so a
+// failed sign-in takes as long whether or not the user is real.
+var dummyHash, _ = bcrypt.GenerateFromPassword([]byte("kasir-api"), bcrypt.DefaultCost)
+
+type AuthService interface {
+	Login(req models.LoginRequest) (models.AuthTokens, error)
+	PINLogin(req models.PINLoginRequest) (models.AuthTokens, error)
+	Refresh(req models.RefreshTokenRequest) (models.AuthTokens, error)
+	// Authenticate resolves an access token to the user it was issued to.
+	Authenticate(accessToken string) (models.User, error)
+	// EnsureAdmin creates the first owner account when there are no users yet.
+	EnsureAdmin(username, password string) error
+	// IssueApprovalToken gives a supervisor a short-lived token they can hand
+	// to a cashier to approve one of their actions.
+	IssueApprovalToken(user models.User) (models.ApprovalToken, error)
+	// Authorize checks that actor may perform an action needing perm, either
+	// because their role allows it or through the approval of a supervisor
+	// whose role does. It returns who approved it and the ID of the approval
+	// token used, if any, which the action consumes when it is recorded.
+	Authorize(actor models.User, perm models.Permission, approval *models.ApprovalRequest) (models.User, string, error)
+}
+
+type authService struct {
+	repo        repository.UserRepository
+	secret      []byte
+	accessTTL   time.Duration
+	refreshTTL  time.Duration
+	approvalTTL time.Duration
+}
+
+func NewAuthService(repo repository.UserRepository, secret string, accessTTL, refreshTTL, approvalTTL time.Duration) AuthService {
+	return &authService{
+		repo:        repo,
+		secret:      []byte(secret),
+		accessTTL:   accessTTL,
+		refreshTTL:  refreshTTL,
+		approvalTTL: approvalTTL,
+	}
+}
+
+type tokenClaims struct {
+	Type    string `json:"typ"`
+	Role    string `json:"role"`
+	Version int    `json:"ver"`
+	jwt.RegisteredClaims
+}
+
+func (s *authService) Login(req models.LoginRequest) (models.AuthTokens, error) {
+	return s.signIn(req.Username, func(u models.User) string { return u.PasswordHash }, req.Password)
+}
+
+// PINLogin is the quick sign-in at the till for users who have a PIN.
+func (s *authService) PINLogin(req models.PINLoginRequest) (models.AuthTokens, error) {
+	return s.signIn(req.Username, func(u models.User) string { return u.PINHash }, req.PIN)
+}
+
+func (s *authService) signIn(username string, hashOf func(models.User) string, secret string) (models.AuthTokens, error) {
+	user, err := s.checkCredentials(username, hashOf, secret)
+	if err != nil {
+		return models.AuthTokens{}, err
+	}
+	return s.issue(user)
+}
+
+// checkCredentials verifies a password or PIN, counting failures towards the
+// account lockout.
+func (s *authService) checkCredentials(username string, hashOf func(models.User) string, secret string) (models.User, error) {
+	user, err := s.repo.GetByUsername(strings.TrimSpace(username))
+	if errors.Is(err, utils.ErrUserNotFound) {
+		bcrypt.CompareHashAndPassword(dummyHash, []byte(secret))
+		return models.User{}, utils.ErrInvalidCredentials
+	}
+	if err != nil {
+		return models.User{}, err
+	}
+
+	if user.LockedUntil != nil && time.Now().Before(*user.LockedUntil) {
+		return models.User{}, utils.ErrAccountLocked
+	}
+
+	hash := hashOf(user)
+	if hash == "" || bcrypt.CompareHashAndPassword([]byte(hash), []byte(secret)) != nil || !user.Active {
+		if hash == "" {
+			bcrypt.CompareHashAndPassword(dummyHash, []byte(secret))
+		}
+		if err := s.repo.RecordLoginFailure(user.ID, maxLoginAttempts, loginLockout); err != nil {
+			return models.User{}, err
+		}
+		return models.User{}, utils.ErrInvalidCredentials
+	}
+
+	if user.FailedLoginAttempts > 0 {
+		if err := s.repo.ResetLoginFailures(user.ID); err != nil {
+			return models.User{}, err
+		}
+	}
+	return user, nil
+}
+
+func (s *authService) Refresh(req models.RefreshTokenRequest) (models.AuthTokens, error) {
+	user, _, err := s.verify(req.RefreshToken, tokenTypeRefresh)
+	if err != nil {
+		return models.AuthTokens{}, err
+	}
+	return s.issue(user)
+}
+
+func (s *authService) Authenticate(accessToken string) (models.User, error) {
+	user, _, err := s.verify(accessToken, tokenTypeAccess)
+	return user, err
+}
+
+// verify checks the signature, expiry and type of a token and that the user
+// is still active and has not had their tokens revoked since.
+func (s *authService) verify(token, tokenType string) (models.User, tokenClaims, error) {
+	var claims tokenClaims
+	_, err := jwt.ParseWithClaims(token, &claims, func(*jwt.Token) (interface{}, error) {
+		return s.secret, nil
+	}, jwt.WithValidMethods([]string{jwt.SigningMethodHS256.Alg()}), jwt.WithExpirationRequired())
+	if err != nil || claims.Type != tokenType {
+		return models.User{}, claims, utils.ErrUnauthorized
+	}
+
+	id, err := strconv.Atoi(claims.Subject)
+	if err != nil {
+		return models.User{}, claims, utils.ErrUnauthorized
+	}
+	user, err := s.repo.GetByID(id)
+	if errors.Is(err, utils.ErrUserNotFound) {
+		return models.User{}, claims, utils.ErrUnauthorized
+	}
+	if err != nil {
+		return models.User{}, claims, err
+	}
+	if !user.Active || user.TokenVersion != claims.Version {
+		return models.User{}, claims, utils.ErrUnauthorized
+	}
+
+	return user, claims, nil
+}
+
+func (s *authService) issue(user models.User) (models.AuthTokens, error) {
+	access, err := s.sign(user, tokenTypeAccess, s.accessTTL)
+	if err != nil {
+		return models.AuthTokens{}, err
+	}
+	refresh, err := s.sign(user, tokenTypeRefresh, s.refreshTTL)
+	if err != nil {
+		return models.AuthTokens{}, err
+	}
+
+	return models.AuthTokens{
+		AccessToken:  access,
+		RefreshToken: refresh,
+		TokenType:    "Bearer",
+		ExpiresIn:    int(s.accessTTL.Seconds()),
+		User:         user,
+	}, nil
+}
+
+func (s *authService) sign(user models.User, tokenType string, ttl time.Duration) (string, error) {
+	// Approval tokens carry an ID so each can be used only once
+	var tokenID string
+	if tokenType == tokenTypeApproval {
+		b := make([]byte, 16)
+		if _, err := rand.Read(b); err != nil {
+			return "", err
+		}
+		tokenID = hex.EncodeToString(b)
+	}
+
+	now := time.Now()
+	claims := tokenClaims{
+		Type:    tokenType,
+		Role:    string(user.Role),
+		Version: user.TokenVersion,
+		RegisteredClaims: jwt.RegisteredClaims{
+			ID:        tokenID,
+			Subject:   strconv.Itoa(user.ID),
+			IssuedAt:  jwt.NewNumericDate(now),
+			ExpiresAt: jwt.NewNumericDate(now.Add(ttl)),
+		},
+	}
+	return jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString(s.secret)
+}
+
+func (s *authService) EnsureAdmin(username, password string) error {
+	count, err := s.repo.Count()
+	if err != nil || count > 0 {
+		return err
+	}
+	if username == "" || password == "" {
+		return nil
+	}
+
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	if err != nil {
+		return err
+	}
+	_, err = s.repo.Create(models.User{
+		Username:     username,
+		Name:         username,
+		PasswordHash: string(hash),
+		Role:         models.UserRoleOwner,
+		Active:       true,
+	})
+	if errors.Is(err, utils.ErrUsernameTaken) {
+		return nil
+	}
+	return err
+}
+
+func (s *authService) IssueApprovalToken(user models.User) (models.ApprovalToken, error) {
+	token, err := s.sign(user, tokenTypeApproval, s.approvalTTL)
+	if err != nil {
+		return models.ApprovalToken{}, err
+	}
+	return models.ApprovalToken{Token: token, ExpiresIn: int(s.approvalTTL.Seconds())}, nil
+}
+
+func (s *authService) Authorize(actor models.User, perm models.Permission, approval *models.ApprovalRequest) (models.User, string, error) {
+	if actor.Can(perm) {
+		return actor, "", nil
+	}
+	if approval == nil || (approval.Token == "" && approval.PIN == "") {
+		return models.User{}, "", utils.ErrApprovalRequired
+	}
+
+	var approver models.User
+	var tokenID string
+	var err error
+	if approval.Token != "" {
+		var claims tokenClaims
+		approver, claims, err = s.verify(approval.Token, tokenTypeApproval)
+		if err == nil && claims.ID == "" {
+			err = utils.ErrUnauthorized
+		}
+		tokenID = claims.ID
+	} else {
+		approver, err = s.checkCredentials(approval.Username, func(u models.User) string { return u.PINHash }, approval.PIN)
+	}
+	if errors.Is(err, utils.ErrInvalidCredentials) || errors.Is(err, utils.ErrUnauthorized) {
+		return models.User{}, "", fmt.Errorf("%w: invalid approver PIN or token", utils.ErrApprovalDenied)
+	}
+	if err != nil {
+		return models.User{}, "", err
+	}
+
+	if !approver.Can(perm) {
+		return models.User{}, "", fmt.Errorf("%w: %s may not approve this action", utils.ErrApprovalDenied, approver.Username)
+	}
+	return approver, tokenID, nil
+}
@@ -0,0 +1,177 @@
+package service
+
+import (
+	"kasir-api-go/internal/models"
+	"kasir-api-go/internal/repository"
+	"kasir-api-go/internal/utils"
+	"strings"
+	"time"
+)
+
+type CartService interface {
+	// Create opens a cart at the given outlet, the only one it can be
+	// checked out at.
+	Create(req models.CreateCartRequest, outlet models.Outlet) (models.Cart, error)
+	GetAll(status models.CartStatus) ([]models.Cart, error)
+	GetByID(id int) (models.Cart, error)
+	AddItem(cartID int, req models.CartItemRequest) (models.Cart, error)
+	UpdateItem(cartID, productID, quantity int) (models.Cart, error)
+	RemoveItem(cartID, productID int) (models.Cart, error)
+	Hold(id int) (models.Cart, error)
+	Resume(id int) (models.Cart, error)
+	Checkout(id int, req models.CartCheckoutRequest) (models.Transaction, error)
+	ExpireStale() (int64, error)
+}
+
+type cartService struct {
+	repo               repository.CartRepository
+	productRepo        repository.ProductRepository
+	transactionService TransactionService
+	ttl                time.Duration
+}
+
+func NewCartService(repo repository.CartRepository, productRepo repository.ProductRepository, transactionService TransactionService, ttl time.Duration) CartService {
+	return &cartService{
+		repo:               repo,
+		productRepo:        productRepo,
+		transactionService: transactionService,
+		ttl:                ttl,
+	}
+}
+
+func (s *cartService) Create(req models.CreateCartRequest, outlet models.Outlet) (models.Cart, error) {
+	return s.repo.Create(models.Cart{
+		OutletID:   outlet.ID,
+		OutletCode: outlet.Code,
+		Label:      strings.TrimSpace(req.Label),
+		Status:     models.CartStatusOpen,
+		ExpiresAt:  s.expiresAt(),
+	})
+}
+
+func (s *cartService) GetAll(status models.CartStatus) ([]models.Cart, error) {
+	if err := s.expireStale(); err != nil {
+		return nil, err
+	}
+	return s.repo.GetAll(status)
+}
+
+func (s *cartService) GetByID(id int) (models.Cart, error) {
+	if err := s.expireStale(); err != nil {
+		return models.Cart{}, err
+	}
+	return s.repo.GetByID(id)
+}
+
+func (s *cartService) AddItem(cartID int, req models.CartItemRequest) (models.Cart, error) {
+	if req.Quantity <= 0 {
+		return models.Cart{}, utils.ErrInvalidCartItem
+	}
+	// A product with variants is sold as one of them
+	if product, found := s.productRepo.GetByID(req.ProductID); !found || len(product.Variants) > 0 {
+		return models.Cart{}, utils.ErrInvalidCartItem
+	}
+
+	if err := s.expireStale(); err != nil {
+		return models.Cart{}, err
+	}
+	if err := s.repo.AddItem(cartID, req.ProductID, req.Quantity, s.expiresAt()); err != nil {
+		return models.Cart{}, err
+	}
+	return s.repo.GetByID(cartID)
+}
+
+func (s *cartService) UpdateItem(cartID, productID, quantity int) (models.Cart, error) {
+	if quantity <= 0 {
+		return s.RemoveItem(cartID, productID)
+	}
+
+	if err := s.expireStale(); err != nil {
+		return models.Cart{}, err
+	}
+	if err := s.repo.UpdateItem(cartID, productID, quantity, s.expiresAt()); err != nil {
+		return models.Cart{}, err
+	}
+	return s.repo.GetByID(cartID)
+}
+
+func (s *cartService) RemoveItem(cartID, productID int) (models.Cart, error) {
+	if err := s.expireStale(); err != nil {
+		return models.Cart{}, err
+	}
+	if err := s.repo.RemoveItem(cartID, productID, s.expiresAt()); err != nil {
+		return models.Cart{}, err
+	}
+	return s.repo.GetByID(cartID)
+}
+
+func (s *cartService) Hold(id int) (models.Cart, error) {
+	return s.changeStatus(id, models.CartStatusOpen, models.CartStatusHeld)
+}
+
+func (s *cartService) Resume(id int) (models.Cart, error) {
+	return s.changeStatus(id, models.CartStatusHeld, models.CartStatusOpen)
+}
+
+func (s *cartService) changeStatus(id int, from, to models.CartStatus) (models.Cart, error) {
+	if err := s.expireStale(); err != nil {
+		return models.Cart{}, err
+	}
+	if err := s.repo.SetStatus(id, []models.CartStatus{from}, to, s.expiresAt()); err != nil {
+		return models.Cart{}, err
+	}
+	return s.repo.GetByID(id)
+}
+
+// Checkout turns the cart into a transaction through the regular checkout,
+// which locks the cart together with the products it sells.
+func (s *cartService) Checkout(id int, req models.CartCheckoutRequest) (models.Transaction, error) {
+	if err := s.expireStale(); err != nil {
+		return models.Transaction{}, err
+	}
+
+	cart, err := s.repo.GetByID(id)
+	if err != nil {
+		return models.Transaction{}, err
+	}
+	if cart.OutletID != req.Outlet.ID {
+		return models.Transaction{}, utils.ErrCartOtherOutlet
+	}
+	if len(cart.Items) == 0 {
+		return models.Transaction{}, utils.ErrCartEmpty
+	}
+
+	items := make([]models.CheckoutItem, len(cart.Items))
+	for i, item := range cart.Items {
+		items[i] = models.CheckoutItem{ProductID: item.ProductID, Quantity: item.Quantity}
+	}
+
+	return s.transactionService.Checkout(models.CheckoutRequest{
+		Items:          items,
+		PaymentMethod:  req.PaymentMethod,
+		PaidAmount:     req.PaidAmount,
+		Reference:      req.Reference,
+		Payments:       req.Payments,
+		ManualDiscount: req.ManualDiscount,
+		Approval:       req.Approval,
+		IdempotencyKey: req.IdempotencyKey,
+		CartID:         cart.ID,
+		Outlet:         req.Outlet,
+		Actor:          req.Actor,
+	})
+}
+
+func (s *cartService) ExpireStale() (int64, error) {
+	return s.repo.ExpireStale(time.Now())
+}
+
+// expireStale runs before every cart operation so an abandoned cart is never
+// resumed or checked out, even between two runs of the background sweep.
+func (s *cartService) expireStale() error {
+	_, err := s.repo.ExpireStale(time.Now())
+	return err
+}
+
+func (s *cartService) expiresAt() time.Time {
+	return time.Now().Add(s.ttl)
+}
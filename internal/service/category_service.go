@@ -15,12 +15,14 @@ type CategoryService interface {
 }
 
 type categoryService struct {
-	repo repository.CategoryRepository
+	repo        repository.CategoryRepository
+	taxRateRepo repository.TaxRateRepository
 }
 
-func NewCategoryService(repo repository.CategoryRepository) CategoryService {
+func NewCategoryService(repo repository.CategoryRepository, taxRateRepo repository.TaxRateRepository) CategoryService {
 	return &categoryService{
-		repo: repo,
+		repo:        repo,
+		taxRateRepo: taxRateRepo,
 	}
 }
 
@@ -42,11 +44,25 @@ func (s *categoryService) Create(category models.Category) (models.Category, err
 		return models.Category{}, errors.New("category ID already exists")
 	}
 
+	// Validation: Tax rate existence
+	if category.TaxRateID != nil {
+		if _, err := s.taxRateRepo.GetByID(*category.TaxRateID); err != nil {
+			return models.Category{}, errors.New("tax rate not found")
+		}
+	}
+
 	s.repo.Create(category)
 	return category, nil
 }
 
 func (s *categoryService) Update(id int, category models.Category) (models.Category, error) {
+	// Validation: Tax rate existence
+	if category.TaxRateID != nil {
+		if _, err := s.taxRateRepo.GetByID(*category.TaxRateID); err != nil {
+			return models.Category{}, errors.New("tax rate not found")
+		}
+	}
+
 	if ok := s.repo.Update(id, category); !ok {
 		return models.Category{}, errors.New("category not found")
 	}
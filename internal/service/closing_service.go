@@ -0,0 +1,56 @@
+package service
+
+import (
+	"fmt"
+	"kasir-api-go/internal/models"
+	"kasir-api-go/internal/repository"
+	"kasir-api-go/internal/utils"
+	"strings"
+	"time"
+)
+
+// ClosingService closes business days per outlet; every method works on
+// the days of the outlet with the given code.
+type ClosingService interface {
+	// CloseDay closes the day for the actor.
+	CloseDay(req models.CloseDayRequest, outletCode string, actor models.User) (models.DailyClosing, error)
+	GetAll(outletCode string) ([]models.DailyClosing, error)
+	GetByDate(date, outletCode string) (models.DailyClosing, error)
+}
+
+type closingService struct {
+	repo repository.ClosingRepository
+}
+
+func NewClosingService(repo repository.ClosingRepository) ClosingService {
+	return &closingService{
+		repo: repo,
+	}
+}
+
+// CloseDay leaves today and whether the date is in the future to the
+// repository, which reads them off the same clock checkout dates sales by.
+func (s *closingService) CloseDay(req models.CloseDayRequest, outletCode string, actor models.User) (models.DailyClosing, error) {
+	var day *time.Time
+	if date := strings.TrimSpace(req.Date); date != "" {
+		parsed, err := time.Parse("2006-01-02", date)
+		if err != nil {
+			return models.DailyClosing{}, fmt.Errorf("%w: date must be YYYY-MM-DD", utils.ErrInvalidClosing)
+		}
+		day = &parsed
+	}
+
+	return s.repo.Close(outletCode, day, actor)
+}
+
+func (s *closingService) GetAll(outletCode string) ([]models.DailyClosing, error) {
+	return s.repo.GetAll(outletCode)
+}
+
+func (s *closingService) GetByDate(date, outletCode string) (models.DailyClosing, error) {
+	day, err := time.Parse("2006-01-02", date)
+	if err != nil {
+		return models.DailyClosing{}, fmt.Errorf("%w: date must be YYYY-MM-DD", utils.ErrInvalidClosing)
+	}
+	return s.repo.GetByDate(outletCode, day)
+}
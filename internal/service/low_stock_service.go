@@ -0,0 +1,49 @@
+package service
+
+import (
+	"kasir-api-go/internal/models"
+	"kasir-api-go/internal/repository"
+	"time"
+)
+
+type LowStockService interface {
+	// GetLowStock lists the products below their minimum stock at the
+	// outlet with the given code, or at every outlet if it is empty, with a
+	// suggested order quantity. A windowDays of 0 uses the configured window.
+	GetLowStock(windowDays int, outletCode string) (models.LowStockReport, error)
+	GetEvents(limit int, outletCode string) ([]models.LowStockEvent, error)
+}
+
+type lowStockService struct {
+	repo       repository.LowStockRepository
+	windowDays int
+}
+
+func NewLowStockService(repo repository.LowStockRepository, windowDays int) LowStockService {
+	return &lowStockService{
+		repo:       repo,
+		windowDays: windowDays,
+	}
+}
+
+func (s *lowStockService) GetLowStock(windowDays int, outletCode string) (models.LowStockReport, error) {
+	if windowDays <= 0 {
+		windowDays = s.windowDays
+	}
+
+	products, err := s.repo.GetBelowMinimum(time.Now().AddDate(0, 0, -windowDays), outletCode)
+	if err != nil {
+		return models.LowStockReport{}, err
+	}
+	for i := range products {
+		products[i].Suggest(windowDays)
+	}
+	return models.LowStockReport{WindowDays: windowDays, Outlet: outletCode, Products: products}, nil
+}
+
+func (s *lowStockService) GetEvents(limit int, outletCode string) ([]models.LowStockEvent, error) {
+	if limit <= 0 || limit > 500 {
+		limit = 100
+	}
+	return s.repo.GetEvents(limit, outletCode)
+}
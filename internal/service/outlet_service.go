@@ -0,0 +1,110 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"kasir-api-go/internal/models"
+	"kasir-api-go/internal/repository"
+	"kasir-api-go/internal/utils"
+	"regexp"
+	"strings"
+)
+
+var outletCodePattern = regexp.MustCompile(`^[A-Za-z0-9_-]{1,20}$`)
+
+type OutletService interface {
+	GetAll() ([]models.Outlet, error)
+	GetByID(id int) (models.Outlet, error)
+	Create(outlet models.Outlet) (models.Outlet, error)
+	Update(id int, outlet models.Outlet) (models.Outlet, error)
+	GetStock(id int) ([]models.OutletStock, error)
+	// Resolve works out the outlet a request is made from: the one the
+	// terminal names in code, the one the user is assigned to, or the
+	// default outlet, in that order. An assigned user cannot act for another
+	// outlet.
+	Resolve(user models.User, code string) (models.Outlet, error)
+	// EnsureDefault creates the default outlet when it does not exist yet.
+	EnsureDefault() error
+}
+
+type outletService struct {
+	repo        repository.OutletRepository
+	defaultCode string
+}
+
+func NewOutletService(repo repository.OutletRepository, defaultCode string) OutletService {
+	return &outletService{
+		repo:        repo,
+		defaultCode: defaultCode,
+	}
+}
+
+func (s *outletService) GetAll() ([]models.Outlet, error) {
+	return s.repo.GetAll()
+}
+
+func (s *outletService) GetByID(id int) (models.Outlet, error) {
+	return s.repo.GetByID(id)
+}
+
+func (s *outletService) Create(outlet models.Outlet) (models.Outlet, error) {
+	outlet.Code = strings.TrimSpace(outlet.Code)
+	if !outletCodePattern.MatchString(outlet.Code) {
+		return models.Outlet{}, fmt.Errorf("%w: code must be 1-20 letters, digits, dashes or underscores", utils.ErrInvalidOutlet)
+	}
+	outlet, err := normalizeOutlet(outlet)
+	if err != nil {
+		return models.Outlet{}, err
+	}
+	return s.repo.Create(outlet)
+}
+
+func (s *outletService) Update(id int, outlet models.Outlet) (models.Outlet, error) {
+	outlet, err := normalizeOutlet(outlet)
+	if err != nil {
+		return models.Outlet{}, err
+	}
+	return s.repo.Update(id, outlet)
+}
+
+func (s *outletService) GetStock(id int) ([]models.OutletStock, error) {
+	if _, err := s.repo.GetByID(id); err != nil {
+		return nil, err
+	}
+	return s.repo.GetStock(id)
+}
+
+func (s *outletService) Resolve(user models.User, code string) (models.Outlet, error) {
+	code = strings.TrimSpace(code)
+	if user.OutletID != nil {
+		if code != "" && code != user.OutletCode {
+			return models.Outlet{}, utils.ErrOutletForbidden
+		}
+		return s.repo.GetByID(*user.OutletID)
+	}
+	if code == "" {
+		code = s.defaultCode
+	}
+	return s.repo.GetByCode(code)
+}
+
+func (s *outletService) EnsureDefault() error {
+	_, err := s.repo.GetByCode(s.defaultCode)
+	if !errors.Is(err, utils.ErrOutletNotFound) {
+		return err
+	}
+	_, err = s.repo.Create(models.Outlet{Code: s.defaultCode, Name: s.defaultCode})
+	if errors.Is(err, utils.ErrOutletExists) {
+		return nil
+	}
+	return err
+}
+
+func normalizeOutlet(outlet models.Outlet) (models.Outlet, error) {
+	outlet.Name = strings.TrimSpace(outlet.Name)
+	outlet.Address = strings.TrimSpace(outlet.Address)
+	if outlet.Name == "" {
+		return outlet, fmt.Errorf("%w: name is required", utils.ErrInvalidOutlet)
+	}
+	return outlet, nil
+}
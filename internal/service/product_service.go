@@ -2,27 +2,44 @@ package service
 
 import (
 	"errors"
+	"fmt"
 	"kasir-api-go/internal/models"
 	"kasir-api-go/internal/repository"
+	"kasir-api-go/internal/utils"
+	"strings"
 )
 
 type ProductService interface {
 	GetAll(search string) []models.Product
 	GetByID(id int) (models.Product, error)
-	Create(product models.Product) (models.Product, error)
-	Update(id int, product models.Product) (models.Product, error)
+	GetVariants(id int) ([]models.Product, error)
+	// Create puts the opening stock in at the outlet with outletID.
+	Create(product models.Product, actor models.User, outletID int) (models.Product, error)
+	Update(id int, req models.UpdateProductRequest) (models.Product, error)
 	Delete(id int) error
+	GetStockHistory(id int) ([]models.StockMovement, error)
+	GetBatches(id int) ([]models.StockBatch, error)
 }
 
 type productService struct {
 	productRepo  repository.ProductRepository
 	categoryRepo repository.CategoryRepository
+	taxRateRepo  repository.TaxRateRepository
+	stockRepo    repository.StockMovementRepository
+	batchRepo    repository.StockBatchRepository
+	authService  AuthService
 }
 
-func NewProductService(productRepo repository.ProductRepository, categoryRepo repository.CategoryRepository) ProductService {
+func NewProductService(productRepo repository.ProductRepository, categoryRepo repository.CategoryRepository, taxRateRepo repository.TaxRateRepository,
+	stockRepo repository.StockMovementRepository, batchRepo repository.StockBatchRepository,
+	authService AuthService) ProductService {
 	return &productService{
 		productRepo:  productRepo,
 		categoryRepo: categoryRepo,
+		taxRateRepo:  taxRateRepo,
+		stockRepo:    stockRepo,
+		batchRepo:    batchRepo,
+		authService:  authService,
 	}
 }
 
@@ -33,17 +50,56 @@ func (s *productService) GetAll(search string) []models.Product {
 func (s *productService) GetByID(id int) (models.Product, error) {
 	product, found := s.productRepo.GetByID(id)
 	if !found {
-		return models.Product{}, errors.New("product not found")
+		return models.Product{}, utils.ErrProductNotFound
 	}
 	return product, nil
 }
 
-func (s *productService) Create(product models.Product) (models.Product, error) {
+func (s *productService) GetVariants(id int) ([]models.Product, error) {
+	if _, found := s.productRepo.GetByID(id); !found {
+		return nil, utils.ErrProductNotFound
+	}
+	return s.productRepo.GetVariants(id), nil
+}
+
+func (s *productService) Create(product models.Product, actor models.User, outletID int) (models.Product, error) {
 	// Validation: Duplicate ID
 	if _, found := s.productRepo.GetByID(product.ID); found {
 		return models.Product{}, errors.New("product ID already exists")
 	}
 
+	if product.Cost < 0 {
+		return models.Product{}, errors.New("cost cannot be negative")
+	}
+
+	if product.MinStock < 0 || product.ReorderQuantity < 0 {
+		return models.Product{}, errors.New("min_stock and reorder_quantity cannot be negative")
+	}
+
+	// A variant takes its name, category and tax rate from its parent, and
+	// its price too unless it has one of its own
+	if product.ParentID != nil {
+		parent, found := s.productRepo.GetByID(*product.ParentID)
+		if !found {
+			return models.Product{}, errors.New("parent product not found")
+		}
+		if parent.ParentID != nil {
+			return models.Product{}, errors.New("a variant cannot have variants")
+		}
+		if err := s.setVariantOf(&product, parent); err != nil {
+			return models.Product{}, err
+		}
+		if product.Price == 0 {
+			product.Price = parent.Price
+		}
+	} else {
+		product.VariantName = ""
+	}
+
+	if err := s.checkCodes(&product); err != nil {
+		return models.Product{}, err
+	}
+
 	// Validation: Category existence
 	if product.Category != nil {
 		if _, found := s.categoryRepo.GetByID(product.Category.ID); !found {
@@ -51,11 +107,30 @@ func (s *productService) Create(product models.Product) (models.Product, error)
 		}
 	}
 
-	s.productRepo.Create(product)
+	// Validation: Tax rate existence
+	if product.TaxRateID != nil {
+		if _, err := s.taxRateRepo.GetByID(*product.TaxRateID); err != nil {
+			return models.Product{}, errors.New("tax rate not found")
+		}
+	}
+
+	product.Stocks = nil
+	product.Variants = nil
+	if err := s.productRepo.Create(product, outletID, actor.ID); err != nil {
+		return models.Product{}, fmt.Errorf("%w: %v", utils.ErrProductNotSaved, err)
+	}
 	return product, nil
 }
 
-func (s *productService) Update(id int, product models.Product) (models.Product, error) {
+// Update saves a product. A price change needs a role allowing price
+// overrides or a supervisor's approval, and is written to the audit trail.
+func (s *productService) Update(id int, req models.UpdateProductRequest) (models.Product, error) {
+	product := req.Product
+
+	if product.MinStock < 0 || product.ReorderQuantity < 0 {
+		return models.Product{}, errors.New("min_stock and reorder_quantity cannot be negative")
+	}
+
 	// Validation: Category existence
 	if product.Category != nil {
 		if _, found := s.categoryRepo.GetByID(product.Category.ID); !found {
@@ -63,15 +138,124 @@ func (s *productService) Update(id int, product models.Product) (models.Product,
 		}
 	}
 
-	if ok := s.productRepo.Update(id, product); !ok {
-		return models.Product{}, errors.New("product not found")
+	// Validation: Tax rate existence
+	if product.TaxRateID != nil {
+		if _, err := s.taxRateRepo.GetByID(*product.TaxRateID); err != nil {
+			return models.Product{}, errors.New("tax rate not found")
+		}
+	}
+
+	current, found := s.productRepo.GetByID(id)
+	if !found {
+		return models.Product{}, utils.ErrProductNotFound
+	}
+
+	product.ID = id
+
+	// A product stays a variant of the parent it was created under
+	product.ParentID = current.ParentID
+	if current.ParentID != nil {
+		parent, found := s.productRepo.GetByID(*current.ParentID)
+		if !found {
+			return models.Product{}, errors.New("parent product not found")
+		}
+		if strings.TrimSpace(product.VariantName) == "" {
+			product.VariantName = current.VariantName
+		}
+		if err := s.setVariantOf(&product, parent); err != nil {
+			return models.Product{}, err
+		}
+	} else {
+		product.VariantName = ""
+	}
+
+	if err := s.checkCodes(&product); err != nil {
+		return models.Product{}, err
+	}
+
+	// The cost is kept by goods receipts once the product exists
+	product.Cost = current.Cost
+	product.Stocks = nil
+	product.Variants = nil
+
+	// The approval is recorded with the price change, in the same database
+	// transaction
+	var approval *models.Approval
+	if product.Price != current.Price {
+		approver, tokenID, err := s.authService.Authorize(req.Actor, models.PermissionPricesOverride, req.Approval)
+		if err != nil {
+			return models.Product{}, err
+		}
+		details := fmt.Sprintf("%s: %d -> %d", current.Name, current.Price, product.Price)
+		a := newApproval(models.ApprovalActionPriceChange, req.Actor, approver, tokenID, details)
+		a.ProductID = &id
+		approval = &a
+	}
+
+	err := s.productRepo.Update(id, product, req.Stock, req.OutletID, req.Actor.ID, approval)
+	if errors.Is(err, utils.ErrProductNotFound) || errors.Is(err, utils.ErrApprovalDenied) {
+		return models.Product{}, err
+	}
+	if err != nil {
+		return models.Product{}, fmt.Errorf("%w: %v", utils.ErrProductNotSaved, err)
+	}
+	if updated, found := s.productRepo.GetByID(id); found {
+		return updated, nil
 	}
 	return product, nil
 }
 
 func (s *productService) Delete(id int) error {
-	if ok := s.productRepo.Delete(id); !ok {
-		return errors.New("product not found")
+	return s.productRepo.Delete(id)
+}
+
+func (s *productService) GetStockHistory(id int) ([]models.StockMovement, error) {
+	if _, found := s.productRepo.GetByID(id); !found {
+		return nil, utils.ErrProductNotFound
+	}
+	return s.stockRepo.GetByProduct(id)
+}
+
+func (s *productService) GetBatches(id int) ([]models.StockBatch, error) {
+	if _, found := s.productRepo.GetByID(id); !found {
+		return nil, utils.ErrProductNotFound
+	}
+	return s.batchRepo.GetByProduct(id)
+}
+
+// setVariantOf makes product the variant of parent named by its
+// VariantName, which must be unique among the variants of parent.
+func (s *productService) setVariantOf(product *models.Product, parent models.Product) error {
+	product.VariantName = strings.TrimSpace(product.VariantName)
+	if product.VariantName == "" {
+		return errors.New("variant_name is required for a variant")
+	}
+	for _, v := range parent.Variants {
+		if v.ID != product.ID && strings.EqualFold(v.VariantName, product.VariantName) {
+			return errors.New("variant name already exists")
+		}
+	}
+
+	product.Name = models.VariantProductName(parent.Name, product.VariantName)
+	product.Category = parent.Category
+	product.TaxRateID = parent.TaxRateID
+	return nil
+}
+
+// checkCodes trims the SKU and barcode of product and fails when another
+// product already uses either of them.
+func (s *productService) checkCodes(product *models.Product) error {
+	product.SKU = strings.TrimSpace(product.SKU)
+	product.Barcode = strings.TrimSpace(product.Barcode)
+	if product.SKU != "" {
+		if other, found := s.productRepo.GetByCode(product.SKU); found && other.ID != product.ID {
+			return errors.New("SKU already exists")
+		}
+	}
+	if product.Barcode != "" {
+		if other, found := s.productRepo.GetByCode(product.Barcode); found && other.ID != product.ID {
+			return errors.New("barcode already exists")
+		}
 	}
 	return nil
 }
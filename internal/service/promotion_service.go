@@ -0,0 +1,125 @@
+package service
+
+import (
+	"fmt"
+	"kasir-api-go/internal/models"
+	"kasir-api-go/internal/repository"
+	"kasir-api-go/internal/utils"
+	"strings"
+	"time"
+)
+
+type PromotionService interface {
+	GetAll() ([]models.Promotion, error)
+	GetByID(id int) (models.Promotion, error)
+	Create(promotion models.Promotion) (models.Promotion, error)
+	Update(id int, promotion models.Promotion) (models.Promotion, error)
+	Delete(id int) error
+}
+
+type promotionService struct {
+	repo         repository.PromotionRepository
+	productRepo  repository.ProductRepository
+	categoryRepo repository.CategoryRepository
+}
+
+func NewPromotionService(repo repository.PromotionRepository, productRepo repository.ProductRepository, categoryRepo repository.CategoryRepository) PromotionService {
+	return &promotionService{
+		repo:         repo,
+		productRepo:  productRepo,
+		categoryRepo: categoryRepo,
+	}
+}
+
+func (s *promotionService) GetAll() ([]models.Promotion, error) {
+	return s.repo.GetAll()
+}
+
+func (s *promotionService) GetByID(id int) (models.Promotion, error) {
+	return s.repo.GetByID(id)
+}
+
+func (s *promotionService) Create(promotion models.Promotion) (models.Promotion, error) {
+	if err := s.validate(promotion); err != nil {
+		return models.Promotion{}, err
+	}
+	return s.repo.Create(promotion)
+}
+
+func (s *promotionService) Update(id int, promotion models.Promotion) (models.Promotion, error) {
+	if err := s.validate(promotion); err != nil {
+		return models.Promotion{}, err
+	}
+	return s.repo.Update(id, promotion)
+}
+
+func (s *promotionService) Delete(id int) error {
+	return s.repo.Delete(id)
+}
+
+func (s *promotionService) validate(p models.Promotion) error {
+	invalid := func(reason string) error {
+		return fmt.Errorf("%w: %s", utils.ErrInvalidPromotion, reason)
+	}
+
+	if strings.TrimSpace(p.Name) == "" {
+		return invalid("name is required")
+	}
+
+	switch p.Scope {
+	case models.PromotionScopeItem, models.PromotionScopeBasket:
+	default:
+		return invalid("scope must be item or basket")
+	}
+
+	switch p.Type {
+	case models.PromotionTypePercentage:
+		if p.Value <= 0 || p.Value > 100 {
+			return invalid("percentage value must be between 1 and 100")
+		}
+	case models.PromotionTypeFixedAmount:
+		if p.Value <= 0 {
+			return invalid("fixed amount value must be greater than zero")
+		}
+	case models.PromotionTypeBuyXGetY:
+		if p.Scope != models.PromotionScopeItem {
+			return invalid("buy_x_get_y promotions must have item scope")
+		}
+		if p.BuyQuantity <= 0 || p.FreeQuantity <= 0 {
+			return invalid("buy_quantity and free_quantity must be greater than zero")
+		}
+	default:
+		return invalid("type must be percentage, fixed_amount or buy_x_get_y")
+	}
+
+	if p.Scope == models.PromotionScopeBasket && (p.ProductID != nil || p.CategoryID != nil) {
+		return invalid("basket promotions cannot target a product or category")
+	}
+	if p.ProductID != nil {
+		if _, found := s.productRepo.GetByID(*p.ProductID); !found {
+			return invalid("product not found")
+		}
+	}
+	if p.CategoryID != nil {
+		if _, found := s.categoryRepo.GetByID(*p.CategoryID); !found {
+			return invalid("category not found")
+		}
+	}
+
+	if p.StartDate != nil && p.EndDate != nil && !p.EndDate.After(*p.StartDate) {
+		return invalid("end_date must be after start_date")
+	}
+	if (p.StartTime == "") != (p.EndTime == "") {
+		return invalid("start_time and end_time must be set together")
+	}
+	for _, clock := range []string{p.StartTime, p.EndTime} {
+		if clock == "" {
+			continue
+		}
+		if _, err := time.Parse("15:04", clock); err != nil {
+			return invalid("start_time and end_time must use HH:MM")
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,164 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"kasir-api-go/internal/models"
+	"kasir-api-go/internal/repository"
+	"kasir-api-go/internal/utils"
+	"strings"
+	"time"
+)
+
+type PurchaseOrderService interface {
+	// Create places an order delivered to req.OutletID, or to the outlet
+	// with outletID when the request names none.
+	Create(req models.CreatePurchaseOrderRequest, actor models.User, outletID int) (models.PurchaseOrder, error)
+	GetAll(status models.PurchaseOrderStatus) ([]models.PurchaseOrder, error)
+	GetOutstanding() ([]models.PurchaseOrder, error)
+	GetByID(id int) (models.PurchaseOrder, error)
+	Receive(id int, req models.ReceiveGoodsRequest, actor models.User) (models.PurchaseOrder, error)
+	Return(id int, req models.PurchaseReturnRequest, actor models.User) (models.PurchaseOrder, error)
+	Cancel(id int) (models.PurchaseOrder, error)
+}
+
+type purchaseOrderService struct {
+	repo         repository.PurchaseOrderRepository
+	supplierRepo repository.SupplierRepository
+	outletRepo   repository.OutletRepository
+}
+
+func NewPurchaseOrderService(repo repository.PurchaseOrderRepository, supplierRepo repository.SupplierRepository,
+	outletRepo repository.OutletRepository) PurchaseOrderService {
+	return &purchaseOrderService{
+		repo:         repo,
+		supplierRepo: supplierRepo,
+		outletRepo:   outletRepo,
+	}
+}
+
+func (s *purchaseOrderService) Create(req models.CreatePurchaseOrderRequest, actor models.User, outletID int) (models.PurchaseOrder, error) {
+	if _, err := s.supplierRepo.GetByID(req.SupplierID); err != nil {
+		if errors.Is(err, utils.ErrSupplierNotFound) {
+			return models.PurchaseOrder{}, fmt.Errorf("%w: supplier %d not found", utils.ErrInvalidPurchaseOrder, req.SupplierID)
+		}
+		return models.PurchaseOrder{}, err
+	}
+
+	if req.OutletID != nil {
+		if _, err := s.outletRepo.GetByID(*req.OutletID); err != nil {
+			if errors.Is(err, utils.ErrOutletNotFound) {
+				return models.PurchaseOrder{}, fmt.Errorf("%w: outlet %d not found", utils.ErrInvalidPurchaseOrder, *req.OutletID)
+			}
+			return models.PurchaseOrder{}, err
+		}
+		outletID = *req.OutletID
+	}
+
+	expectedDate := strings.TrimSpace(req.ExpectedDate)
+	if expectedDate != "" {
+		if _, err := time.Parse("2006-01-02", expectedDate); err != nil {
+			return models.PurchaseOrder{}, fmt.Errorf("%w: expected_date must be YYYY-MM-DD", utils.ErrInvalidPurchaseOrder)
+		}
+	}
+
+	if len(req.Items) == 0 {
+		return models.PurchaseOrder{}, fmt.Errorf("%w: items are required", utils.ErrInvalidPurchaseOrder)
+	}
+	if err := validatePurchaseLines(req.Items, utils.ErrInvalidPurchaseOrder); err != nil {
+		return models.PurchaseOrder{}, err
+	}
+
+	order := models.PurchaseOrder{
+		SupplierID:   req.SupplierID,
+		OutletID:     outletID,
+		ExpectedDate: expectedDate,
+		Note:         strings.TrimSpace(req.Note),
+	}
+	for _, line := range req.Items {
+		if line.UnitCost == nil {
+			return models.PurchaseOrder{}, fmt.Errorf("%w: unit_cost is required", utils.ErrInvalidPurchaseOrder)
+		}
+		productID := line.ProductID
+		order.Items = append(order.Items, models.PurchaseOrderItem{
+			ProductID: &productID,
+			Quantity:  line.Quantity,
+			UnitCost:  *line.UnitCost,
+		})
+	}
+
+	return s.repo.Create(order, actor.ID)
+}
+
+func (s *purchaseOrderService) GetAll(status models.PurchaseOrderStatus) ([]models.PurchaseOrder, error) {
+	return s.repo.GetAll(status)
+}
+
+func (s *purchaseOrderService) GetOutstanding() ([]models.PurchaseOrder, error) {
+	return s.repo.GetOutstanding()
+}
+
+func (s *purchaseOrderService) GetByID(id int) (models.PurchaseOrder, error) {
+	return s.repo.GetByID(id)
+}
+
+func (s *purchaseOrderService) Receive(id int, req models.ReceiveGoodsRequest, actor models.User) (models.PurchaseOrder, error) {
+	if err := validatePurchaseLines(req.Items, utils.ErrInvalidGoodsReceipt); err != nil {
+		return models.PurchaseOrder{}, err
+	}
+	for i := range req.Items {
+		line := &req.Items[i]
+		line.BatchNumber = strings.TrimSpace(line.BatchNumber)
+		if len(line.BatchNumber) > 100 {
+			return models.PurchaseOrder{}, fmt.Errorf("%w: batch_number is too long", utils.ErrInvalidGoodsReceipt)
+		}
+		line.ExpiryDate = strings.TrimSpace(line.ExpiryDate)
+		if line.ExpiryDate != "" {
+			if _, err := time.Parse("2006-01-02", line.ExpiryDate); err != nil {
+				return models.PurchaseOrder{}, fmt.Errorf("%w: expiry_date must be YYYY-MM-DD", utils.ErrInvalidGoodsReceipt)
+			}
+		}
+	}
+	req.Note = strings.TrimSpace(req.Note)
+	return s.repo.Receive(id, req, actor.ID)
+}
+
+func (s *purchaseOrderService) Return(id int, req models.PurchaseReturnRequest, actor models.User) (models.PurchaseOrder, error) {
+	req.Reason = strings.TrimSpace(req.Reason)
+	if req.Reason == "" {
+		return models.PurchaseOrder{}, fmt.Errorf("%w: reason is required", utils.ErrInvalidPurchaseReturn)
+	}
+	if len(req.Items) == 0 {
+		return models.PurchaseOrder{}, fmt.Errorf("%w: items are required", utils.ErrInvalidPurchaseReturn)
+	}
+	if err := validatePurchaseLines(req.Items, utils.ErrInvalidPurchaseReturn); err != nil {
+		return models.PurchaseOrder{}, err
+	}
+	return s.repo.Return(id, req, actor.ID)
+}
+
+func (s *purchaseOrderService) Cancel(id int) (models.PurchaseOrder, error) {
+	return s.repo.Cancel(id)
+}
+
+// validatePurchaseLines checks the lines of an order, a receipt or a return,
+// reporting problems as invalid.
+func validatePurchaseLines(lines []models.PurchaseLineRequest, invalid error) error {
+	seen := make(map[int]bool, len(lines))
+	for _, line := range lines {
+		if line.ProductID <= 0 {
+			return fmt.Errorf("%w: product_id is required", invalid)
+		}
+		if line.Quantity <= 0 {
+			return fmt.Errorf("%w: quantity must be greater than zero", invalid)
+		}
+		if line.UnitCost != nil && *line.UnitCost < 0 {
+			return fmt.Errorf("%w: unit_cost cannot be negative", invalid)
+		}
+		if seen[line.ProductID] {
+			return fmt.Errorf("%w: product %d is listed twice", invalid, line.ProductID)
+		}
+		seen[line.ProductID] = true
+	}
+	return nil
+}
@@ -0,0 +1,409 @@
+package service
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"html/template"
+	"kasir-api-go/internal/config"
+	"kasir-api-go/internal/models"
+	"kasir-api-go/internal/repository"
+	"kasir-api-go/internal/utils"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+const (
+	ReceiptFormatText   = "text"
+	ReceiptFormatESCPOS = "escpos"
+	ReceiptFormatHTML   = "html"
+)
+
+// paperColumns is the number of characters a line holds on each supported
+// thermal paper width (in mm) using the printer's standard font.
+var paperColumns = map[int]int{
+	58: 32,
+	80: 48,
+}
+
+type ReceiptService interface {
+	// Render returns the receipt of a transaction and its content type.
+	Render(transactionID int, format string, paperWidth int) ([]byte, string, error)
+}
+
+type receiptService struct {
+	repo       repository.TransactionRepository
+	outletRepo repository.OutletRepository
+	cfg        config.ReceiptConfig
+}
+
+func NewReceiptService(repo repository.TransactionRepository, outletRepo repository.OutletRepository, cfg config.ReceiptConfig) ReceiptService {
+	return &receiptService{repo: repo, outletRepo: outletRepo, cfg: cfg}
+}
+
+func (s *receiptService) Render(transactionID int, format string, paperWidth int) ([]byte, string, error) {
+	if format == "" {
+		format = ReceiptFormatText
+	}
+	if paperWidth == 0 {
+		paperWidth = s.cfg.PaperWidth
+	}
+	columns, ok := paperColumns[paperWidth]
+	if !ok {
+		return nil, "", utils.ErrInvalidPaperWidth
+	}
+
+	var render func([]receiptLine, int, int) ([]byte, error)
+	var contentType string
+	switch format {
+	case ReceiptFormatText:
+		render, contentType = renderText, "text/plain; charset=utf-8"
+	case ReceiptFormatESCPOS:
+		render, contentType = renderESCPOS, "application/octet-stream"
+	case ReceiptFormatHTML:
+		render, contentType = renderHTML, "text/html; charset=utf-8"
+	default:
+		return nil, "", utils.ErrInvalidReceiptFormat
+	}
+
+	transaction, err := s.repo.GetByID(transactionID)
+	if err != nil {
+		return nil, "", err
+	}
+	header, err := s.header(transaction.OutletCode)
+	if err != nil {
+		return nil, "", err
+	}
+
+	body, err := render(s.lines(transaction, header), columns, paperWidth)
+	if err != nil {
+		return nil, "", err
+	}
+	return body, contentType, nil
+}
+
+// receiptHeader is the store a receipt is printed for.
+type receiptHeader struct {
+	Name    string
+	Address string
+	Phone   string
+}
+
+// header returns the name and address of the outlet that made the sale. The
+// store settings only stand in when the outlet is unknown, and always give
+// the phone, which outlets do not have.
+func (s *receiptService) header(outletCode string) (receiptHeader, error) {
+	header := receiptHeader{Name: s.cfg.StoreName, Address: s.cfg.StoreAddress, Phone: s.cfg.StorePhone}
+
+	outlet, err := s.outletRepo.GetByCode(outletCode)
+	if errors.Is(err, utils.ErrOutletNotFound) {
+		return header, nil
+	}
+	if err != nil {
+		return header, err
+	}
+
+	header.Name, header.Address = outlet.Name, outlet.Address
+	return header, nil
+}
+
+type receiptLine struct {
+	Left   string
+	Right  string
+	Center bool
+	Bold   bool
+	Large  bool
+	Rule   bool
+}
+
+// lines lays out the receipt once so every output format prints the same thing.
+func (s *receiptService) lines(t models.Transaction, header receiptHeader) []receiptLine {
+	var lines []receiptLine
+	center := func(text string, bold, large bool) {
+		if text != "" {
+			lines = append(lines, receiptLine{Left: text, Center: true, Bold: bold, Large: large})
+		}
+	}
+	row := func(left, right string) {
+		lines = append(lines, receiptLine{Left: left, Right: right})
+	}
+	rule := func() {
+		lines = append(lines, receiptLine{Rule: true})
+	}
+
+	center(header.Name, true, true)
+	center(header.Address, false, false)
+	center(header.Phone, false, false)
+	rule()
+
+	row("No", t.InvoiceNumber)
+	row("Date", t.CreatedAt.Format("02/01/2006 15:04"))
+	if t.Status == models.TransactionStatusVoided {
+		center("*** VOID ***", true, false)
+	}
+	rule()
+
+	type taxLine struct {
+		label  string
+		amount int
+	}
+	var taxes []taxLine
+	taxIndex := make(map[string]int)
+	for _, d := range t.Details {
+		row(d.ProductName, "")
+		if d.SKU != "" {
+			row("  "+d.SKU, "")
+		}
+		row(fmt.Sprintf("  %d x %s", d.Quantity, formatAmount(d.UnitPrice)), formatAmount(d.UnitPrice*d.Quantity))
+		if d.DiscountAmount > 0 {
+			row("  Discount", formatAmount(-d.DiscountAmount))
+		}
+		if d.RefundedQuantity > 0 {
+			row(fmt.Sprintf("  Returned %d", d.RefundedQuantity), "")
+		}
+
+		if d.TaxAmount > 0 {
+			label := fmt.Sprintf("%s %s%%", d.TaxName, strconv.FormatFloat(d.TaxRate, 'f', -1, 64))
+			if d.TaxIncluded() {
+				label = "Incl. " + label
+			}
+			if i, ok := taxIndex[label]; ok {
+				taxes[i].amount += d.TaxAmount
+			} else {
+				taxIndex[label] = len(taxes)
+				taxes = append(taxes, taxLine{label: label, amount: d.TaxAmount})
+			}
+		}
+	}
+	rule()
+
+	row("Subtotal", formatAmount(t.GrossAmount))
+	if t.DiscountAmount > 0 {
+		row("Discount", formatAmount(-t.DiscountAmount))
+	}
+	for _, tax := range taxes {
+		row(tax.label, formatAmount(tax.amount))
+	}
+	if t.ServiceCharge > 0 {
+		row("Service Charge", formatAmount(t.ServiceCharge))
+	}
+	lines = append(lines, receiptLine{Left: "TOTAL", Right: formatAmount(t.TotalAmount), Bold: true})
+
+	for _, p := range t.Payments {
+		label := paymentLabel(p.Method)
+		if p.Reference != "" {
+			label += " " + p.Reference
+		}
+		row(label, formatAmount(p.TenderedAmount))
+	}
+	if t.ChangeAmount > 0 {
+		row("Change", formatAmount(t.ChangeAmount))
+	}
+	if t.RefundedAmount > 0 && t.Status != models.TransactionStatusVoided {
+		row("Refunded", formatAmount(-t.RefundedAmount))
+	}
+
+	if s.cfg.Footer != "" {
+		rule()
+		center(s.cfg.Footer, false, false)
+	}
+
+	return lines
+}
+
+func paymentLabel(method models.PaymentMethod) string {
+	switch method {
+	case models.PaymentMethodCash:
+		return "Cash"
+	case models.PaymentMethodDebitCard:
+		return "Debit Card"
+	case models.PaymentMethodQRIS:
+		return "QRIS"
+	case models.PaymentMethodTransfer:
+		return "Transfer"
+	}
+	return string(method)
+}
+
+// formatAmount prints rupiah with dot thousand separators, e.g. 20.000.
+func formatAmount(amount int) string {
+	sign := ""
+	if amount < 0 {
+		sign, amount = "-", -amount
+	}
+
+	digits := strconv.Itoa(amount)
+	var b strings.Builder
+	for i, c := range digits {
+		if i > 0 && (len(digits)-i)%3 == 0 {
+			b.WriteByte('.')
+		}
+		b.WriteRune(c)
+	}
+	return sign + b.String()
+}
+
+// layout turns a line into fixed-width text rows of at most columns
+// characters, wrapping long left text above the right-aligned value.
+func (l receiptLine) layout(columns int) []string {
+	if l.Rule {
+		return []string{strings.Repeat("-", columns)}
+	}
+
+	if l.Center {
+		var rows []string
+		for _, part := range wrap(l.Left, columns) {
+			pad := (columns - utf8.RuneCountInString(part)) / 2
+			rows = append(rows, strings.Repeat(" ", pad)+part)
+		}
+		return rows
+	}
+
+	rightWidth := utf8.RuneCountInString(l.Right)
+	rows := wrap(l.Left, columns)
+	if l.Right == "" {
+		return rows
+	}
+
+	last := rows[len(rows)-1]
+	gap := columns - utf8.RuneCountInString(last) - rightWidth
+	if gap < 1 {
+		return append(rows, strings.Repeat(" ", columns-rightWidth)+l.Right)
+	}
+	rows[len(rows)-1] = last + strings.Repeat(" ", gap) + l.Right
+	return rows
+}
+
+func wrap(text string, columns int) []string {
+	runes := []rune(text)
+	if len(runes) == 0 {
+		return []string{""}
+	}
+
+	var rows []string
+	for len(runes) > columns {
+		rows = append(rows, string(runes[:columns]))
+		runes = runes[columns:]
+	}
+	return append(rows, string(runes))
+}
+
+func renderText(lines []receiptLine, columns, _ int) ([]byte, error) {
+	var b bytes.Buffer
+	for _, line := range lines {
+		for _, row := range line.layout(columns) {
+			b.WriteString(strings.TrimRight(row, " "))
+			b.WriteByte('\n')
+		}
+	}
+	return b.Bytes(), nil
+}
+
+// ESC/POS commands understood by common thermal printers.
+var (
+	escInit        = []byte{0x1B, 0x40}
+	escAlignLeft   = []byte{0x1B, 0x61, 0x00}
+	escAlignCenter = []byte{0x1B, 0x61, 0x01}
+	escBoldOn      = []byte{0x1B, 0x45, 0x01}
+	escBoldOff     = []byte{0x1B, 0x45, 0x00}
+	escSizeDouble  = []byte{0x1D, 0x21, 0x11}
+	escSizeNormal  = []byte{0x1D, 0x21, 0x00}
+	escFeedLines   = []byte{0x1B, 0x64, 0x04}
+	escPartialCut  = []byte{0x1D, 0x56, 0x42, 0x00}
+)
+
+// renderESCPOS produces a byte stream that printer bridges can send to the
+// printer as is. Text outside ASCII is replaced because printer code pages
+// vary.
+func renderESCPOS(lines []receiptLine, columns, _ int) ([]byte, error) {
+	var b bytes.Buffer
+	b.Write(escInit)
+
+	for _, line := range lines {
+		width := columns
+		if line.Center {
+			b.Write(escAlignCenter)
+		} else {
+			b.Write(escAlignLeft)
+		}
+		if line.Bold {
+			b.Write(escBoldOn)
+		}
+		if line.Large {
+			b.Write(escSizeDouble)
+			width = columns / 2
+		}
+
+		// The printer centres by itself, so centred text is sent unpadded
+		rows := line.layout(width)
+		if line.Center {
+			rows = wrap(line.Left, width)
+		}
+		for _, row := range rows {
+			b.WriteString(asciiOnly(strings.TrimRight(row, " ")))
+			b.WriteByte('\n')
+		}
+
+		if line.Large {
+			b.Write(escSizeNormal)
+		}
+		if line.Bold {
+			b.Write(escBoldOff)
+		}
+	}
+
+	b.Write(escFeedLines)
+	b.Write(escPartialCut)
+	return b.Bytes(), nil
+}
+
+func asciiOnly(text string) string {
+	return strings.Map(func(r rune) rune {
+		if r < 0x20 || r > 0x7E {
+			return '?'
+		}
+		return r
+	}, text)
+}
+
+var receiptTemplate = template.Must(template.New("receipt").Parse(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>Receipt</title>
+<style>
+body { margin: 0; }
+.receipt { width: {{.Width}}mm; padding: 2mm; font-family: monospace; font-size: 12px; }
+.row { display: flex; justify-content: space-between; gap: 1em; }
+.center { justify-content: center; text-align: center; }
+.bold { font-weight: bold; }
+.large { font-size: 18px; }
+hr { border: 0; border-top: 1px dashed #000; }
+</style>
+</head>
+<body>
+<div class="receipt">
+{{- range .Lines}}
+{{- if .Rule}}
+<hr>
+{{- else}}
+<div class="row{{if .Center}} center{{end}}{{if .Bold}} bold{{end}}{{if .Large}} large{{end}}"><span>{{.Left}}</span>{{if .Right}}<span>{{.Right}}</span>{{end}}</div>
+{{- end}}
+{{- end}}
+</div>
+</body>
+</html>
+`))
+
+func renderHTML(lines []receiptLine, _, paperWidth int) ([]byte, error) {
+	var b bytes.Buffer
+	err := receiptTemplate.Execute(&b, struct {
+		Width int
+		Lines []receiptLine
+	}{Width: paperWidth, Lines: lines})
+	if err != nil {
+		return nil, err
+	}
+	return b.Bytes(), nil
+}
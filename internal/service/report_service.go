@@ -3,31 +3,72 @@ package service
 import (
 	"kasir-api-go/internal/models"
 	"kasir-api-go/internal/repository"
+	"kasir-api-go/internal/utils"
 	"time"
 )
 
+// ReportService builds the reports of the outlet with the given code, or of
+// every outlet when it is empty.
 type ReportService interface {
-	GetTodayReport() (models.SalesReport, error)
-	GetReportByRange(startDate, endDate time.Time) (models.SalesReport, error)
+	GetTodayReport(outletCode string) (models.SalesReport, error)
+	GetReportByRange(startDate, endDate time.Time, outletCode string) (models.SalesReport, error)
+	GetInventoryValuation(outletCode string) (models.InventoryValuation, error)
+	GetProfitReport(startDate, endDate time.Time, groupBy models.ProfitGrouping, outletCode string) (models.ProfitReport, error)
 }
 
 type reportService struct {
-	repo repository.ReportRepository
+	repo          repository.ReportRepository
+	costingMethod models.CostingMethod
 }
 
-func NewReportService(repo repository.ReportRepository) ReportService {
-	return &reportService{repo: repo}
+func NewReportService(repo repository.ReportRepository, costingMethod models.CostingMethod) ReportService {
+	return &reportService{repo: repo, costingMethod: costingMethod}
 }
 
-func (s *reportService) GetTodayReport() (models.SalesReport, error) {
+func (s *reportService) GetTodayReport(outletCode string) (models.SalesReport, error) {
 	now := time.Now()
 	startDate := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
 	endDate := startDate.Add(24 * time.Hour)
-	return s.repo.GetSalesReport(startDate, endDate)
+	return s.repo.GetSalesReport(startDate, endDate, outletCode)
 }
 
-func (s *reportService) GetReportByRange(startDate, endDate time.Time) (models.SalesReport, error) {
+func (s *reportService) GetReportByRange(startDate, endDate time.Time, outletCode string) (models.SalesReport, error) {
 	// Adjust endDate to include the whole day if only date is provided
 	endDate = endDate.Add(24 * time.Hour)
-	return s.repo.GetSalesReport(startDate, endDate)
+	return s.repo.GetSalesReport(startDate, endDate, outletCode)
+}
+
+func (s *reportService) GetInventoryValuation(outletCode string) (models.InventoryValuation, error) {
+	return s.repo.GetInventoryValuation(s.costingMethod, outletCode)
+}
+
+// GetProfitReport works out the gross profit between startDate and endDate,
+// both inclusive, optionally broken down by product, category or day.
+func (s *reportService) GetProfitReport(startDate, endDate time.Time, groupBy models.ProfitGrouping,
+	outletCode string) (models.ProfitReport, error) {
+	if groupBy != "" && !groupBy.IsValid() {
+		return models.ProfitReport{}, utils.ErrInvalidProfitGrouping
+	}
+
+	lines, err := s.repo.GetProfitLines(startDate, endDate.Add(24*time.Hour), groupBy, outletCode)
+	if err != nil {
+		return models.ProfitReport{}, err
+	}
+
+	report := models.ProfitReport{
+		StartDate: startDate.Format("2006-01-02"),
+		EndDate:   endDate.Format("2006-01-02"),
+		GroupBy:   groupBy,
+		Outlet:    outletCode,
+	}
+	for _, line := range lines {
+		report.Revenue += line.Revenue
+		report.COGS += line.COGS
+	}
+	report.GrossProfit = report.Revenue - report.COGS
+	report.Margin = models.MarginPercent(report.GrossProfit, report.Revenue)
+	if groupBy != "" {
+		report.Lines = lines
+	}
+	return report, nil
 }
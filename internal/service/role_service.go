@@ -0,0 +1,89 @@
+package service
+
+import (
+	"fmt"
+	"kasir-api-go/internal/models"
+	"kasir-api-go/internal/repository"
+	"kasir-api-go/internal/utils"
+	"regexp"
+	"strings"
+)
+
+var roleNamePattern = regexp.MustCompile(`^[a-z0-9_-]{2,20}$`)
+
+type RoleService interface {
+	GetAll() ([]models.Role, error)
+	GetByName(name models.UserRole) (models.Role, error)
+	Create(role models.Role) (models.Role, error)
+	Update(name models.UserRole, role models.Role) (models.Role, error)
+	Delete(name models.UserRole) error
+}
+
+type roleService struct {
+	repo repository.RoleRepository
+}
+
+func NewRoleService(repo repository.RoleRepository) RoleService {
+	return &roleService{repo: repo}
+}
+
+func (s *roleService) GetAll() ([]models.Role, error) {
+	return s.repo.GetAll()
+}
+
+func (s *roleService) GetByName(name models.UserRole) (models.Role, error) {
+	return s.repo.GetByName(name)
+}
+
+func (s *roleService) Create(role models.Role) (models.Role, error) {
+	role.Name = models.UserRole(strings.ToLower(strings.TrimSpace(string(role.Name))))
+	if !roleNamePattern.MatchString(string(role.Name)) {
+		return models.Role{}, fmt.Errorf("%w: name must be 2-20 lowercase letters, digits, dashes or underscores", utils.ErrInvalidRole)
+	}
+	if err := normalizeRole(&role); err != nil {
+		return models.Role{}, err
+	}
+	return s.repo.Create(role)
+}
+
+// Update replaces the description and permissions of a role. Users holding
+// the role get the new permissions on their next request.
+func (s *roleService) Update(name models.UserRole, role models.Role) (models.Role, error) {
+	if name == models.UserRoleOwner {
+		return models.Role{}, utils.ErrRoleLocked
+	}
+	role.Name = name
+	if err := normalizeRole(&role); err != nil {
+		return models.Role{}, err
+	}
+	return s.repo.Update(role)
+}
+
+func (s *roleService) Delete(name models.UserRole) error {
+	if name == models.UserRoleOwner {
+		return utils.ErrRoleLocked
+	}
+	return s.repo.Delete(name)
+}
+
+// normalizeRole trims the description and checks and de-duplicates the
+// permissions, keeping them in the order of models.Permissions.
+func normalizeRole(role *models.Role) error {
+	role.Description = strings.TrimSpace(role.Description)
+
+	granted := make(map[models.Permission]bool, len(role.Permissions))
+	for _, p := range role.Permissions {
+		if !p.IsValid() {
+			return fmt.Errorf("%w: unknown permission %q", utils.ErrInvalidRole, p)
+		}
+		granted[p] = true
+	}
+
+	role.Permissions = []models.Permission{}
+	for _, p := range models.Permissions {
+		if granted[p] {
+			role.Permissions = append(role.Permissions, p)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,130 @@
+package service
+
+import (
+	"fmt"
+	"kasir-api-go/internal/models"
+	"kasir-api-go/internal/repository"
+	"kasir-api-go/internal/utils"
+	"strings"
+)
+
+type ShiftService interface {
+	// Open starts a shift for the actor on the till of the outlet with the
+	// given code.
+	Open(req models.OpenShiftRequest, outletCode string, actor models.User) (models.Shift, error)
+	GetAll() ([]models.Shift, error)
+	GetByID(id int) (models.Shift, error)
+	GetCurrent(outletCode string) (models.Shift, error)
+	// AddCashMovement and Close only act on a shift of the outlet with the
+	// given code.
+	AddCashMovement(shiftID int, req models.CashMovementRequest, outletCode string, actor models.User) (models.CashMovement, error)
+	Close(id int, req models.CloseShiftRequest, outletCode string) (models.Shift, error)
+}
+
+type shiftService struct {
+	repo       repository.ShiftRepository
+	reportRepo repository.ReportRepository
+}
+
+func NewShiftService(repo repository.ShiftRepository, reportRepo repository.ReportRepository) ShiftService {
+	return &shiftService{
+		repo:       repo,
+		reportRepo: reportRepo,
+	}
+}
+
+func (s *shiftService) Open(req models.OpenShiftRequest, outletCode string, actor models.User) (models.Shift, error) {
+	if req.OpeningFloat < 0 {
+		return models.Shift{}, fmt.Errorf("%w: opening_float cannot be negative", utils.ErrInvalidShift)
+	}
+
+	return s.repo.Open(models.Shift{
+		OutletCode:   outletCode,
+		CashierID:    &actor.ID,
+		CashierName:  actor.Name,
+		OpeningFloat: req.OpeningFloat,
+	})
+}
+
+func (s *shiftService) GetAll() ([]models.Shift, error) {
+	return s.repo.GetAll()
+}
+
+// GetByID returns the shift with its drawer summary, which for an open shift
+// is the running total so far.
+func (s *shiftService) GetByID(id int) (models.Shift, error) {
+	shift, err := s.repo.GetByID(id)
+	if err != nil {
+		return shift, err
+	}
+	return s.withSummary(shift)
+}
+
+func (s *shiftService) GetCurrent(outletCode string) (models.Shift, error) {
+	shift, err := s.repo.GetOpen(outletCode)
+	if err != nil {
+		return shift, err
+	}
+	return s.withSummary(shift)
+}
+
+func (s *shiftService) AddCashMovement(shiftID int, req models.CashMovementRequest, outletCode string, actor models.User) (models.CashMovement, error) {
+	reason := strings.TrimSpace(req.Reason)
+	if (req.Type != models.CashMovementIn && req.Type != models.CashMovementOut) || req.Amount <= 0 || reason == "" {
+		return models.CashMovement{}, utils.ErrInvalidCashMovement
+	}
+	if err := s.checkOutlet(shiftID, outletCode); err != nil {
+		return models.CashMovement{}, err
+	}
+
+	return s.repo.AddCashMovement(shiftID, actor.ID, models.CashMovement{
+		Type:   req.Type,
+		Amount: req.Amount,
+		Reason: reason,
+	})
+}
+
+func (s *shiftService) Close(id int, req models.CloseShiftRequest, outletCode string) (models.Shift, error) {
+	if req.CountedCash < 0 {
+		return models.Shift{}, fmt.Errorf("%w: counted_cash cannot be negative", utils.ErrInvalidShift)
+	}
+	if err := s.checkOutlet(id, outletCode); err != nil {
+		return models.Shift{}, err
+	}
+
+	shift, err := s.repo.Close(id, req.CountedCash, strings.TrimSpace(req.Note))
+	if err != nil {
+		return shift, err
+	}
+	return s.withSummary(shift)
+}
+
+// checkOutlet keeps a till to the drawer of its own outlet. The outlet never
+// changes once a shift is open, so the drawer change that follows can rely on
+// it.
+func (s *shiftService) checkOutlet(shiftID int, outletCode string) error {
+	shift, err := s.repo.GetByID(shiftID)
+	if err != nil {
+		return err
+	}
+	if shift.OutletCode != outletCode {
+		return utils.ErrShiftOtherOutlet
+	}
+	return nil
+}
+
+func (s *shiftService) withSummary(shift models.Shift) (models.Shift, error) {
+	summary, err := s.repo.CashSummary(shift.ID)
+	if err != nil {
+		return shift, err
+	}
+
+	summary.Sales, err = s.reportRepo.GetShiftSalesReport(shift.ID)
+	if err != nil {
+		return shift, err
+	}
+
+	summary.Reconcile(shift.CountedCash)
+	shift.Summary = &summary
+	return shift, nil
+}
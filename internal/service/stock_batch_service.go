@@ -0,0 +1,38 @@
+package service
+
+import (
+	"kasir-api-go/internal/models"
+	"kasir-api-go/internal/repository"
+	"time"
+)
+
+type StockBatchService interface {
+	// GetExpiring lists the batches in stock expiring within days days at
+	// the outlet with the given code, or at every outlet if it is empty. A
+	// days of 0 uses the configured warning period.
+	GetExpiring(days int, outletCode string) (models.ExpiringBatches, error)
+}
+
+type stockBatchService struct {
+	repo        repository.StockBatchRepository
+	warningDays int
+}
+
+func NewStockBatchService(repo repository.StockBatchRepository, warningDays int) StockBatchService {
+	return &stockBatchService{
+		repo:        repo,
+		warningDays: warningDays,
+	}
+}
+
+func (s *stockBatchService) GetExpiring(days int, outletCode string) (models.ExpiringBatches, error) {
+	if days <= 0 {
+		days = s.warningDays
+	}
+
+	batches, err := s.repo.GetExpiring(time.Now().AddDate(0, 0, days), outletCode)
+	if err != nil {
+		return models.ExpiringBatches{}, err
+	}
+	return models.ExpiringBatches{Days: days, Outlet: outletCode, Batches: batches}, nil
+}
@@ -0,0 +1,102 @@
+package service
+
+import (
+	"fmt"
+	"kasir-api-go/internal/models"
+	"kasir-api-go/internal/repository"
+	"kasir-api-go/internal/utils"
+	"strings"
+)
+
+type StockTakeService interface {
+	// Open starts a count at the outlet with outletID.
+	Open(req models.OpenStockTakeRequest, actor models.User, outletID int) (models.StockTake, error)
+	GetAll() ([]models.StockTake, error)
+	GetByID(id int) (models.StockTake, error)
+	SubmitCounts(id int, req models.StockCountRequest, actor models.User) (models.StockTake, error)
+	Approve(id int, actor models.User) (models.StockTake, error)
+	Cancel(id int, actor models.User) (models.StockTake, error)
+}
+
+type stockTakeService struct {
+	repo repository.StockTakeRepository
+}
+
+func NewStockTakeService(repo repository.StockTakeRepository) StockTakeService {
+	return &stockTakeService{
+		repo: repo,
+	}
+}
+
+func (s *stockTakeService) Open(req models.OpenStockTakeRequest, actor models.User, outletID int) (models.StockTake, error) {
+	return s.reconciled(s.repo.Open(strings.TrimSpace(req.Note), outletID, actor.ID))
+}
+
+func (s *stockTakeService) GetAll() ([]models.StockTake, error) {
+	return s.repo.GetAll()
+}
+
+// GetByID returns the count with its variances, which for an open count
+// cover the products counted so far.
+func (s *stockTakeService) GetByID(id int) (models.StockTake, error) {
+	return s.reconciled(s.repo.GetByID(id))
+}
+
+func (s *stockTakeService) SubmitCounts(id int, req models.StockCountRequest, actor models.User) (models.StockTake, error) {
+	if len(req.Items) == 0 {
+		return models.StockTake{}, fmt.Errorf("%w: items are required", utils.ErrInvalidStockCount)
+	}
+
+	seen := make(map[int]bool, len(req.Items))
+	for _, item := range req.Items {
+		if item.ProductID <= 0 {
+			return models.StockTake{}, fmt.Errorf("%w: product_id is required", utils.ErrInvalidStockCount)
+		}
+		if item.CountedQuantity < 0 {
+			return models.StockTake{}, fmt.Errorf("%w: counted_quantity cannot be negative", utils.ErrInvalidStockCount)
+		}
+		if seen[item.ProductID] {
+			return models.StockTake{}, fmt.Errorf("%w: product %d is listed twice", utils.ErrInvalidStockCount, item.ProductID)
+		}
+		seen[item.ProductID] = true
+	}
+
+	if err := s.checkOutlet(id, actor); err != nil {
+		return models.StockTake{}, err
+	}
+	if err := s.repo.SubmitCounts(id, req.Items, actor.ID); err != nil {
+		return models.StockTake{}, err
+	}
+	return s.GetByID(id)
+}
+
+func (s *stockTakeService) Approve(id int, actor models.User) (models.StockTake, error) {
+	if err := s.checkOutlet(id, actor); err != nil {
+		return models.StockTake{}, err
+	}
+	return s.reconciled(s.repo.Approve(id, actor.ID))
+}
+
+func (s *stockTakeService) Cancel(id int, actor models.User) (models.StockTake, error) {
+	if err := s.checkOutlet(id, actor); err != nil {
+		return models.StockTake{}, err
+	}
+	return s.reconciled(s.repo.Cancel(id, actor.ID))
+}
+
+// checkOutlet keeps a user assigned to an outlet to the counts of that outlet.
+func (s *stockTakeService) checkOutlet(id int, actor models.User) error {
+	take, err := s.repo.GetByID(id)
+	if err != nil {
+		return err
+	}
+	return checkAssignedOutlet(actor, take.OutletID)
+}
+
+func (s *stockTakeService) reconciled(take models.StockTake, err error) (models.StockTake, error) {
+	if err != nil {
+		return take, err
+	}
+	take.Reconcile()
+	return take, nil
+}
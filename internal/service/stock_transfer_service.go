@@ -0,0 +1,222 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"kasir-api-go/internal/models"
+	"kasir-api-go/internal/repository"
+	"kasir-api-go/internal/utils"
+	"strings"
+	"time"
+)
+
+// StockTransferService moves goods between outlets. A user assigned to an
+// outlet can only draft, send and cancel transfers from it, and only
+// receive transfers at it.
+type StockTransferService interface {
+	// Create drafts a transfer from req.FromOutletID, or from the outlet
+	// with outletID when the request names none.
+	Create(req models.StockTransferRequest, actor models.User, outletID int) (models.StockTransfer, error)
+	GetAll(status models.StockTransferStatus, outletCode string) ([]models.StockTransfer, error)
+	GetByID(id int) (models.StockTransfer, error)
+	Update(id int, req models.StockTransferRequest, actor models.User) (models.StockTransfer, error)
+	Send(id int, actor models.User) (models.StockTransfer, error)
+	Receive(id int, req models.ReceiveStockTransferRequest, actor models.User) (models.StockTransfer, error)
+	Cancel(id int, actor models.User) (models.StockTransfer, error)
+	// GetDiscrepancyReport reports the discrepancies of the transfers
+	// received between startDate and endDate, both inclusive.
+	GetDiscrepancyReport(startDate, endDate time.Time, outletCode string) (models.TransferDiscrepancyReport, error)
+}
+
+type stockTransferService struct {
+	repo          repository.StockTransferRepository
+	outletRepo    repository.OutletRepository
+	costingMethod models.CostingMethod
+}
+
+func NewStockTransferService(repo repository.StockTransferRepository, outletRepo repository.OutletRepository,
+	costingMethod models.CostingMethod) StockTransferService {
+	return &stockTransferService{
+		repo:          repo,
+		outletRepo:    outletRepo,
+		costingMethod: costingMethod,
+	}
+}
+
+func (s *stockTransferService) Create(req models.StockTransferRequest, actor models.User, outletID int) (models.StockTransfer, error) {
+	transfer, err := s.transferFromRequest(req, outletID, actor)
+	if err != nil {
+		return models.StockTransfer{}, err
+	}
+	return s.repo.Create(transfer, actor.ID)
+}
+
+func (s *stockTransferService) GetAll(status models.StockTransferStatus, outletCode string) ([]models.StockTransfer, error) {
+	return s.repo.GetAll(status, outletCode)
+}
+
+func (s *stockTransferService) GetByID(id int) (models.StockTransfer, error) {
+	return s.repo.GetByID(id)
+}
+
+func (s *stockTransferService) Update(id int, req models.StockTransferRequest, actor models.User) (models.StockTransfer, error) {
+	current, err := s.repo.GetByID(id)
+	if err != nil {
+		return models.StockTransfer{}, err
+	}
+	if err := checkAssignedOutlet(actor, current.FromOutletID); err != nil {
+		return models.StockTransfer{}, err
+	}
+
+	transfer, err := s.transferFromRequest(req, current.FromOutletID, actor)
+	if err != nil {
+		return models.StockTransfer{}, err
+	}
+	return s.repo.Update(id, transfer)
+}
+
+func (s *stockTransferService) Send(id int, actor models.User) (models.StockTransfer, error) {
+	transfer, err := s.repo.GetByID(id)
+	if err != nil {
+		return models.StockTransfer{}, err
+	}
+	if err := checkAssignedOutlet(actor, transfer.FromOutletID); err != nil {
+		return models.StockTransfer{}, err
+	}
+	return s.repo.Send(id, s.costingMethod, actor.ID)
+}
+
+func (s *stockTransferService) Receive(id int, req models.ReceiveStockTransferRequest, actor models.User) (models.StockTransfer, error) {
+	transfer, err := s.repo.GetByID(id)
+	if err != nil {
+		return models.StockTransfer{}, err
+	}
+	if err := checkAssignedOutlet(actor, transfer.ToOutletID); err != nil {
+		return models.StockTransfer{}, err
+	}
+
+	seen := make(map[int]bool, len(req.Items))
+	for _, line := range req.Items {
+		if line.ProductID <= 0 {
+			return models.StockTransfer{}, fmt.Errorf("%w: product_id is required", utils.ErrInvalidTransferReceipt)
+		}
+		if line.ReceivedQuantity < 0 {
+			return models.StockTransfer{}, fmt.Errorf("%w: received_quantity cannot be negative", utils.ErrInvalidTransferReceipt)
+		}
+		if seen[line.ProductID] {
+			return models.StockTransfer{}, fmt.Errorf("%w: product %d is listed twice", utils.ErrInvalidTransferReceipt, line.ProductID)
+		}
+		seen[line.ProductID] = true
+	}
+	req.Note = strings.TrimSpace(req.Note)
+	return s.repo.Receive(id, req, actor.ID)
+}
+
+func (s *stockTransferService) Cancel(id int, actor models.User) (models.StockTransfer, error) {
+	transfer, err := s.repo.GetByID(id)
+	if err != nil {
+		return models.StockTransfer{}, err
+	}
+	if err := checkAssignedOutlet(actor, transfer.FromOutletID); err != nil {
+		return models.StockTransfer{}, err
+	}
+	return s.repo.Cancel(id)
+}
+
+func (s *stockTransferService) GetDiscrepancyReport(startDate, endDate time.Time, outletCode string) (models.TransferDiscrepancyReport, error) {
+	lines, err := s.repo.GetDiscrepancies(startDate, endDate.Add(24*time.Hour), outletCode)
+	if err != nil {
+		return models.TransferDiscrepancyReport{}, err
+	}
+
+	report := models.TransferDiscrepancyReport{
+		StartDate: startDate.Format("2006-01-02"),
+		EndDate:   endDate.Format("2006-01-02"),
+		Outlet:    outletCode,
+		Lines:     lines,
+	}
+	for _, line := range lines {
+		if line.Discrepancy < 0 {
+			report.ShortageQuantity -= line.Discrepancy
+			report.ShortageValue -= line.DiscrepancyValue
+		} else {
+			report.SurplusQuantity += line.Discrepancy
+			report.SurplusValue += line.DiscrepancyValue
+		}
+		report.NetValue += line.DiscrepancyValue
+	}
+	return report, nil
+}
+
+// transferFromRequest checks a transfer from req.FromOutletID, or from the
+// outlet with fromOutletID when the request names none.
+func (s *stockTransferService) transferFromRequest(req models.StockTransferRequest, fromOutletID int,
+	actor models.User) (models.StockTransfer, error) {
+	if req.FromOutletID != nil {
+		if err := s.checkOutletExists(*req.FromOutletID); err != nil {
+			return models.StockTransfer{}, err
+		}
+		fromOutletID = *req.FromOutletID
+	}
+	if err := checkAssignedOutlet(actor, fromOutletID); err != nil {
+		return models.StockTransfer{}, err
+	}
+
+	if req.ToOutletID <= 0 {
+		return models.StockTransfer{}, fmt.Errorf("%w: to_outlet_id is required", utils.ErrInvalidStockTransfer)
+	}
+	if req.ToOutletID == fromOutletID {
+		return models.StockTransfer{}, fmt.Errorf("%w: goods cannot be transferred to the outlet they are at", utils.ErrInvalidStockTransfer)
+	}
+	if err := s.checkOutletExists(req.ToOutletID); err != nil {
+		return models.StockTransfer{}, err
+	}
+
+	if len(req.Items) == 0 {
+		return models.StockTransfer{}, fmt.Errorf("%w: items are required", utils.ErrInvalidStockTransfer)
+	}
+	transfer := models.StockTransfer{
+		FromOutletID: fromOutletID,
+		ToOutletID:   req.ToOutletID,
+		Note:         strings.TrimSpace(req.Note),
+	}
+	seen := make(map[int]bool, len(req.Items))
+	for _, line := range req.Items {
+		if line.ProductID <= 0 {
+			return models.StockTransfer{}, fmt.Errorf("%w: product_id is required", utils.ErrInvalidStockTransfer)
+		}
+		if line.Quantity <= 0 {
+			return models.StockTransfer{}, fmt.Errorf("%w: quantity must be greater than zero", utils.ErrInvalidStockTransfer)
+		}
+		if seen[line.ProductID] {
+			return models.StockTransfer{}, fmt.Errorf("%w: product %d is listed twice", utils.ErrInvalidStockTransfer, line.ProductID)
+		}
+		seen[line.ProductID] = true
+
+		productID := line.ProductID
+		transfer.Items = append(transfer.Items, models.StockTransferItem{
+			ProductID: &productID,
+			Quantity:  line.Quantity,
+		})
+	}
+	return transfer, nil
+}
+
+func (s *stockTransferService) checkOutletExists(id int) error {
+	if _, err := s.outletRepo.GetByID(id); err != nil {
+		if errors.Is(err, utils.ErrOutletNotFound) {
+			return fmt.Errorf("%w: outlet %d not found", utils.ErrInvalidStockTransfer, id)
+		}
+		return err
+	}
+	return nil
+}
+
+// checkAssignedOutlet fails when actor is assigned to an outlet other than
+// the one with outletID.
+func checkAssignedOutlet(actor models.User, outletID int) error {
+	if actor.OutletID != nil && *actor.OutletID != outletID {
+		return utils.ErrOutletForbidden
+	}
+	return nil
+}
@@ -0,0 +1,65 @@
+package service
+
+import (
+	"fmt"
+	"kasir-api-go/internal/models"
+	"kasir-api-go/internal/repository"
+	"kasir-api-go/internal/utils"
+	"strings"
+)
+
+type SupplierService interface {
+	GetAll() ([]models.Supplier, error)
+	GetByID(id int) (models.Supplier, error)
+	Create(supplier models.Supplier) (models.Supplier, error)
+	Update(id int, supplier models.Supplier) (models.Supplier, error)
+	Delete(id int) error
+}
+
+type supplierService struct {
+	repo repository.SupplierRepository
+}
+
+func NewSupplierService(repo repository.SupplierRepository) SupplierService {
+	return &supplierService{repo: repo}
+}
+
+func (s *supplierService) GetAll() ([]models.Supplier, error) {
+	return s.repo.GetAll()
+}
+
+func (s *supplierService) GetByID(id int) (models.Supplier, error) {
+	return s.repo.GetByID(id)
+}
+
+func (s *supplierService) Create(supplier models.Supplier) (models.Supplier, error) {
+	supplier, err := normalizeSupplier(supplier)
+	if err != nil {
+		return models.Supplier{}, err
+	}
+	return s.repo.Create(supplier)
+}
+
+func (s *supplierService) Update(id int, supplier models.Supplier) (models.Supplier, error) {
+	supplier, err := normalizeSupplier(supplier)
+	if err != nil {
+		return models.Supplier{}, err
+	}
+	return s.repo.Update(id, supplier)
+}
+
+func (s *supplierService) Delete(id int) error {
+	return s.repo.Delete(id)
+}
+
+func normalizeSupplier(supplier models.Supplier) (models.Supplier, error) {
+	supplier.Name = strings.TrimSpace(supplier.Name)
+	supplier.ContactName = strings.TrimSpace(supplier.ContactName)
+	supplier.Phone = strings.TrimSpace(supplier.Phone)
+	supplier.Email = strings.TrimSpace(supplier.Email)
+	supplier.Address = strings.TrimSpace(supplier.Address)
+	if supplier.Name == "" {
+		return supplier, fmt.Errorf("%w: name is required", utils.ErrInvalidSupplier)
+	}
+	return supplier, nil
+}
@@ -0,0 +1,61 @@
+package service
+
+import (
+	"fmt"
+	"kasir-api-go/internal/models"
+	"kasir-api-go/internal/repository"
+	"kasir-api-go/internal/utils"
+	"strings"
+)
+
+type TaxRateService interface {
+	GetAll() ([]models.TaxRate, error)
+	GetByID(id int) (models.TaxRate, error)
+	Create(taxRate models.TaxRate) (models.TaxRate, error)
+	Update(id int, taxRate models.TaxRate) (models.TaxRate, error)
+	Delete(id int) error
+}
+
+type taxRateService struct {
+	repo repository.TaxRateRepository
+}
+
+func NewTaxRateService(repo repository.TaxRateRepository) TaxRateService {
+	return &taxRateService{repo: repo}
+}
+
+func (s *taxRateService) GetAll() ([]models.TaxRate, error) {
+	return s.repo.GetAll()
+}
+
+func (s *taxRateService) GetByID(id int) (models.TaxRate, error) {
+	return s.repo.GetByID(id)
+}
+
+func (s *taxRateService) Create(taxRate models.TaxRate) (models.TaxRate, error) {
+	if err := validateTaxRate(taxRate); err != nil {
+		return models.TaxRate{}, err
+	}
+	return s.repo.Create(taxRate)
+}
+
+func (s *taxRateService) Update(id int, taxRate models.TaxRate) (models.TaxRate, error) {
+	if err := validateTaxRate(taxRate); err != nil {
+		return models.TaxRate{}, err
+	}
+	return s.repo.Update(id, taxRate)
+}
+
+func (s *taxRateService) Delete(id int) error {
+	return s.repo.Delete(id)
+}
+
+func validateTaxRate(t models.TaxRate) error {
+	if strings.TrimSpace(t.Name) == "" {
+		return fmt.Errorf("%w: name is required", utils.ErrInvalidTaxRate)
+	}
+	if t.Rate < 0 || t.Rate > 100 {
+		return fmt.Errorf("%w: rate must be between 0 and 100", utils.ErrInvalidTaxRate)
+	}
+	return nil
+}
@@ -1,35 +1,114 @@
 package service
 
 import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
 	"errors"
+	"fmt"
 	"kasir-api-go/internal/models"
 	"kasir-api-go/internal/repository"
+	"kasir-api-go/internal/utils"
+	"sort"
+	"strings"
 )
 
 type TransactionService interface {
-	Checkout(items []models.CheckoutItem) (models.Transaction, error)
+	Checkout(req models.CheckoutRequest) (models.Transaction, error)
 	GetAllTransactions() ([]models.Transaction, error)
 	GetTransactionByID(id int) (models.Transaction, error)
+	// GetTransactionByInvoiceNumber looks the invoice number up among the
+	// sales of the outlet with the given code.
+	GetTransactionByInvoiceNumber(outletCode, invoiceNumber string) (models.Transaction, error)
+	VoidTransaction(id int, req models.VoidRequest) (models.Refund, error)
+	RefundTransaction(id int, req models.RefundRequest) (models.Refund, error)
 }
 
 type transactionService struct {
-	repo        repository.TransactionRepository
-	productRepo repository.ProductRepository
+	repo              repository.TransactionRepository
+	productRepo       repository.ProductRepository
+	authService       AuthService
+	serviceChargeRate float64
+	invoiceFormat     models.InvoiceFormat
+	discountThreshold float64
+	costingMethod     models.CostingMethod
 }
 
-func NewTransactionService(repo repository.TransactionRepository, productRepo repository.ProductRepository) TransactionService {
+func NewTransactionService(repo repository.TransactionRepository, productRepo repository.ProductRepository, authService AuthService,
+	serviceChargeRate float64, invoiceFormat models.InvoiceFormat, discountThreshold float64,
+	costingMethod models.CostingMethod) TransactionService {
 	return &transactionService{
-		repo:        repo,
-		productRepo: productRepo,
+		repo:              repo,
+		productRepo:       productRepo,
+		authService:       authService,
+		serviceChargeRate: serviceChargeRate,
+		invoiceFormat:     invoiceFormat,
+		discountThreshold: discountThreshold,
+		costingMethod:     costingMethod,
 	}
 }
 
-func (s *transactionService) Checkout(items []models.CheckoutItem) (models.Transaction, error) {
-	if len(items) == 0 {
+func (s *transactionService) Checkout(req models.CheckoutRequest) (models.Transaction, error) {
+	if len(req.Items) == 0 {
 		return models.Transaction{}, errors.New("transaction items cannot be empty")
 	}
+	for _, item := range req.Items {
+		if item.Quantity <= 0 {
+			return models.Transaction{}, utils.ErrInvalidItemQuantity
+		}
+	}
+
+	if req.IdempotencyKey != "" {
+		if len(req.IdempotencyKey) > 255 {
+			return models.Transaction{}, utils.ErrInvalidIdempotencyKey
+		}
+
+		// Fingerprint the request as sent, and the cart it checks out, so a
+		// reused key can be told apart from a genuine retry
+		body, err := json.Marshal(struct {
+			models.CheckoutRequest
+			CartID int `json:"cart_id,omitempty"`
+		}{req, req.CartID})
+		if err != nil {
+			return models.Transaction{}, err
+		}
+		sum := sha256.Sum256(body)
+		req.RequestHash = hex.EncodeToString(sum[:])
+	}
+
+	// A single payment is treated as a split tender with one line
+	if len(req.Payments) == 0 {
+		req.Payments = []models.CheckoutPayment{{
+			Method:    req.PaymentMethod,
+			Amount:    req.PaidAmount,
+			Reference: req.Reference,
+		}}
+	}
+
+	for _, p := range req.Payments {
+		if !p.Method.IsValid() {
+			return models.Transaction{}, utils.ErrInvalidPaymentMethod
+		}
+		if p.Amount <= 0 {
+			return models.Transaction{}, utils.ErrInvalidPaymentAmount
+		}
+	}
+
+	if err := s.resolveVariants(req.Items); err != nil {
+		return models.Transaction{}, err
+	}
+
+	approvals, err := s.authorizeOverrides(req)
+	if err != nil {
+		return models.Transaction{}, err
+	}
+
+	req.ServiceChargeRate = s.serviceChargeRate
+	req.CostingMethod = s.costingMethod
+	req.InvoiceFormat = s.invoiceFormat
+	req.Approvals = approvals
 
-	transaction, err := s.repo.CreateTransaction(items)
+	transaction, err := s.repo.CreateTransaction(req)
 	if err != nil {
 		return models.Transaction{}, err
 	}
@@ -37,6 +116,119 @@ func (s *transactionService) Checkout(items []models.CheckoutItem) (models.Trans
 	return *transaction, nil
 }
 
+// resolveVariants makes the items selling a variant sell it as a product of
+// its own. The product an item names with its variant must be the parent of
+// that variant.
+func (s *transactionService) resolveVariants(items []models.CheckoutItem) error {
+	for i, item := range items {
+		if item.VariantID == nil {
+			continue
+		}
+		variant, found := s.productRepo.GetByID(*item.VariantID)
+		if !found || variant.ParentID == nil {
+			return fmt.Errorf("%w: variant %d not found", utils.ErrInvalidVariant, *item.VariantID)
+		}
+		if item.ProductID != 0 && item.ProductID != *variant.ParentID {
+			return fmt.Errorf("%w: variant %d is not a variant of product %d", utils.ErrInvalidVariant, variant.ID, item.ProductID)
+		}
+		items[i].ProductID = variant.ID
+		items[i].VariantID = nil
+	}
+	return nil
+}
+
+// authorizeOverrides checks the price overrides and the manual discount of a
+// checkout and returns the approvals to record with the sale. The basket is
+// valued at the current prices; the repository still checks the products
+// under lock.
+func (s *transactionService) authorizeOverrides(req models.CheckoutRequest) ([]models.Approval, error) {
+	if req.ManualDiscount < 0 {
+		return nil, fmt.Errorf("%w: manual discount cannot be negative", utils.ErrInvalidOverride)
+	}
+
+	overrides := make(map[int]int)
+	quantities := make(map[int]int)
+	for _, item := range req.Items {
+		quantities[item.ProductID] += item.Quantity
+		if item.UnitPrice == nil {
+			continue
+		}
+		if *item.UnitPrice < 0 {
+			return nil, fmt.Errorf("%w: unit price cannot be negative", utils.ErrInvalidOverride)
+		}
+		if price, ok := overrides[item.ProductID]; ok && price != *item.UnitPrice {
+			return nil, fmt.Errorf("%w: product %d has two different unit prices", utils.ErrInvalidOverride, item.ProductID)
+		}
+		overrides[item.ProductID] = *item.UnitPrice
+	}
+	if len(overrides) == 0 && req.ManualDiscount == 0 {
+		return nil, nil
+	}
+
+	productIDs := make([]int, 0, len(quantities))
+	for id := range quantities {
+		productIDs = append(productIDs, id)
+	}
+	sort.Ints(productIDs)
+
+	gross := 0
+	var changes []string
+	for _, id := range productIDs {
+		product, found := s.productRepo.GetByID(id)
+		if !found {
+			continue
+		}
+		price := product.Price
+		if override, ok := overrides[id]; ok && override != product.Price {
+			changes = append(changes, fmt.Sprintf("%s: %d -> %d", product.Name, product.Price, override))
+			price = override
+		}
+		gross += price * quantities[id]
+	}
+
+	var approvals []models.Approval
+	if len(changes) > 0 {
+		approver, tokenID, err := s.authService.Authorize(req.Actor, models.PermissionPricesOverride, req.Approval)
+		if err != nil {
+			return nil, err
+		}
+		approvals = append(approvals, newApproval(models.ApprovalActionPriceOverride, req.Actor, approver, tokenID, strings.Join(changes, "; ")))
+	}
+
+	if req.ManualDiscount > 0 && float64(req.ManualDiscount*100) > s.discountThreshold*float64(gross) {
+		approver, tokenID, err := s.authService.Authorize(req.Actor, models.PermissionDiscountsLarge, req.Approval)
+		if err != nil {
+			return nil, err
+		}
+		details := fmt.Sprintf("Manual discount %d on a basket of %d", req.ManualDiscount, gross)
+		approvals = append(approvals, newApproval(models.ApprovalActionDiscount, req.Actor, approver, tokenID, details))
+	}
+
+	return approvals, nil
+}
+
+// reversalOutlet is the outlet whose sales actor can void and refund while
+// working at outlet. Users managing outlets can reverse the sales of any
+// outlet.
+func reversalOutlet(actor models.User, outlet models.Outlet) models.Outlet {
+	if actor.Can(models.PermissionOutletsManage) {
+		return models.Outlet{}
+	}
+	return outlet
+}
+
+func newApproval(action models.ApprovalAction, actor, approver models.User, tokenID, details string) models.Approval {
+	return models.Approval{
+		Action:        action,
+		RequestedByID: actor.ID,
+		RequestedBy:   actor.Username,
+		ApprovedByID:  approver.ID,
+		ApprovedBy:    approver.Username,
+		Details:       details,
+		TokenID:       tokenID,
+	}
+}
+
 func (s *transactionService) GetAllTransactions() ([]models.Transaction, error) {
 	return s.repo.GetAll()
 }
@@ -44,3 +236,49 @@ func (s *transactionService) GetAllTransactions() ([]models.Transaction, error)
 func (s *transactionService) GetTransactionByID(id int) (models.Transaction, error) {
 	return s.repo.GetByID(id)
 }
+
+func (s *transactionService) GetTransactionByInvoiceNumber(outletCode, invoiceNumber string) (models.Transaction, error) {
+	return s.repo.GetByInvoiceNumber(outletCode, invoiceNumber)
+}
+
+func (s *transactionService) VoidTransaction(id int, req models.VoidRequest) (models.Refund, error) {
+	if strings.TrimSpace(req.Reason) == "" {
+		return models.Refund{}, utils.ErrRefundReasonRequired
+	}
+	req.PerformedBy = req.Actor.Username
+	req.Outlet = reversalOutlet(req.Actor, req.Outlet)
+
+	approver, tokenID, err := s.authService.Authorize(req.Actor, models.PermissionSalesVoid, req.Approval)
+	if err != nil {
+		return models.Refund{}, err
+	}
+	req.Approvals = []models.Approval{newApproval(models.ApprovalActionVoid, req.Actor, approver, tokenID, req.Reason)}
+
+	refund, err := s.repo.VoidTransaction(id, req)
+	if err != nil {
+		return models.Refund{}, err
+	}
+
+	return *refund, nil
+}
+
+func (s *transactionService) RefundTransaction(id int, req models.RefundRequest) (models.Refund, error) {
+	if strings.TrimSpace(req.Reason) == "" {
+		return models.Refund{}, utils.ErrRefundReasonRequired
+	}
+	req.PerformedBy = req.Actor.Username
+	req.Outlet = reversalOutlet(req.Actor, req.Outlet)
+
+	approver, tokenID, err := s.authService.Authorize(req.Actor, models.PermissionSalesVoid, req.Approval)
+	if err != nil {
+		return models.Refund{}, err
+	}
+	req.Approvals = []models.Approval{newApproval(models.ApprovalActionRefund, req.Actor, approver, tokenID, req.Reason)}
+
+	refund, err := s.repo.RefundTransaction(id, req)
+	if err != nil {
+		return models.Refund{}, err
+	}
+
+	return *refund, nil
+}
@@ -0,0 +1,147 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"kasir-api-go/internal/models"
+	"kasir-api-go/internal/repository"
+	"kasir-api-go/internal/utils"
+	"regexp"
+	"strings"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+var (
+	usernamePattern = regexp.MustCompile(`^[a-z0-9._-]{3,50}$`)
+	pinPattern      = regexp.MustCompile(`^[0-9]{4,6}$`)
+)
+
+type UserService interface {
+	GetAll() ([]models.User, error)
+	GetByID(id int) (models.User, error)
+	Create(req models.CreateUserRequest) (models.User, error)
+	Update(id int, req models.UpdateUserRequest) (models.User, error)
+	Delete(id int) error
+}
+
+type userService struct {
+	repo       repository.UserRepository
+	roleRepo   repository.RoleRepository
+	outletRepo repository.OutletRepository
+}
+
+func NewUserService(repo repository.UserRepository, roleRepo repository.RoleRepository, outletRepo repository.OutletRepository) UserService {
+	return &userService{repo: repo, roleRepo: roleRepo, outletRepo: outletRepo}
+}
+
+func (s *userService) GetAll() ([]models.User, error) {
+	return s.repo.GetAll()
+}
+
+func (s *userService) GetByID(id int) (models.User, error) {
+	return s.repo.GetByID(id)
+}
+
+func (s *userService) Create(req models.CreateUserRequest) (models.User, error) {
+	user := models.User{
+		Username: strings.ToLower(strings.TrimSpace(req.Username)),
+		Name:     strings.TrimSpace(req.Name),
+		Role:     req.Role,
+		OutletID: req.OutletID,
+		Active:   true,
+	}
+	if !usernamePattern.MatchString(user.Username) {
+		return models.User{}, invalidUser("username must be 3-50 lowercase letters, digits, dots, dashes or underscores")
+	}
+	if req.Password == "" {
+		return models.User{}, invalidUser("password is required")
+	}
+	if err := s.setCredentials(&user, req.Password, req.PIN); err != nil {
+		return models.User{}, err
+	}
+	if err := s.validateProfile(user); err != nil {
+		return models.User{}, err
+	}
+
+	return s.repo.Create(user)
+}
+
+func (s *userService) Update(id int, req models.UpdateUserRequest) (models.User, error) {
+	user, err := s.repo.GetByID(id)
+	if err != nil {
+		return models.User{}, err
+	}
+
+	user.Name = strings.TrimSpace(req.Name)
+	user.Role = req.Role
+	user.OutletID = req.OutletID
+	if req.Active != nil {
+		user.Active = *req.Active
+	}
+	if err := s.setCredentials(&user, req.Password, req.PIN); err != nil {
+		return models.User{}, err
+	}
+	if err := s.validateProfile(user); err != nil {
+		return models.User{}, err
+	}
+
+	return s.repo.Update(id, user)
+}
+
+func (s *userService) Delete(id int) error {
+	return s.repo.Delete(id)
+}
+
+// setCredentials hashes the password and PIN that are given and keeps the
+// current ones otherwise.
+func (s *userService) setCredentials(user *models.User, password, pin string) error {
+	if password != "" {
+		if len(password) < 8 || len(password) > 72 {
+			return invalidUser("password must be 8-72 characters")
+		}
+		hash, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+		if err != nil {
+			return err
+		}
+		user.PasswordHash = string(hash)
+	}
+
+	if pin != "" {
+		if !pinPattern.MatchString(pin) {
+			return invalidUser("pin must be 4-6 digits")
+		}
+		hash, err := bcrypt.GenerateFromPassword([]byte(pin), bcrypt.DefaultCost)
+		if err != nil {
+			return err
+		}
+		user.PINHash = string(hash)
+	}
+
+	return nil
+}
+
+func (s *userService) validateProfile(user models.User) error {
+	if user.Name == "" {
+		return invalidUser("name is required")
+	}
+	_, err := s.roleRepo.GetByName(user.Role)
+	if errors.Is(err, utils.ErrRoleNotFound) {
+		return invalidUser(fmt.Sprintf("role %q does not exist", user.Role))
+	}
+	if err != nil {
+		return err
+	}
+
+	if user.OutletID != nil {
+		_, err = s.outletRepo.GetByID(*user.OutletID)
+		if errors.Is(err, utils.ErrOutletNotFound) {
+			return invalidUser(fmt.Sprintf("outlet %d does not exist", *user.OutletID))
+		}
+	}
+	return err
+}
+
+func invalidUser(reason string) error {
+	return fmt.Errorf("%w: %s", utils.ErrInvalidUser, reason)
+}
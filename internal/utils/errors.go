@@ -4,4 +4,99 @@ import "errors"
 
 var (
 	ErrEmptyDatabaseURL = errors.New("database url is empty")
+	ErrEmptyJWTSecret   = errors.New("jwt secret is empty")
+
+	ErrInvalidPaymentMethod = errors.New("invalid payment method")
+	ErrInvalidPaymentAmount = errors.New("payment amount must be greater than zero")
+	ErrInsufficientPayment  = errors.New("paid amount is less than total amount")
+	ErrNonCashOverpayment   = errors.New("non-cash payments cannot exceed total amount")
+
+	ErrInvalidItemQuantity = errors.New("item quantity must be greater than zero")
+
+	ErrInvalidIdempotencyKey  = errors.New("idempotency key must be at most 255 characters")
+	ErrIdempotencyKeyConflict = errors.New("idempotency key was already used with a different request")
+
+	ErrTransactionNotFound      = errors.New("transaction not found")
+	ErrTransactionNotRefundable = errors.New("transaction has already been voided or fully refunded")
+	ErrInvalidRefundQuantity    = errors.New("invalid refund quantity")
+	ErrRefundReasonRequired     = errors.New("reason is required")
+	ErrVoidNotToday             = errors.New("only sales of the current business day can be voided, refund the items instead")
+
+	ErrPromotionNotFound = errors.New("promotion not found")
+	ErrInvalidPromotion  = errors.New("invalid promotion")
+
+	ErrTaxRateNotFound = errors.New("tax rate not found")
+	ErrInvalidTaxRate  = errors.New("invalid tax rate")
+
+	ErrProductNotFound = errors.New("product not found")
+	ErrProductNotSaved = errors.New("product could not be saved")
+
+	ErrCartNotFound    = errors.New("cart not found")
+	ErrCartNotOpen     = errors.New("cart is not open")
+	ErrCartEmpty       = errors.New("cart has no items")
+	ErrCartOtherOutlet = errors.New("cart belongs to another outlet")
+	ErrInvalidCartItem = errors.New("invalid cart item")
+	ErrCartChanged     = errors.New("cart was changed during checkout")
+
+	ErrInvalidReceiptFormat = errors.New("format must be text, escpos or html")
+	ErrInvalidPaperWidth    = errors.New("paper width must be 58 or 80")
+
+	ErrShiftNotFound       = errors.New("shift not found")
+	ErrNoOpenShift         = errors.New("no shift is open")
+	ErrShiftAlreadyOpen    = errors.New("a shift is already open")
+	ErrShiftClosed         = errors.New("shift is already closed")
+	ErrShiftOtherOutlet    = errors.New("shift belongs to another outlet")
+	ErrInvalidShift        = errors.New("invalid shift")
+	ErrInvalidCashMovement = errors.New("cash movement type must be cash_in or cash_out with a positive amount and a reason")
+
+	ErrDayClosed       = errors.New("business day is already closed")
+	ErrClosingNotFound = errors.New("daily closing not found")
+	ErrInvalidClosing  = errors.New("invalid daily closing")
+
+	ErrUserNotFound       = errors.New("user not found")
+	ErrUsernameTaken      = errors.New("username is already taken")
+	ErrInvalidUser        = errors.New("invalid user")
+	ErrInvalidCredentials = errors.New("invalid username or password")
+	ErrAccountLocked      = errors.New("too many failed attempts, try again later")
+	ErrUnauthorized       = errors.New("missing or invalid token")
+	ErrForbidden          = errors.New("not allowed to access this resource")
+
+	ErrRoleNotFound = errors.New("role not found")
+	ErrRoleExists   = errors.New("role already exists")
+	ErrRoleInUse    = errors.New("role is assigned to users")
+	ErrRoleLocked   = errors.New("the owner role cannot be changed or deleted")
+	ErrInvalidRole  = errors.New("invalid role")
+
+	ErrApprovalRequired = errors.New("this action needs a supervisor approval")
+	ErrApprovalDenied   = errors.New("approval was not accepted")
+	ErrInvalidOverride  = errors.New("invalid price override or discount")
+
+	ErrStockTakeNotFound    = errors.New("stock take not found")
+	ErrStockTakeAlreadyOpen = errors.New("a stock take is already open")
+	ErrStockTakeNotOpen     = errors.New("stock take is already approved or cancelled")
+	ErrInvalidStockCount    = errors.New("invalid stock count")
+
+	ErrSupplierNotFound      = errors.New("supplier not found")
+	ErrSupplierInUse         = errors.New("supplier has purchase orders")
+	ErrInvalidSupplier       = errors.New("invalid supplier")
+	ErrPurchaseOrderNotFound = errors.New("purchase order not found")
+	ErrPurchaseOrderClosed   = errors.New("purchase order is already received or cancelled")
+	ErrInvalidPurchaseOrder  = errors.New("invalid purchase order")
+	ErrInvalidGoodsReceipt   = errors.New("invalid goods receipt")
+	ErrInvalidPurchaseReturn = errors.New("invalid purchase return")
+
+	ErrInvalidProfitGrouping = errors.New("group_by must be product, variant, category or day")
+
+	ErrOutletNotFound  = errors.New("outlet not found")
+	ErrOutletExists    = errors.New("outlet code is already taken")
+	ErrInvalidOutlet   = errors.New("invalid outlet")
+	ErrOutletForbidden = errors.New("user is assigned to another outlet")
+
+	ErrStockTransferNotFound  = errors.New("stock transfer not found")
+	ErrStockTransferNotDraft  = errors.New("stock transfer has already been sent or cancelled")
+	ErrStockTransferNotSent   = errors.New("stock transfer is not in transit")
+	ErrInvalidStockTransfer   = errors.New("invalid stock transfer")
+	ErrInvalidTransferReceipt = errors.New("invalid transfer receipt")
+
+	ErrInvalidVariant = errors.New("invalid product variant")
 )
@@ -0,0 +1,17 @@
+-- Add tendered and change amounts to transactions
+ALTER TABLE transactions ADD COLUMN IF NOT EXISTS paid_amount INT NOT NULL DEFAULT 0;
+ALTER TABLE transactions ADD COLUMN IF NOT EXISTS change_amount INT NOT NULL DEFAULT 0;
+
+-- Create transaction_payments table
+CREATE TABLE IF NOT EXISTS transaction_payments (
+    id SERIAL PRIMARY KEY,
+    transaction_id INT REFERENCES transactions(id) ON DELETE CASCADE,
+    method VARCHAR(20) NOT NULL,
+    amount INT NOT NULL,
+    tendered_amount INT NOT NULL,
+    reference VARCHAR(100),
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+-- Create index for performance
+CREATE INDEX IF NOT EXISTS idx_transaction_payments_transaction_id ON transaction_payments(transaction_id);
@@ -0,0 +1,30 @@
+-- Track the lifecycle of a transaction
+ALTER TABLE transactions ADD COLUMN IF NOT EXISTS status VARCHAR(20) NOT NULL DEFAULT 'completed';
+ALTER TABLE transactions ADD COLUMN IF NOT EXISTS refunded_amount INT NOT NULL DEFAULT 0;
+ALTER TABLE transaction_details ADD COLUMN IF NOT EXISTS refunded_quantity INT NOT NULL DEFAULT 0;
+
+-- Create transaction_refunds table
+CREATE TABLE IF NOT EXISTS transaction_refunds (
+    id SERIAL PRIMARY KEY,
+    transaction_id INT REFERENCES transactions(id) ON DELETE CASCADE,
+    type VARCHAR(20) NOT NULL,
+    amount INT NOT NULL,
+    reason TEXT NOT NULL,
+    performed_by VARCHAR(100) NOT NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+-- Create transaction_refund_items table
+CREATE TABLE IF NOT EXISTS transaction_refund_items (
+    id SERIAL PRIMARY KEY,
+    refund_id INT REFERENCES transaction_refunds(id) ON DELETE CASCADE,
+    transaction_detail_id INT REFERENCES transaction_details(id) ON DELETE CASCADE,
+    product_id INT,
+    quantity INT NOT NULL,
+    amount INT NOT NULL
+);
+
+-- Create index for performance
+CREATE INDEX IF NOT EXISTS idx_transactions_status ON transactions(status);
+CREATE INDEX IF NOT EXISTS idx_transaction_refunds_transaction_id ON transaction_refunds(transaction_id);
+CREATE INDEX IF NOT EXISTS idx_transaction_refund_items_refund_id ON transaction_refund_items(refund_id);
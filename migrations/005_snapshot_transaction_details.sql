@@ -0,0 +1,16 @@
+-- Snapshot the product name and unit price on each sold line
+ALTER TABLE transaction_details ADD COLUMN IF NOT EXISTS product_name VARCHAR(255) NOT NULL DEFAULT '';
+ALTER TABLE transaction_details ADD COLUMN IF NOT EXISTS unit_price INT NOT NULL DEFAULT 0;
+
+-- Backfill existing rows from the current catalog
+UPDATE transaction_details td
+SET product_name = p.name,
+    unit_price = CASE WHEN td.quantity > 0 THEN td.subtotal / td.quantity ELSE p.price END
+FROM products p
+WHERE td.product_id = p.id AND td.product_name = '';
+
+-- Keep historical lines when a product is deleted
+ALTER TABLE transaction_details DROP CONSTRAINT IF EXISTS transaction_details_product_id_fkey;
+ALTER TABLE transaction_details
+    ADD CONSTRAINT transaction_details_product_id_fkey
+    FOREIGN KEY (product_id) REFERENCES products(id) ON DELETE SET NULL;
@@ -0,0 +1,41 @@
+-- Create promotions table
+CREATE TABLE IF NOT EXISTS promotions (
+    id SERIAL PRIMARY KEY,
+    name VARCHAR(255) NOT NULL,
+    type VARCHAR(20) NOT NULL,
+    scope VARCHAR(20) NOT NULL,
+    value INT NOT NULL DEFAULT 0,
+    buy_quantity INT NOT NULL DEFAULT 0,
+    free_quantity INT NOT NULL DEFAULT 0,
+    min_purchase INT NOT NULL DEFAULT 0,
+    product_id INT REFERENCES products(id) ON DELETE CASCADE,
+    category_id INT REFERENCES categories(id) ON DELETE CASCADE,
+    start_date TIMESTAMP,
+    end_date TIMESTAMP,
+    start_time VARCHAR(5),
+    end_time VARCHAR(5),
+    active BOOLEAN NOT NULL DEFAULT TRUE,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+-- Store gross and discount amounts on transactions and their lines
+ALTER TABLE transactions ADD COLUMN IF NOT EXISTS gross_amount INT NOT NULL DEFAULT 0;
+ALTER TABLE transactions ADD COLUMN IF NOT EXISTS discount_amount INT NOT NULL DEFAULT 0;
+ALTER TABLE transaction_details ADD COLUMN IF NOT EXISTS discount_amount INT NOT NULL DEFAULT 0;
+
+UPDATE transactions SET gross_amount = total_amount WHERE gross_amount = 0;
+
+-- Create transaction_discounts table
+CREATE TABLE IF NOT EXISTS transaction_discounts (
+    id SERIAL PRIMARY KEY,
+    transaction_id INT REFERENCES transactions(id) ON DELETE CASCADE,
+    transaction_detail_id INT REFERENCES transaction_details(id) ON DELETE CASCADE,
+    promotion_id INT REFERENCES promotions(id) ON DELETE SET NULL,
+    promotion_name VARCHAR(255) NOT NULL,
+    amount INT NOT NULL
+);
+
+-- Create index for performance
+CREATE INDEX IF NOT EXISTS idx_promotions_active ON promotions(active);
+CREATE INDEX IF NOT EXISTS idx_transaction_discounts_transaction_id ON transaction_discounts(transaction_id);
@@ -0,0 +1,29 @@
+-- Create tax_rates table
+CREATE TABLE IF NOT EXISTS tax_rates (
+    id SERIAL PRIMARY KEY,
+    name VARCHAR(100) NOT NULL,
+    rate NUMERIC(5, 2) NOT NULL,
+    inclusive BOOLEAN NOT NULL DEFAULT FALSE,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+-- Assign tax rates per product or per category
+ALTER TABLE products ADD COLUMN IF NOT EXISTS tax_rate_id INT REFERENCES tax_rates(id) ON DELETE SET NULL;
+ALTER TABLE categories ADD COLUMN IF NOT EXISTS tax_rate_id INT REFERENCES tax_rates(id) ON DELETE SET NULL;
+
+-- Store subtotal, tax and service charge separately
+ALTER TABLE transactions ADD COLUMN IF NOT EXISTS subtotal_amount INT NOT NULL DEFAULT 0;
+ALTER TABLE transactions ADD COLUMN IF NOT EXISTS tax_amount INT NOT NULL DEFAULT 0;
+ALTER TABLE transactions ADD COLUMN IF NOT EXISTS service_charge INT NOT NULL DEFAULT 0;
+ALTER TABLE transaction_details ADD COLUMN IF NOT EXISTS tax_name VARCHAR(100) NOT NULL DEFAULT '';
+ALTER TABLE transaction_details ADD COLUMN IF NOT EXISTS tax_rate NUMERIC(5, 2) NOT NULL DEFAULT 0;
+ALTER TABLE transaction_details ADD COLUMN IF NOT EXISTS tax_amount INT NOT NULL DEFAULT 0;
+ALTER TABLE transaction_details ADD COLUMN IF NOT EXISTS service_charge INT NOT NULL DEFAULT 0;
+
+UPDATE transactions SET subtotal_amount = total_amount WHERE subtotal_amount = 0;
+
+-- Seed PPN
+INSERT INTO tax_rates (name, rate, inclusive)
+SELECT 'PPN', 11, FALSE
+WHERE NOT EXISTS (SELECT 1 FROM tax_rates WHERE name = 'PPN');
@@ -0,0 +1,11 @@
+-- Create idempotency_keys table to replay retried checkouts
+CREATE TABLE IF NOT EXISTS idempotency_keys (
+    key VARCHAR(255) PRIMARY KEY,
+    request_hash VARCHAR(64) NOT NULL,
+    transaction_id INT REFERENCES transactions(id) ON DELETE CASCADE,
+    response JSONB NOT NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+-- Create index for performance
+CREATE INDEX IF NOT EXISTS idx_idempotency_keys_transaction_id ON idempotency_keys(transaction_id);
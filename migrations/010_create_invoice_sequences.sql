@@ -0,0 +1,28 @@
+-- Create invoice_sequences table holding the last invoice number per outlet per day
+CREATE TABLE IF NOT EXISTS invoice_sequences (
+    outlet_code VARCHAR(20) NOT NULL,
+    business_date DATE NOT NULL,
+    last_number INT NOT NULL,
+    PRIMARY KEY (outlet_code, business_date)
+);
+
+-- Number transactions with the default INV/{date}/{seq} format
+ALTER TABLE transactions ADD COLUMN IF NOT EXISTS outlet_code VARCHAR(20) NOT NULL DEFAULT 'MAIN';
+ALTER TABLE transactions ADD COLUMN IF NOT EXISTS invoice_number VARCHAR(50);
+
+UPDATE transactions t
+SET invoice_number = 'INV/' || to_char(n.created_at, 'YYYYMMDD') || '/' || lpad(n.seq::text, 4, '0')
+FROM (
+    SELECT id, created_at, ROW_NUMBER() OVER (PARTITION BY outlet_code, created_at::date ORDER BY id) AS seq
+    FROM transactions
+) n
+WHERE t.id = n.id AND t.invoice_number IS NULL;
+
+INSERT INTO invoice_sequences (outlet_code, business_date, last_number)
+SELECT outlet_code, created_at::date, COUNT(*) FROM transactions GROUP BY outlet_code, created_at::date
+ON CONFLICT (outlet_code, business_date) DO NOTHING;
+
+ALTER TABLE transactions ALTER COLUMN invoice_number SET NOT NULL;
+
+-- Invoice numbers are unique per outlet
+CREATE UNIQUE INDEX IF NOT EXISTS idx_transactions_outlet_invoice_number ON transactions(outlet_code, invoice_number);
@@ -0,0 +1,36 @@
+-- Create shifts table
+CREATE TABLE IF NOT EXISTS shifts (
+    id SERIAL PRIMARY KEY,
+    outlet_code VARCHAR(20) NOT NULL,
+    cashier_name VARCHAR(100) NOT NULL,
+    status VARCHAR(20) NOT NULL DEFAULT 'open',
+    opening_float INT NOT NULL DEFAULT 0,
+    expected_cash INT,
+    counted_cash INT,
+    cash_difference INT,
+    note TEXT NOT NULL DEFAULT '',
+    opened_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    closed_at TIMESTAMP
+);
+
+-- Only one shift can be open per outlet
+CREATE UNIQUE INDEX IF NOT EXISTS idx_shifts_open_outlet ON shifts(outlet_code) WHERE status = 'open';
+
+-- Create shift_cash_movements table
+CREATE TABLE IF NOT EXISTS shift_cash_movements (
+    id SERIAL PRIMARY KEY,
+    shift_id INT REFERENCES shifts(id) ON DELETE CASCADE,
+    type VARCHAR(20) NOT NULL,
+    amount INT NOT NULL,
+    reason TEXT NOT NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+-- Link sales and refunds to the shift they were made in
+ALTER TABLE transactions ADD COLUMN IF NOT EXISTS shift_id INT REFERENCES shifts(id);
+ALTER TABLE transaction_refunds ADD COLUMN IF NOT EXISTS shift_id INT REFERENCES shifts(id);
+
+-- Create index for performance
+CREATE INDEX IF NOT EXISTS idx_shift_cash_movements_shift_id ON shift_cash_movements(shift_id);
+CREATE INDEX IF NOT EXISTS idx_transactions_shift_id ON transactions(shift_id);
+CREATE INDEX IF NOT EXISTS idx_transaction_refunds_shift_id ON transaction_refunds(shift_id);
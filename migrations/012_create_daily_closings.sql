@@ -0,0 +1,10 @@
+-- Create daily_closings table holding the frozen Z-report of each closed day
+CREATE TABLE IF NOT EXISTS daily_closings (
+    id SERIAL PRIMARY KEY,
+    outlet_code VARCHAR(20) NOT NULL,
+    business_date DATE NOT NULL,
+    closed_by VARCHAR(100) NOT NULL,
+    report JSONB NOT NULL,
+    closed_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE (outlet_code, business_date)
+);
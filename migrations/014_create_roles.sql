@@ -0,0 +1,21 @@
+-- Create roles table; users.role now refers to a role by name
+CREATE TABLE IF NOT EXISTS roles (
+    name VARCHAR(20) PRIMARY KEY,
+    description VARCHAR(255) NOT NULL DEFAULT '',
+    permissions TEXT[] NOT NULL DEFAULT '{}',
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+-- The owner always holds every permission, whatever is stored here
+INSERT INTO roles (name, description, permissions) VALUES
+    ('owner', 'Full access', '{}'),
+    ('manager', 'Runs the store: catalog, voids, closings and reports',
+        '{catalog.view,catalog.manage,sales.checkout,sales.void,shifts.operate,closings.manage,reports.view}'),
+    ('cashier', 'Sells at the till', '{catalog.view,sales.checkout,shifts.operate}')
+ON CONFLICT (name) DO NOTHING;
+
+-- Admins from the first users migration become owners
+UPDATE users SET role = 'owner' WHERE role = 'admin';
+
+ALTER TABLE users ADD CONSTRAINT users_role_fkey FOREIGN KEY (role) REFERENCES roles (name);
@@ -0,0 +1,19 @@
+-- Create approvals table, the audit trail of sensitive actions
+CREATE TABLE IF NOT EXISTS approvals (
+    id SERIAL PRIMARY KEY,
+    action VARCHAR(20) NOT NULL,
+    requested_by_id INT REFERENCES users(id) ON DELETE SET NULL,
+    requested_by VARCHAR(50) NOT NULL,
+    approved_by_id INT REFERENCES users(id) ON DELETE SET NULL,
+    approved_by VARCHAR(50) NOT NULL,
+    transaction_id INT REFERENCES transactions(id) ON DELETE CASCADE,
+    product_id INT REFERENCES products(id) ON DELETE SET NULL,
+    details TEXT NOT NULL DEFAULT '',
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_approvals_created_at ON approvals (created_at);
+
+-- Managers may override prices and give large discounts themselves
+UPDATE roles SET permissions = permissions || '{prices.override,discounts.large}'::TEXT[], updated_at = CURRENT_TIMESTAMP
+WHERE name = 'manager' AND NOT permissions @> '{prices.override}'::TEXT[];
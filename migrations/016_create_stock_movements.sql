@@ -0,0 +1,22 @@
+-- Create stock_movements table, the ledger of every change to products.stock
+CREATE TABLE IF NOT EXISTS stock_movements (
+    id SERIAL PRIMARY KEY,
+    product_id INT NOT NULL REFERENCES products(id) ON DELETE CASCADE,
+    delta INT NOT NULL,
+    balance_after INT NOT NULL,
+    reason VARCHAR(20) NOT NULL,
+    reference_type VARCHAR(30) NOT NULL DEFAULT '',
+    reference_id INT,
+    user_id INT REFERENCES users(id) ON DELETE SET NULL,
+    note VARCHAR(255) NOT NULL DEFAULT '',
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_stock_movements_product_id ON stock_movements (product_id, created_at);
+
+-- Open the ledger with the stock on hand, so it adds up to products.stock
+INSERT INTO stock_movements (product_id, delta, balance_after, reason, note)
+SELECT p.id, p.stock, p.stock, 'adjustment', 'Opening balance'
+FROM products p
+WHERE p.stock <> 0
+    AND NOT EXISTS (SELECT 1 FROM stock_movements m WHERE m.product_id = p.id);
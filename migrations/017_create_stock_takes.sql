@@ -0,0 +1,33 @@
+-- Create stock_takes table, the physical count sessions
+CREATE TABLE IF NOT EXISTS stock_takes (
+    id SERIAL PRIMARY KEY,
+    status VARCHAR(20) NOT NULL DEFAULT 'open',
+    note TEXT NOT NULL DEFAULT '',
+    opened_by_id INT REFERENCES users(id) ON DELETE SET NULL,
+    opened_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    closed_by_id INT REFERENCES users(id) ON DELETE SET NULL,
+    closed_at TIMESTAMP
+);
+
+-- Only one count can be open at a time
+CREATE UNIQUE INDEX IF NOT EXISTS idx_stock_takes_open ON stock_takes ((status)) WHERE status = 'open';
+
+-- Create stock_take_items table, the snapshot of each product and its count
+CREATE TABLE IF NOT EXISTS stock_take_items (
+    stock_take_id INT NOT NULL REFERENCES stock_takes(id) ON DELETE CASCADE,
+    product_id INT NOT NULL REFERENCES products(id) ON DELETE CASCADE,
+    product_name VARCHAR(255) NOT NULL,
+    system_quantity INT NOT NULL,
+    unit_value INT NOT NULL DEFAULT 0,
+    counted_quantity INT,
+    counted_by_id INT REFERENCES users(id) ON DELETE SET NULL,
+    counted_at TIMESTAMP,
+    PRIMARY KEY (stock_take_id, product_id)
+);
+
+-- Managers run stock takes, and cashiers help with the counting
+UPDATE roles SET permissions = permissions || '{inventory.count,inventory.manage}'::TEXT[], updated_at = CURRENT_TIMESTAMP
+WHERE name = 'manager' AND NOT permissions @> '{inventory.manage}'::TEXT[];
+
+UPDATE roles SET permissions = permissions || '{inventory.count}'::TEXT[], updated_at = CURRENT_TIMESTAMP
+WHERE name = 'cashier' AND NOT permissions @> '{inventory.count}'::TEXT[];
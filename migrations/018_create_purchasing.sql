@@ -0,0 +1,84 @@
+-- Create suppliers table
+CREATE TABLE IF NOT EXISTS suppliers (
+    id SERIAL PRIMARY KEY,
+    name VARCHAR(255) NOT NULL,
+    contact_name VARCHAR(255) NOT NULL DEFAULT '',
+    phone VARCHAR(50) NOT NULL DEFAULT '',
+    email VARCHAR(255) NOT NULL DEFAULT '',
+    address TEXT NOT NULL DEFAULT '',
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+-- Create purchase_orders table
+CREATE TABLE IF NOT EXISTS purchase_orders (
+    id SERIAL PRIMARY KEY,
+    supplier_id INT NOT NULL REFERENCES suppliers(id),
+    status VARCHAR(20) NOT NULL DEFAULT 'open',
+    expected_date DATE,
+    note TEXT NOT NULL DEFAULT '',
+    created_by_id INT REFERENCES users(id) ON DELETE SET NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+-- Create purchase_order_items table, one line per product
+CREATE TABLE IF NOT EXISTS purchase_order_items (
+    id SERIAL PRIMARY KEY,
+    purchase_order_id INT NOT NULL REFERENCES purchase_orders(id) ON DELETE CASCADE,
+    product_id INT REFERENCES products(id) ON DELETE SET NULL,
+    product_name VARCHAR(255) NOT NULL,
+    quantity INT NOT NULL,
+    unit_cost INT NOT NULL,
+    received_quantity INT NOT NULL DEFAULT 0,
+    returned_quantity INT NOT NULL DEFAULT 0,
+    UNIQUE (purchase_order_id, product_id)
+);
+
+-- Create goods_receipts table, each delivery received against a purchase order
+CREATE TABLE IF NOT EXISTS goods_receipts (
+    id SERIAL PRIMARY KEY,
+    purchase_order_id INT NOT NULL REFERENCES purchase_orders(id) ON DELETE CASCADE,
+    note TEXT NOT NULL DEFAULT '',
+    received_by_id INT REFERENCES users(id) ON DELETE SET NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE TABLE IF NOT EXISTS goods_receipt_items (
+    id SERIAL PRIMARY KEY,
+    goods_receipt_id INT NOT NULL REFERENCES goods_receipts(id) ON DELETE CASCADE,
+    product_id INT REFERENCES products(id) ON DELETE SET NULL,
+    product_name VARCHAR(255) NOT NULL,
+    quantity INT NOT NULL,
+    unit_cost INT NOT NULL
+);
+
+-- Create purchase_returns table, goods sent back to the supplier
+CREATE TABLE IF NOT EXISTS purchase_returns (
+    id SERIAL PRIMARY KEY,
+    purchase_order_id INT NOT NULL REFERENCES purchase_orders(id) ON DELETE CASCADE,
+    reason TEXT NOT NULL,
+    returned_by_id INT REFERENCES users(id) ON DELETE SET NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE TABLE IF NOT EXISTS purchase_return_items (
+    id SERIAL PRIMARY KEY,
+    purchase_return_id INT NOT NULL REFERENCES purchase_returns(id) ON DELETE CASCADE,
+    product_id INT REFERENCES products(id) ON DELETE SET NULL,
+    product_name VARCHAR(255) NOT NULL,
+    quantity INT NOT NULL,
+    unit_cost INT NOT NULL
+);
+
+-- Create index for performance
+CREATE INDEX IF NOT EXISTS idx_purchase_orders_supplier_id ON purchase_orders(supplier_id);
+CREATE INDEX IF NOT EXISTS idx_purchase_orders_status ON purchase_orders(status);
+CREATE INDEX IF NOT EXISTS idx_goods_receipts_purchase_order_id ON goods_receipts(purchase_order_id);
+CREATE INDEX IF NOT EXISTS idx_goods_receipt_items_goods_receipt_id ON goods_receipt_items(goods_receipt_id);
+CREATE INDEX IF NOT EXISTS idx_purchase_returns_purchase_order_id ON purchase_returns(purchase_order_id);
+CREATE INDEX IF NOT EXISTS idx_purchase_return_items_purchase_return_id ON purchase_return_items(purchase_return_id);
+
+-- Managers handle purchasing
+UPDATE roles SET permissions = permissions || '{purchasing.manage}'::TEXT[], updated_at = CURRENT_TIMESTAMP
+WHERE name = 'manager' AND NOT permissions @> '{purchasing.manage}'::TEXT[];
@@ -0,0 +1,27 @@
+-- Moving average purchase cost of each product
+ALTER TABLE products ADD COLUMN IF NOT EXISTS cost INT NOT NULL DEFAULT 0;
+
+-- Create stock_cost_layers table, the stock on hand per receipt for FIFO
+CREATE TABLE IF NOT EXISTS stock_cost_layers (
+    id SERIAL PRIMARY KEY,
+    product_id INT NOT NULL REFERENCES products(id) ON DELETE CASCADE,
+    stock_movement_id INT REFERENCES stock_movements(id) ON DELETE SET NULL,
+    unit_cost INT NOT NULL,
+    quantity INT NOT NULL,
+    remaining_quantity INT NOT NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_stock_cost_layers_open ON stock_cost_layers (product_id, id) WHERE remaining_quantity > 0;
+
+-- The stock on hand has no known cost yet; it is the oldest layer
+INSERT INTO stock_cost_layers (product_id, unit_cost, quantity, remaining_quantity)
+SELECT p.id, p.cost, p.stock, p.stock
+FROM products p
+WHERE p.stock > 0
+    AND NOT EXISTS (SELECT 1 FROM stock_cost_layers l WHERE l.product_id = p.id);
+
+-- Cost of goods sold per transaction line, and the part of it given back
+-- by refunds
+ALTER TABLE transaction_details ADD COLUMN IF NOT EXISTS cost_amount INT NOT NULL DEFAULT 0;
+ALTER TABLE transaction_refund_items ADD COLUMN IF NOT EXISTS cost_amount INT NOT NULL DEFAULT 0;
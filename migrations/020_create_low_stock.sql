@@ -0,0 +1,17 @@
+-- Reorder settings per product. A min_stock of 0 turns the alert off.
+ALTER TABLE products ADD COLUMN IF NOT EXISTS min_stock INT NOT NULL DEFAULT 0;
+ALTER TABLE products ADD COLUMN IF NOT EXISTS reorder_quantity INT NOT NULL DEFAULT 0;
+
+-- Create low_stock_events table, written by checkout when a sale takes a
+-- product below its minimum stock
+CREATE TABLE IF NOT EXISTS low_stock_events (
+    id SERIAL PRIMARY KEY,
+    product_id INT NOT NULL REFERENCES products(id) ON DELETE CASCADE,
+    product_name VARCHAR(255) NOT NULL,
+    stock INT NOT NULL,
+    min_stock INT NOT NULL,
+    transaction_id INT REFERENCES transactions(id) ON DELETE SET NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_low_stock_events_created_at ON low_stock_events (created_at DESC);
@@ -0,0 +1,27 @@
+-- Create stock_batches table, the stock on hand per lot with its expiry
+-- date. Stock without a known lot is kept as batches with no number and no
+-- expiry date.
+CREATE TABLE IF NOT EXISTS stock_batches (
+    id SERIAL PRIMARY KEY,
+    product_id INT NOT NULL REFERENCES products(id) ON DELETE CASCADE,
+    stock_movement_id INT REFERENCES stock_movements(id) ON DELETE SET NULL,
+    batch_number VARCHAR(100) NOT NULL DEFAULT '',
+    expiry_date DATE,
+    quantity INT NOT NULL,
+    remaining_quantity INT NOT NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_stock_batches_open ON stock_batches (product_id, expiry_date) WHERE remaining_quantity > 0;
+CREATE INDEX IF NOT EXISTS idx_stock_batches_expiry_date ON stock_batches (expiry_date) WHERE remaining_quantity > 0;
+
+-- The stock on hand has no known lot yet
+INSERT INTO stock_batches (product_id, quantity, remaining_quantity)
+SELECT p.id, p.stock, p.stock
+FROM products p
+WHERE p.stock > 0
+    AND NOT EXISTS (SELECT 1 FROM stock_batches b WHERE b.product_id = p.id);
+
+-- Lot received on each goods receipt line
+ALTER TABLE goods_receipt_items ADD COLUMN IF NOT EXISTS batch_number VARCHAR(100) NOT NULL DEFAULT '';
+ALTER TABLE goods_receipt_items ADD COLUMN IF NOT EXISTS expiry_date DATE;
@@ -0,0 +1,74 @@
+-- Create outlets table, the stores and warehouses holding stock. The code is
+-- what transactions, shifts and closings are recorded under.
+CREATE TABLE IF NOT EXISTS outlets (
+    id SERIAL PRIMARY KEY,
+    code VARCHAR(20) NOT NULL UNIQUE,
+    name VARCHAR(100) NOT NULL,
+    address TEXT NOT NULL DEFAULT '',
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+-- The outlet that sold the most so far holds the current stock, so it goes
+-- in first; then every other outlet code already in use
+INSERT INTO outlets (code, name)
+SELECT code, code FROM (
+    SELECT COALESCE((SELECT outlet_code FROM transactions GROUP BY outlet_code ORDER BY COUNT(*) DESC LIMIT 1), 'MAIN') AS code
+) home
+ON CONFLICT (code) DO NOTHING;
+
+INSERT INTO outlets (code, name)
+SELECT DISTINCT outlet_code, outlet_code FROM (
+    SELECT outlet_code FROM transactions
+    UNION SELECT outlet_code FROM shifts
+    UNION SELECT outlet_code FROM daily_closings
+) used
+ON CONFLICT (code) DO NOTHING;
+
+-- Stock of each product per outlet; products.stock stays the total
+CREATE TABLE IF NOT EXISTS outlet_stocks (
+    outlet_id INT NOT NULL REFERENCES outlets(id) ON DELETE CASCADE,
+    product_id INT NOT NULL REFERENCES products(id) ON DELETE CASCADE,
+    stock INT NOT NULL DEFAULT 0,
+    PRIMARY KEY (outlet_id, product_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_outlet_stocks_product_id ON outlet_stocks(product_id);
+
+INSERT INTO outlet_stocks (outlet_id, product_id, stock)
+SELECT (SELECT MIN(id) FROM outlets), p.id, p.stock
+FROM products p
+WHERE NOT EXISTS (SELECT 1 FROM outlet_stocks s WHERE s.product_id = p.id);
+
+-- Users can be assigned to the outlet they work at
+ALTER TABLE users ADD COLUMN IF NOT EXISTS outlet_id INT REFERENCES outlets(id) ON DELETE SET NULL;
+
+-- Everything that moves stock happens at an outlet
+ALTER TABLE stock_movements ADD COLUMN IF NOT EXISTS outlet_id INT REFERENCES outlets(id);
+UPDATE stock_movements SET outlet_id = (SELECT MIN(id) FROM outlets) WHERE outlet_id IS NULL;
+ALTER TABLE stock_movements ALTER COLUMN outlet_id SET NOT NULL;
+
+ALTER TABLE stock_batches ADD COLUMN IF NOT EXISTS outlet_id INT REFERENCES outlets(id) ON DELETE CASCADE;
+UPDATE stock_batches SET outlet_id = (SELECT MIN(id) FROM outlets) WHERE outlet_id IS NULL;
+ALTER TABLE stock_batches ALTER COLUMN outlet_id SET NOT NULL;
+DROP INDEX IF EXISTS idx_stock_batches_open;
+CREATE INDEX IF NOT EXISTS idx_stock_batches_open ON stock_batches (product_id, outlet_id, expiry_date) WHERE remaining_quantity > 0;
+
+ALTER TABLE low_stock_events ADD COLUMN IF NOT EXISTS outlet_id INT REFERENCES outlets(id) ON DELETE CASCADE;
+UPDATE low_stock_events SET outlet_id = (SELECT MIN(id) FROM outlets) WHERE outlet_id IS NULL;
+ALTER TABLE low_stock_events ALTER COLUMN outlet_id SET NOT NULL;
+
+ALTER TABLE purchase_orders ADD COLUMN IF NOT EXISTS outlet_id INT REFERENCES outlets(id);
+UPDATE purchase_orders SET outlet_id = (SELECT MIN(id) FROM outlets) WHERE outlet_id IS NULL;
+ALTER TABLE purchase_orders ALTER COLUMN outlet_id SET NOT NULL;
+
+-- One stock take can be open per outlet
+ALTER TABLE stock_takes ADD COLUMN IF NOT EXISTS outlet_id INT REFERENCES outlets(id);
+UPDATE stock_takes SET outlet_id = (SELECT MIN(id) FROM outlets) WHERE outlet_id IS NULL;
+ALTER TABLE stock_takes ALTER COLUMN outlet_id SET NOT NULL;
+DROP INDEX IF EXISTS idx_stock_takes_open;
+CREATE UNIQUE INDEX IF NOT EXISTS idx_stock_takes_open ON stock_takes (outlet_id) WHERE status = 'open';
+
+-- Managers set up outlets
+UPDATE roles SET permissions = permissions || '{outlets.manage}'::TEXT[], updated_at = CURRENT_TIMESTAMP
+WHERE name = 'manager' AND NOT permissions @> '{outlets.manage}'::TEXT[];
@@ -0,0 +1,46 @@
+-- Create stock_transfers table, goods moved from one outlet to another
+CREATE TABLE IF NOT EXISTS stock_transfers (
+    id SERIAL PRIMARY KEY,
+    from_outlet_id INT NOT NULL REFERENCES outlets(id),
+    to_outlet_id INT NOT NULL REFERENCES outlets(id),
+    status VARCHAR(20) NOT NULL DEFAULT 'draft',
+    note TEXT NOT NULL DEFAULT '',
+    created_by_id INT REFERENCES users(id) ON DELETE SET NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    sent_by_id INT REFERENCES users(id) ON DELETE SET NULL,
+    sent_at TIMESTAMP,
+    received_by_id INT REFERENCES users(id) ON DELETE SET NULL,
+    received_at TIMESTAMP,
+    receipt_note TEXT NOT NULL DEFAULT '',
+    CHECK (from_outlet_id <> to_outlet_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_stock_transfers_status ON stock_transfers(status);
+
+-- Create stock_transfer_items table. cost_amount is what the goods cost when
+-- they were sent, and they are received at that cost.
+CREATE TABLE IF NOT EXISTS stock_transfer_items (
+    id SERIAL PRIMARY KEY,
+    stock_transfer_id INT NOT NULL REFERENCES stock_transfers(id) ON DELETE CASCADE,
+    product_id INT REFERENCES products(id) ON DELETE SET NULL,
+    product_name VARCHAR(255) NOT NULL,
+    quantity INT NOT NULL CHECK (quantity > 0),
+    cost_amount INT NOT NULL DEFAULT 0,
+    received_quantity INT CHECK (received_quantity >= 0),
+    UNIQUE (stock_transfer_id, product_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_stock_transfer_items_product_id ON stock_transfer_items(product_id);
+
+-- Create stock_transfer_lots table, the batches the goods were taken from at
+-- the origin, so they arrive with the same batch number and expiry date
+CREATE TABLE IF NOT EXISTS stock_transfer_lots (
+    id SERIAL PRIMARY KEY,
+    stock_transfer_item_id INT NOT NULL REFERENCES stock_transfer_items(id) ON DELETE CASCADE,
+    batch_number VARCHAR(100) NOT NULL DEFAULT '',
+    expiry_date DATE,
+    quantity INT NOT NULL CHECK (quantity > 0)
+);
+
+CREATE INDEX IF NOT EXISTS idx_stock_transfer_lots_item_id ON stock_transfer_lots(stock_transfer_item_id);
@@ -0,0 +1,17 @@
+-- Products can be variants of a parent product, like a size and colour of a
+-- shirt. A variant is sold and stocked like any other product; its name is
+-- the parent's name followed by variant_name.
+ALTER TABLE products ADD COLUMN IF NOT EXISTS parent_id INT REFERENCES products(id) ON DELETE CASCADE;
+ALTER TABLE products ADD COLUMN IF NOT EXISTS variant_name VARCHAR(100) NOT NULL DEFAULT '';
+ALTER TABLE products ADD COLUMN IF NOT EXISTS sku VARCHAR(64);
+ALTER TABLE products ADD COLUMN IF NOT EXISTS barcode VARCHAR(64);
+
+CREATE INDEX IF NOT EXISTS idx_products_parent_id ON products(parent_id);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_products_variant_name ON products (parent_id, variant_name) WHERE parent_id IS NOT NULL;
+CREATE UNIQUE INDEX IF NOT EXISTS idx_products_sku ON products(sku) WHERE sku IS NOT NULL;
+CREATE UNIQUE INDEX IF NOT EXISTS idx_products_barcode ON products(barcode) WHERE barcode IS NOT NULL;
+
+-- Snapshot the parent of a variant on each sold line, so reports can roll
+-- variants up to it
+ALTER TABLE transaction_details ADD COLUMN IF NOT EXISTS parent_product_id INT REFERENCES products(id) ON DELETE SET NULL;
+ALTER TABLE transaction_details ADD COLUMN IF NOT EXISTS parent_product_name VARCHAR(255) NOT NULL DEFAULT '';
@@ -0,0 +1,10 @@
+-- Idempotency keys are scoped to the outlet and the user checking out, so a
+-- key reused at another outlet or by another cashier starts a new checkout
+-- instead of replaying someone else's. Keys stored before have no scope and
+-- are never matched again.
+ALTER TABLE idempotency_keys ADD COLUMN IF NOT EXISTS outlet_id INT REFERENCES outlets(id) ON DELETE CASCADE;
+ALTER TABLE idempotency_keys ADD COLUMN IF NOT EXISTS user_id INT REFERENCES users(id) ON DELETE CASCADE;
+
+ALTER TABLE idempotency_keys DROP CONSTRAINT IF EXISTS idempotency_keys_pkey;
+ALTER TABLE idempotency_keys ADD COLUMN IF NOT EXISTS id SERIAL PRIMARY KEY;
+CREATE UNIQUE INDEX IF NOT EXISTS idx_idempotency_keys_scope ON idempotency_keys (COALESCE(outlet_id, 0), COALESCE(user_id, 0), key);
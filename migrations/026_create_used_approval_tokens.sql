@@ -0,0 +1,6 @@
+-- Approval tokens already spent on an action, so each approves only one
+CREATE TABLE IF NOT EXISTS used_approval_tokens (
+    token_id VARCHAR(64) PRIMARY KEY,
+    approval_id INT REFERENCES approvals(id) ON DELETE SET NULL,
+    used_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
@@ -0,0 +1,13 @@
+-- Create transaction_detail_lots table, the batches the goods of a sale line
+-- were taken from, so returned goods go back into the same batches.
+-- returned_quantity is how much of the lot has been refunded so far.
+CREATE TABLE IF NOT EXISTS transaction_detail_lots (
+    id SERIAL PRIMARY KEY,
+    transaction_detail_id INT NOT NULL REFERENCES transaction_details(id) ON DELETE CASCADE,
+    batch_number VARCHAR(100) NOT NULL DEFAULT '',
+    expiry_date DATE,
+    quantity INT NOT NULL CHECK (quantity > 0),
+    returned_quantity INT NOT NULL DEFAULT 0 CHECK (returned_quantity BETWEEN 0 AND quantity)
+);
+
+CREATE INDEX IF NOT EXISTS idx_transaction_detail_lots_detail_id ON transaction_detail_lots(transaction_detail_id);
@@ -0,0 +1,8 @@
+-- Snapshot the product or variant SKU on each sold line for the receipt
+ALTER TABLE transaction_details ADD COLUMN IF NOT EXISTS sku VARCHAR(64) NOT NULL DEFAULT '';
+
+-- Backfill existing rows from the current catalog
+UPDATE transaction_details td
+SET sku = p.sku
+FROM products p
+WHERE td.product_id = p.id AND td.sku = '' AND p.sku IS NOT NULL;
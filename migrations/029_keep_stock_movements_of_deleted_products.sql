@@ -0,0 +1,14 @@
+-- Keep the stock ledger of deleted products: the movements lose the product
+-- reference but keep a snapshot of its name
+ALTER TABLE stock_movements ADD COLUMN IF NOT EXISTS product_name VARCHAR(255) NOT NULL DEFAULT '';
+
+UPDATE stock_movements m
+SET product_name = p.name
+FROM products p
+WHERE m.product_id = p.id AND m.product_name = '';
+
+ALTER TABLE stock_movements ALTER COLUMN product_id DROP NOT NULL;
+ALTER TABLE stock_movements DROP CONSTRAINT IF EXISTS stock_movements_product_id_fkey;
+ALTER TABLE stock_movements
+    ADD CONSTRAINT stock_movements_product_id_fkey
+    FOREIGN KEY (product_id) REFERENCES products(id) ON DELETE SET NULL;
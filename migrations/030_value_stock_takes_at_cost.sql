@@ -0,0 +1,5 @@
+-- Stock take variances are valued at cost: revalue the counts still open
+UPDATE stock_take_items i
+SET unit_value = p.cost
+FROM stock_takes s, products p
+WHERE s.id = i.stock_take_id AND s.status = 'open' AND p.id = i.product_id;
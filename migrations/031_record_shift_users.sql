@@ -0,0 +1,3 @@
+-- Record the signed-in user who opened a shift and who moved cash in its drawer
+ALTER TABLE shifts ADD COLUMN IF NOT EXISTS cashier_id INT REFERENCES users(id) ON DELETE SET NULL;
+ALTER TABLE shift_cash_movements ADD COLUMN IF NOT EXISTS user_id INT REFERENCES users(id) ON DELETE SET NULL;
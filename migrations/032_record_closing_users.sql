@@ -0,0 +1,2 @@
+-- Record the signed-in user who closed a day
+ALTER TABLE daily_closings ADD COLUMN IF NOT EXISTS closed_by_id INT REFERENCES users(id) ON DELETE SET NULL;
@@ -0,0 +1,5 @@
+-- A cart is checked out at the outlet it was opened at. Carts opened before
+-- belong to the first outlet, which holds the stock they were built from.
+ALTER TABLE carts ADD COLUMN IF NOT EXISTS outlet_id INT REFERENCES outlets(id) ON DELETE CASCADE;
+UPDATE carts SET outlet_id = (SELECT MIN(id) FROM outlets) WHERE outlet_id IS NULL;
+ALTER TABLE carts ALTER COLUMN outlet_id SET NOT NULL;